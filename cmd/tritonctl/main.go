@@ -0,0 +1,76 @@
+// Command tritonctl is the command line tool of triton-service-go.
+//
+// Usage:
+//
+//	tritonctl check-repo <model_repository>
+//
+// check-repo scans a local triton model repository and reports problems of model configs, version directories,
+// model files and ensemble references, it exits with status 1 if any problem is found.
+// Warnings (like config auto-completed by triton) are reported but do not fail the check.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+const usage string = `usage: tritonctl <command> [arguments]
+
+commands:
+  check-repo <model_repository>    scan local model repository and report problems
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch flag.Arg(0) {
+	case "check-repo":
+		os.Exit(checkRepo(flag.Args()[1:]))
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command: "+flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// checkRepo run check-repo subcommand and return exit code
+func checkRepo(args []string) int {
+	flagSet := flag.NewFlagSet("check-repo", flag.ExitOnError)
+	quiet := flagSet.Bool("q", false, "only print problems")
+	_ = flagSet.Parse(args)
+	if flagSet.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: tritonctl check-repo [-q] <model_repository>")
+		return 2
+	}
+	report, scanErr := nvidia_inferenceserver.ScanModelRepository(flagSet.Arg(0))
+	if scanErr != nil {
+		fmt.Fprintln(os.Stderr, scanErr)
+		return 2
+	}
+	if !*quiet {
+		for _, model := range report.Models {
+			fmt.Printf("model %s versions %v\n", model.Name, model.Versions)
+		}
+	}
+	for _, warning := range report.Warnings {
+		fmt.Println("warning: " + warning.String())
+	}
+	for _, problem := range report.Problems {
+		fmt.Println("problem: " + problem.String())
+	}
+	if !report.OK() {
+		fmt.Printf("%d problem(s) found in %s\n", len(report.Problems), report.Path)
+		return 1
+	}
+	if !*quiet {
+		fmt.Printf("%d model(s) checked, no problem found\n", len(report.Models))
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package bert
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBatcherMaxQueueDelay  time.Duration = 5 * time.Millisecond
+	DefaultBatcherRequestTimeout time.Duration = 10 * time.Second
+)
+
+var ErrBatcherClosed = errors.New("dynamic batcher is closed")
+
+// batchResult infer result of one item in batch
+type batchResult struct {
+	result interface{}
+	err    error
+}
+
+// batchItem single item request waiting in the batcher queue
+type batchItem struct {
+	ctx        context.Context
+	inferData  string
+	resultChan chan batchResult
+}
+
+// DynamicBatcher client side dynamic batcher of ModelService.
+// Concurrent single item requests of a model are queued and flushed as one batched infer call when max batch size
+// or max queue delay is reached, then the results of the batch are scattered back to every caller.
+// The infer callback (DecoderFunc) of ModelService must return one result per batch item in input order.
+// Settings should be changed before the first Infer call.
+type DynamicBatcher struct {
+	service        *ModelService
+	modelName      string
+	modelVersion   string
+	maxBatchSize   int
+	maxQueueDelay  time.Duration
+	requestTimeout time.Duration
+	params         []interface{}
+
+	queue     chan *batchItem
+	closeChan chan struct{}
+	startOnce sync.Once
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+///////////////////////////////////////////////// Batcher Setting API /////////////////////////////////////////////////
+
+// SetMaxBatchSize set max batch size, override the max_batch_size of model config
+func (b *DynamicBatcher) SetMaxBatchSize(maxBatchSize int) *DynamicBatcher {
+	if maxBatchSize > 0 {
+		b.maxBatchSize = maxBatchSize
+	}
+	return b
+}
+
+// SetMaxQueueDelay set max delay of the first queued request before the batch is flushed
+func (b *DynamicBatcher) SetMaxQueueDelay(maxQueueDelay time.Duration) *DynamicBatcher {
+	b.maxQueueDelay = maxQueueDelay
+	return b
+}
+
+// SetRequestTimeout set timeout of batched infer call
+func (b *DynamicBatcher) SetRequestTimeout(requestTimeout time.Duration) *DynamicBatcher {
+	b.requestTimeout = requestTimeout
+	return b
+}
+
+// GetMaxBatchSize get max batch size
+func (b *DynamicBatcher) GetMaxBatchSize() int { return b.maxBatchSize }
+
+// GetRequestTimeout get timeout of batched infer call
+func (b *DynamicBatcher) GetRequestTimeout() time.Duration { return b.requestTimeout }
+
+///////////////////////////////////////////////// Batcher Setting API /////////////////////////////////////////////////
+
+// start start batching loop, settings must not be changed after the first request
+func (b *DynamicBatcher) start() {
+	b.wg.Add(1)
+	go b.loop()
+}
+
+// Infer queue single item request and wait for the result of this item
+func (b *DynamicBatcher) Infer(ctx context.Context, inferData string) (interface{}, error) {
+	b.startOnce.Do(b.start)
+	item := &batchItem{ctx: ctx, inferData: inferData, resultChan: make(chan batchResult, 1)}
+	select {
+	case b.queue <- item:
+	case <-b.closeChan:
+		return nil, ErrBatcherClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case result := <-item.resultChan:
+		return result.result, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stop accepting requests, flush queued requests and wait for in-flight batches
+func (b *DynamicBatcher) Close() {
+	b.closeOnce.Do(func() { close(b.closeChan) })
+	// batcher which never starts will not start after closed
+	b.startOnce.Do(func() {})
+	b.wg.Wait()
+}
+
+// loop collect queued requests into batches
+func (b *DynamicBatcher) loop() {
+	defer b.wg.Done()
+
+	var (
+		batch  []*batchItem
+		timer  *time.Timer
+		timerC <-chan time.Time
+	)
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+		if len(batch) == 0 {
+			return
+		}
+		b.wg.Add(1)
+		go b.infer(batch)
+		batch = nil
+	}
+	for {
+		select {
+		case item := <-b.queue:
+			batch = append(batch, item)
+			if len(batch) >= b.maxBatchSize {
+				flush()
+			} else if timer == nil {
+				timer = time.NewTimer(b.maxQueueDelay)
+				timerC = timer.C
+			}
+		case <-timerC:
+			timer, timerC = nil, nil
+			flush()
+		case <-b.closeChan:
+			flush()
+			return
+		}
+	}
+}
+
+// infer issue one batched infer call and scatter results and errors to callers
+func (b *DynamicBatcher) infer(batch []*batchItem) {
+	defer b.wg.Done()
+
+	// requests canceled in queue are not sent
+	inferData := make([]string, 0, len(batch))
+	items := make([]*batchItem, 0, len(batch))
+	for _, item := range batch {
+		if itemErr := item.ctx.Err(); itemErr != nil {
+			item.resultChan <- batchResult{err: itemErr}
+			continue
+		}
+		inferData = append(inferData, item.inferData)
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.requestTimeout)
+	defer cancel()
+	results, inferErr := b.service.ModelInferCtx(ctx, inferData, b.modelName, b.modelVersion, b.params...)
+	if inferErr == nil && len(results) != len(items) {
+		inferErr = errors.New("infer callback should return one result per batch item")
+	}
+	for i, item := range items {
+		if inferErr != nil {
+			item.resultChan <- batchResult{err: inferErr}
+			continue
+		}
+		item.resultChan <- batchResult{result: results[i]}
+	}
+}
+
+// NewDynamicBatcher create dynamic batcher of model, max batch size is read from max_batch_size of model config,
+// model without batching support (max_batch_size is 0) use batch size 1.
+// requestTimeout is the timeout of model config request and every batched infer call (DefaultBatcherRequestTimeout
+// if not positive), infer timeout can be changed by SetRequestTimeout.
+// params are passed to every ModelInferCtx call of the batcher.
+func (m *ModelService) NewDynamicBatcher(
+	modelName, modelVersion string, requestTimeout time.Duration, params ...interface{},
+) (*DynamicBatcher, error) {
+	if requestTimeout <= 0 {
+		requestTimeout = DefaultBatcherRequestTimeout
+	}
+	modelConfig, configErr := m.tritonService.ModelConfiguration(modelName, modelVersion, requestTimeout)
+	if configErr != nil {
+		return nil, configErr
+	}
+	batcher := &DynamicBatcher{
+		service:        m,
+		modelName:      modelName,
+		modelVersion:   modelVersion,
+		maxBatchSize:   1,
+		maxQueueDelay:  DefaultBatcherMaxQueueDelay,
+		requestTimeout: requestTimeout,
+		params:         params,
+		queue:          make(chan *batchItem),
+		closeChan:      make(chan struct{}),
+	}
+	return batcher.SetMaxBatchSize(int(modelConfig.GetConfig().GetMaxBatchSize())), nil
+}
@@ -21,7 +21,18 @@ type InputFeature struct {
 type InputObjects struct {
 	Input    string
 	Tokens   []string
-	PosArray []OffsetsType
+	PosArray []OffsetsType // offsets of Input tokens
+	// PairInput / PairPosArray second text of sentence pair and offsets of its tokens (relative to PairInput)
+	PairInput    string
+	PairPosArray []OffsetsType
+	// Window sliding window of original input, only set in overflow mode
+	Window *InputWindow
+}
+
+// TextPair sentence pair input for cross-encoder / NLI / QA model
+type TextPair struct {
+	TextA string // segment_ids 0
+	TextB string // segment_ids 1
 }
 
 // HTTPBatchInput Model HTTP Batch Request Input Struct.(Support batch 1)
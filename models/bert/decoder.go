@@ -0,0 +1,353 @@
+package bert
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// PoolingStrategy how token embeddings are pooled into sentence embedding
+type PoolingStrategy int
+
+const (
+	PoolingCLS  PoolingStrategy = iota // embedding of [CLS] token
+	PoolingMean                        // mean of token embeddings with input mask
+)
+
+// LabelScore label with softmax score of sequence classification
+type LabelScore struct {
+	Index int
+	Label string
+	Score float32
+}
+
+// Entity merged entity of token classification
+type Entity struct {
+	Label string
+	Text  string
+	// TokenStart / TokenEnd token range of entity in tokens of Input (the whole Input in overflow mode), end is exclusive
+	TokenStart int
+	TokenEnd   int
+	Offsets    OffsetsType // character offsets in Input
+	Score      float32     // mean softmax score of entity tokens
+}
+
+// decoderInputObjects input objects passed by ModelService to DecoderFunc
+func decoderInputObjects(params []interface{}) ([]*InputObjects, error) {
+	if len(params) > 1 {
+		if inputObjects, ok := params[1].([]*InputObjects); ok {
+			return inputObjects, nil
+		}
+	}
+	return nil, errors.New("decoder should be used as infer callback of bert ModelService")
+}
+
+// decoderOutput float32 data of output with batch size as the first dim
+func decoderOutput(
+	result *nvidia_inferenceserver.InferResult, outputName string, batchSize, minDims int,
+) ([]float32, []int64, error) {
+	output, outputErr := result.Output(outputName)
+	if outputErr != nil {
+		return nil, nil, outputErr
+	}
+	if len(output.Shape) < minDims || output.Shape[0] != int64(batchSize) {
+		return nil, nil, errors.New("output " + outputName + " shape does not match batch size " + strconv.Itoa(batchSize))
+	}
+	data, decodeErr := output.AsFloat32()
+	if decodeErr != nil {
+		return nil, nil, decodeErr
+	}
+	return data, output.Shape, nil
+}
+
+// labelName label of index, index is used if label map has no such index
+func labelName(labels map[int]string, index int) string {
+	if label, ok := labels[index]; ok {
+		return label
+	}
+	return strconv.Itoa(index)
+}
+
+// softmax softmax of logits
+func softmax(logits []float32) []float32 {
+	scores := make([]float32, len(logits))
+	if len(logits) == 0 {
+		return scores
+	}
+	maxLogit := logits[0]
+	for _, logit := range logits[1:] {
+		if logit > maxLogit {
+			maxLogit = logit
+		}
+	}
+	var sum float64
+	for i, logit := range logits {
+		exp := math.Exp(float64(logit - maxLogit))
+		scores[i] = float32(exp)
+		sum += exp
+	}
+	for i := range scores {
+		scores[i] = float32(float64(scores[i]) / sum)
+	}
+	return scores
+}
+
+// NewClassificationDecoder decoder of sequence classification head, output logits shape is [batch, num_labels].
+// Result of every batch item is []LabelScore of top-k softmax scores in descending order,
+// labels is label map of logit index (like id2label), topK <= 0 returns all labels.
+func NewClassificationDecoder(outputName string, labels map[int]string, topK int) nvidia_inferenceserver.DecoderFunc {
+	return nvidia_inferenceserver.NewInferResultDecoder(
+		func(result *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			inputObjects, inputErr := decoderInputObjects(params)
+			if inputErr != nil {
+				return nil, inputErr
+			}
+			logits, shape, outputErr := decoderOutput(result, outputName, len(inputObjects), 2)
+			if outputErr != nil {
+				return nil, outputErr
+			}
+			numLabels := int(shape[len(shape)-1])
+			decoded := make([]interface{}, len(inputObjects))
+			for i := range decoded {
+				scores := softmax(logits[i*numLabels : (i+1)*numLabels])
+				labelScores := make([]LabelScore, numLabels)
+				for j, score := range scores {
+					labelScores[j] = LabelScore{Index: j, Label: labelName(labels, j), Score: score}
+				}
+				sort.SliceStable(labelScores, func(a, b int) bool { return labelScores[a].Score > labelScores[b].Score })
+				if topK > 0 && topK < numLabels {
+					labelScores = labelScores[:topK]
+				}
+				decoded[i] = labelScores
+			}
+			return decoded, nil
+		},
+	)
+}
+
+// splitTag split BIO / BIOES tag into prefix and entity label, tag without prefix is treated as I (IO scheme)
+func splitTag(tag string) (string, string) {
+	if tag == "O" || tag == "" {
+		return "O", ""
+	}
+	if len(tag) > 2 && (tag[1] == '-' || tag[1] == '_') && strings.ContainsRune("BIESLU", rune(tag[0])) {
+		switch prefix := tag[:1]; prefix {
+		case "L":
+			return "E", tag[2:]
+		case "U":
+			return "S", tag[2:]
+		default:
+			return prefix, tag[2:]
+		}
+	}
+	return "I", tag
+}
+
+// mergeEntities merge BIO / BIOES tags of tokens into entities, word piece tokens (##xx) extend the open entity
+func mergeEntities(inputObject *InputObjects, tokens []string, tags []string, scores []float32) []Entity {
+	var entities []Entity
+	var current *Entity
+	var scoreSum float32
+	// isEnded current entity is ended by S / E tag, only its word pieces can extend it
+	var isEnded bool
+	closeEntity := func() {
+		if current != nil {
+			current.Score = scoreSum / float32(current.TokenEnd-current.TokenStart)
+			entities = append(entities, *current)
+			current = nil
+		}
+	}
+	openEntity := func(label string, index int) {
+		closeEntity()
+		current = &Entity{Label: label, TokenStart: index, TokenEnd: index + 1}
+		scoreSum, isEnded = scores[index], false
+	}
+	for i, tag := range tags {
+		if strings.HasPrefix(tokens[i], NumPadToken) {
+			if current != nil && current.TokenEnd == i {
+				current.TokenEnd++
+				scoreSum += scores[i]
+			}
+			continue
+		}
+		prefix, label := splitTag(tag)
+		switch {
+		case prefix == "O":
+			closeEntity()
+		case prefix == "B" || prefix == "S":
+			openEntity(label, i)
+		case current != nil && !isEnded && current.Label == label && current.TokenEnd == i:
+			current.TokenEnd++
+			scoreSum += scores[i]
+		default:
+			openEntity(label, i)
+		}
+		if prefix == "S" || prefix == "E" {
+			isEnded = true
+		}
+	}
+	closeEntity()
+	// offsets are rune offsets
+	runes := []rune(inputObject.Input)
+	for i := range entities {
+		entity := &entities[i]
+		entity.Offsets = OffsetsType{
+			Start: inputObject.PosArray[entity.TokenStart].Start, End: inputObject.PosArray[entity.TokenEnd-1].End,
+		}
+		if entity.Offsets.End <= len(runes) {
+			entity.Text = string(runes[entity.Offsets.Start:entity.Offsets.End])
+		}
+		// tokens of window start at Window.TokenStart of Input in overflow mode
+		if window := inputObject.Window; window != nil && window.Segment == 0 {
+			entity.TokenStart += window.TokenStart
+			entity.TokenEnd += window.TokenStart
+		}
+	}
+	return entities
+}
+
+// NewTokenClassificationDecoder decoder of token classification (NER) head, output logits shape is
+// [batch, seq_len, num_labels]. Tags of tokens (argmax of logits, labels is label map like id2label) are merged
+// into entities by BIO / BIOES scheme, result of every batch item is []Entity mapped back to Input by
+// InputObjects.PosArray, so tokenizer offsets must be enabled (SetTokenizerReturnPosInfo).
+func NewTokenClassificationDecoder(outputName string, labels map[int]string) nvidia_inferenceserver.DecoderFunc {
+	return nvidia_inferenceserver.NewInferResultDecoder(
+		func(result *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			inputObjects, inputErr := decoderInputObjects(params)
+			if inputErr != nil {
+				return nil, inputErr
+			}
+			logits, shape, outputErr := decoderOutput(result, outputName, len(inputObjects), 3)
+			if outputErr != nil {
+				return nil, outputErr
+			}
+			seqLength, numLabels := int(shape[1]), int(shape[2])
+			decoded := make([]interface{}, len(inputObjects))
+			for i, inputObject := range inputObjects {
+				if inputObject.PosArray == nil {
+					return nil, errors.New("token classification decoder requires tokenizer offsets (SetTokenizerReturnPosInfo)")
+				}
+				// tokens of Input start after [CLS]
+				numTokens := len(inputObject.PosArray)
+				if numTokens+1 > seqLength {
+					numTokens = seqLength - 1
+				}
+				tags := make([]string, numTokens)
+				scores := make([]float32, numTokens)
+				for j := range tags {
+					offset := (i*seqLength + j + 1) * numLabels
+					tokenScores := softmax(logits[offset : offset+numLabels])
+					best := 0
+					for k, score := range tokenScores {
+						if score > tokenScores[best] {
+							best = k
+						}
+					}
+					tags[j], scores[j] = labelName(labels, best), tokenScores[best]
+				}
+				decoded[i] = mergeEntities(inputObject, inputObject.Tokens[1:numTokens+1], tags, scores)
+			}
+			return decoded, nil
+		},
+	)
+}
+
+// NewQuestionAnsweringDecoder decoder of extractive QA head, start / end logits shape is [batch, seq_len].
+// Result of every batch item is *AnswerSpan (nil if no candidate) with at most maxAnswerTokens tokens in TextB of
+// sentence pair (question, context), windows of overflow mode can be combined by WindowResults.BestSpan.
+// Tokenizer offsets must be enabled (SetTokenizerReturnPosInfo or overflow mode).
+func NewQuestionAnsweringDecoder(startOutputName, endOutputName string, maxAnswerTokens int) nvidia_inferenceserver.DecoderFunc {
+	return nvidia_inferenceserver.NewInferResultDecoder(
+		func(result *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			inputObjects, inputErr := decoderInputObjects(params)
+			if inputErr != nil {
+				return nil, inputErr
+			}
+			startLogits, shape, startErr := decoderOutput(result, startOutputName, len(inputObjects), 2)
+			if startErr != nil {
+				return nil, startErr
+			}
+			endLogits, _, endErr := decoderOutput(result, endOutputName, len(inputObjects), 2)
+			if endErr != nil {
+				return nil, endErr
+			}
+			seqLength := int(shape[1])
+			if len(startLogits) != len(endLogits) {
+				return nil, errors.New("start and end logits have different sizes")
+			}
+			decoded := make([]interface{}, len(inputObjects))
+			for i, inputObject := range inputObjects {
+				if inputObject.PosArray == nil {
+					return nil, errors.New("question answering decoder requires tokenizer offsets (SetTokenizerReturnPosInfo)")
+				}
+				decoded[i] = bestInputSpan(inputObject, &SpanLogits{
+					Start: startLogits[i*seqLength : (i+1)*seqLength],
+					End:   endLogits[i*seqLength : (i+1)*seqLength],
+				}, maxAnswerTokens)
+			}
+			return decoded, nil
+		},
+	)
+}
+
+// NewEmbeddingDecoder decoder of sentence embedding, output shape is [batch, seq_len, hidden] (token embeddings,
+// pooled by pooling strategy) or [batch, hidden] (already pooled). Result of every batch item is []float32,
+// embedding is L2 normalized if normalize is true.
+func NewEmbeddingDecoder(outputName string, pooling PoolingStrategy, normalize bool) nvidia_inferenceserver.DecoderFunc {
+	return nvidia_inferenceserver.NewInferResultDecoder(
+		func(result *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			inputObjects, inputErr := decoderInputObjects(params)
+			if inputErr != nil {
+				return nil, inputErr
+			}
+			data, shape, outputErr := decoderOutput(result, outputName, len(inputObjects), 2)
+			if outputErr != nil {
+				return nil, outputErr
+			}
+			hidden := int(shape[len(shape)-1])
+			seqLength := 1
+			if len(shape) == 3 {
+				seqLength = int(shape[1])
+			}
+			decoded := make([]interface{}, len(inputObjects))
+			for i, inputObject := range inputObjects {
+				tokenEmbeddings := data[i*seqLength*hidden : (i+1)*seqLength*hidden]
+				embedding := make([]float32, hidden)
+				if len(shape) == 2 || pooling == PoolingCLS {
+					copy(embedding, tokenEmbeddings[:hidden])
+				} else {
+					// input mask is 1 for tokens, padding tokens are empty
+					var count float32
+					for j := 0; j < seqLength && j < len(inputObject.Tokens) && inputObject.Tokens[j] != ""; j++ {
+						for k, value := range tokenEmbeddings[j*hidden : (j+1)*hidden] {
+							embedding[k] += value
+						}
+						count++
+					}
+					if count > 0 {
+						for k := range embedding {
+							embedding[k] /= count
+						}
+					}
+				}
+				if normalize {
+					var norm float64
+					for _, value := range embedding {
+						norm += float64(value) * float64(value)
+					}
+					if norm = math.Sqrt(norm); norm > 0 {
+						for k := range embedding {
+							embedding[k] = float32(float64(embedding[k]) / norm)
+						}
+					}
+				}
+				decoded[i] = embedding
+			}
+			return decoded, nil
+		},
+	)
+}
@@ -0,0 +1,131 @@
+package bert
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+const (
+	ModelBertModelAttentionMaskKey string = "attention_mask"
+	ModelBertModelTokenTypeIdsKey  string = "token_type_ids"
+)
+
+// FeatureField field of InputFeature which is sent as a model input tensor
+type FeatureField int
+
+const (
+	FeatureTokenIDs FeatureField = iota // InputFeature.TokenIDs, input_ids
+	FeatureMask                         // InputFeature.Mask, input_mask / attention_mask
+	FeatureTypeIDs                      // InputFeature.TypeIDs, segment_ids / token_type_ids
+)
+
+// String name of feature field
+func (f FeatureField) String() string {
+	switch f {
+	case FeatureTokenIDs:
+		return "TokenIDs"
+	case FeatureMask:
+		return "Mask"
+	case FeatureTypeIDs:
+		return "TypeIDs"
+	}
+	return "Unknown"
+}
+
+// defaultInputTensorFields input tensor names of google bert and huggingface exports
+func defaultInputTensorFields() map[string]FeatureField {
+	return map[string]FeatureField{
+		ModelBertModelInputIdsKey:      FeatureTokenIDs,
+		ModelBertModelInputMaskKey:     FeatureMask,
+		ModelBertModelAttentionMaskKey: FeatureMask,
+		ModelBertModelSegmentIdsKey:    FeatureTypeIDs,
+		ModelBertModelTokenTypeIdsKey:  FeatureTypeIDs,
+	}
+}
+
+// featureFieldData data of feature field
+func featureFieldData(feature *InputFeature, field FeatureField) []int32 {
+	switch field {
+	case FeatureTokenIDs:
+		return feature.TokenIDs
+	case FeatureMask:
+		return feature.Mask
+	case FeatureTypeIDs:
+		return feature.TypeIDs
+	}
+	return nil
+}
+
+// inputTensorField feature field of input tensor name
+func (m *ModelService) inputTensorField(tensorName string) (FeatureField, error) {
+	field, ok := m.inputTensorFields[tensorName]
+	if !ok {
+		return 0, errors.New("input tensor " + tensorName + " is not mapped to a feature field")
+	}
+	return field, nil
+}
+
+// SetInputTensorField map input tensor name to feature field, tensors are matched by name in any order,
+// so model inputs which are not needed (like segment_ids) can be omitted by input callback.
+// segment_ids / token_type_ids, input_ids and input_mask / attention_mask are mapped by default.
+func (m *ModelService) SetInputTensorField(tensorName string, field FeatureField) *ModelService {
+	m.inputTensorFields[tensorName] = field
+	return m
+}
+
+// SetInputTensorFields replace all input tensor name mappings
+func (m *ModelService) SetInputTensorFields(fields map[string]FeatureField) *ModelService {
+	m.inputTensorFields = make(map[string]FeatureField, len(fields))
+	for tensorName, field := range fields {
+		m.inputTensorFields[tensorName] = field
+	}
+	return m
+}
+
+// GetInputTensorFields Get input tensor name mappings
+func (m *ModelService) GetInputTensorFields() map[string]FeatureField {
+	fields := make(map[string]FeatureField, len(m.inputTensorFields))
+	for tensorName, field := range m.inputTensorFields {
+		fields[tensorName] = field
+	}
+	return fields
+}
+
+// SetInputTensorsFromModelMeta derive input tensors from model metadata, replace the input callback of ModelService.
+// Input names must be mapped (by default or SetInputTensorField) and datatypes must be INT32 or INT64,
+// input shape is [batchSize, maxSeqLength]. It should be called before infer.
+func (m *ModelService) SetInputTensorsFromModelMeta(
+	modelName, modelVersion string, requestTimeout time.Duration,
+) error {
+	modelMeta, metaErr := m.tritonService.ModelMetadataRequest(modelName, modelVersion, requestTimeout)
+	if metaErr != nil {
+		return metaErr
+	}
+	if len(modelMeta.Inputs) == 0 {
+		return errors.New("model " + modelName + " has no input")
+	}
+	inputs := make([]*nvidia_inferenceserver.ModelMetadataResponse_TensorMetadata, len(modelMeta.Inputs))
+	for i, input := range modelMeta.Inputs {
+		if _, fieldErr := m.inputTensorField(input.Name); fieldErr != nil {
+			return fieldErr
+		}
+		if input.Datatype != ModelInt32DataType && input.Datatype != ModelInt64DataType {
+			return errors.New("input tensor " + input.Name + " datatype " + input.Datatype + " is not INT32 or INT64")
+		}
+		inputs[i] = input
+	}
+	m.generateModelInferRequest = func(batchSize, maxSeqLength int) []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor {
+		inferInputs := make([]*nvidia_inferenceserver.ModelInferRequest_InferInputTensor, len(inputs))
+		for i, input := range inputs {
+			inferInputs[i] = &nvidia_inferenceserver.ModelInferRequest_InferInputTensor{
+				Name:     input.Name,
+				Datatype: input.Datatype,
+				Shape:    []int64{int64(batchSize), int64(maxSeqLength)},
+			}
+		}
+		return inferInputs
+	}
+	return nil
+}
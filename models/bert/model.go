@@ -1,7 +1,7 @@
 package bert
 
 import (
-	"encoding/binary"
+	"context"
 	"errors"
 	"strings"
 	"time"
@@ -27,6 +27,7 @@ const (
 
 type ModelService struct {
 	isGRPC                          bool
+	isHTTPBinary                    bool
 	isChinese                       bool
 	isReturnPosArray                bool
 	maxSeqLength                    int
@@ -37,13 +38,21 @@ type ModelService struct {
 	BertTokenizer                   *WordPieceTokenizer
 	generateModelInferRequest       GenerateModelInferRequest
 	generateModelInferOutputRequest GenerateModelInferOutputRequest
+	inputTensorFields               map[string]FeatureField
+	paddingStrategy                 PaddingStrategy
+	paddingMultiple                 int
+	paddingBuckets                  []int
+	modelSeqLength                  int
+	isOverflow                      bool
+	overflowStride                  int
 }
 
 ////////////////////////////////////////////////// Flag Switch API //////////////////////////////////////////////////
 
-// SetMaxSeqLength Set model infer max sequence length
+// SetMaxSeqLength Set model infer max sequence length, it overrides sequence length of SetSeqLengthFromModelConfig
 func (m *ModelService) SetMaxSeqLength(maxSeqLen int) *ModelService {
 	m.maxSeqLength = maxSeqLen
+	m.modelSeqLength = 0
 	return m
 }
 
@@ -71,6 +80,24 @@ func (m *ModelService) UnsetModelInferWithGRPC() *ModelService {
 	return m
 }
 
+// SetModelInferWithHTTPBinary Use http binary tensor data extension to call triton,
+// inferCallback will receive *nvidia_inferenceserver.ModelInferResponse like grpc.
+func (m *ModelService) SetModelInferWithHTTPBinary() *ModelService {
+	m.isHTTPBinary = true
+	return m
+}
+
+// UnsetModelInferWithHTTPBinary Un-use http binary tensor data extension to call triton
+func (m *ModelService) UnsetModelInferWithHTTPBinary() *ModelService {
+	m.isHTTPBinary = false
+	return m
+}
+
+// GetModelInferIsHTTPBinary Get isHTTPBinary flag
+func (m *ModelService) GetModelInferIsHTTPBinary() bool {
+	return m.isHTTPBinary
+}
+
 // GetModelInferIsGRPC Get isGRPC flag
 func (m *ModelService) GetModelInferIsGRPC() bool {
 	return m.isGRPC
@@ -124,12 +151,40 @@ func (m *ModelService) getTokenizerResultWithOffsets(inferData string) ([]string
 	return GetStrings(tokenizerResult), GetOffsets(tokenizerResult)
 }
 
-// getBertInputFeature Get Bert Feature (before Make HTTP or GRPC Request)
-func (m *ModelService) getBertInputFeature(inferData string) (*InputFeature, *InputObjects) {
-	// Replace BertDataSplitString Here, so the parts is 1, no need to use strings.Split and decrease a for-loop.
-	if strings.Index(inferData, DataSplitString) > 0 {
-		inferData = strings.ReplaceAll(inferData, DataSplitString, "")
+// splitTextPair split infer data into sentence pair by DataSplitString, like "text_a ||| text_b".
+// Infer data without DataSplitString is a single text.
+func splitTextPair(inferData string) []string {
+	if textA, textB, isPair := strings.Cut(inferData, DataSplitString); isPair {
+		return []string{textA, textB}
+	}
+	return []string{inferData}
+}
+
+// tokenizeTexts tokenize every text, offsets are nil if withOffsets is false
+func (m *ModelService) tokenizeTexts(texts []string, withOffsets bool) ([][]string, [][]OffsetsType) {
+	sequence := make([][]string, len(texts))
+	offsets := make([][]OffsetsType, len(texts))
+	for i, text := range texts {
+		if withOffsets {
+			sequence[i], offsets[i] = m.getTokenizerResultWithOffsets(text)
+		} else {
+			sequence[i] = m.getTokenizerResult(text)
+		}
 	}
+	return sequence, offsets
+}
+
+// getBertInputFeature Get Bert Feature (before Make HTTP or GRPC Request)
+// texts is a single text or a sentence pair, sentence pair is encoded as [CLS] A [SEP] B [SEP] with segment_ids 0 / 1.
+func (m *ModelService) getBertInputFeature(texts ...string) (*InputFeature, *InputObjects) {
+	sequence, offsets := m.tokenizeTexts(texts, m.isReturnPosArray)
+	return m.buildBertInputFeature(texts, sequence, offsets)
+}
+
+// buildBertInputFeature build Bert Feature of tokenized texts, offsets of tokens are reported if offsets is not nil
+func (m *ModelService) buildBertInputFeature(
+	texts []string, sequence [][]string, offsets [][]OffsetsType,
+) (*InputFeature, *InputObjects) {
 	// InputFeature
 	// feature.TypeIDs  == segment_ids
 	// feature.TokenIDs == input_ids
@@ -140,27 +195,32 @@ func (m *ModelService) getBertInputFeature(inferData string) (*InputFeature, *In
 		Mask:     make([]int32, m.maxSeqLength),
 		TypeIDs:  make([]int32, m.maxSeqLength),
 	}
-	inputObjects := &InputObjects{Input: inferData}
-	// inferData only a short text, so it`s length always 1.
-	// truncate w/ space for CLS/SEP, 1 for sequence length and 1 for the last index
-	sequence := make([][]string, 1)
-	if m.isReturnPosArray {
-		sequence[0], inputObjects.PosArray = m.getTokenizerResultWithOffsets(inferData)
-	} else {
-		sequence[0] = m.getTokenizerResult(inferData)
+	inputObjects := &InputObjects{Input: texts[0]}
+	// truncate w/ space for CLS and one SEP after every text, the longest text is trimmed first
+	sequence = utils.StringSliceTruncate(sequence, m.maxSeqLength-1-len(sequence))
+	pos := 0
+	appendToken := func(token string, typeID int32) {
+		feature.Tokens[pos] = token
+		feature.TokenIDs[pos] = int32(m.BertVocab.GetID(token))
+		feature.Mask[pos] = 1
+		feature.TypeIDs[pos] = typeID
+		pos++
 	}
-	sequence = utils.StringSliceTruncate(sequence, int(m.maxSeqLength)-2)
-	for i := 0; i <= len(sequence[0])+1; i++ {
-		feature.Mask[i] = 1
-		if i == 0 {
-			feature.TokenIDs[i] = int32(m.BertVocab.GetID(DefaultCLS))
-			feature.Tokens[i] = DefaultCLS
-		} else if i == len(sequence[0])+1 {
-			feature.TokenIDs[i] = int32(m.BertVocab.GetID(DefaultSEP))
-			feature.Tokens[i] = DefaultSEP
-		} else {
-			feature.TokenIDs[i] = int32(m.BertVocab.GetID(sequence[0][i-1]))
-			feature.Tokens[i] = sequence[0][i-1]
+	appendToken(DefaultCLS, 0)
+	for i, tokens := range sequence {
+		for _, token := range tokens {
+			appendToken(token, int32(i))
+		}
+		appendToken(DefaultSEP, int32(i))
+	}
+	// offsets are relative to their own text and trimmed like tokens
+	if offsets[0] != nil {
+		inputObjects.PosArray = offsets[0][:len(sequence[0])]
+	}
+	if len(texts) > 1 {
+		inputObjects.PairInput = texts[1]
+		if offsets[1] != nil {
+			inputObjects.PairPosArray = offsets[1][:len(sequence[1])]
 		}
 	}
 	inputObjects.Tokens = feature.Tokens
@@ -188,116 +248,98 @@ func (m *ModelService) generateHTTPOutputs(
 	return requestOutputs
 }
 
+// inputTensorFieldList feature field of every input tensor
+func (m *ModelService) inputTensorFieldList(
+	inferInputs []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor,
+) ([]FeatureField, error) {
+	inputFields := make([]FeatureField, len(inferInputs))
+	for i, input := range inferInputs {
+		field, fieldErr := m.inputTensorField(input.Name)
+		if fieldErr != nil {
+			return nil, fieldErr
+		}
+		inputFields[i] = field
+	}
+	return inputFields, nil
+}
+
 // generateHTTPInputs get bert input feature for http request
-// inferDataArr: model infer data slice
-// inferInputs: triton inference server input tensor
+// features: bert input feature of every batch item, padded to seqLength
+// inferInputs: triton inference server input tensor, feature field of every tensor is mapped by name
 func (m *ModelService) generateHTTPInputs(
-	inferDataArr []string, inferInputs []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor,
-) ([]HTTPBatchInput, []*InputObjects) {
-	// Bert Feature
-	batchModelInputObjs := make([]*InputObjects, len(inferDataArr))
-	batchRequestInputs := make([]HTTPBatchInput, len(inferInputs))
-
-	inferDataObjs := make([][][]int32, len(inferDataArr))
-	for i, inferData := range inferDataArr {
-		feature, inputObject := m.getBertInputFeature(inferData)
-		batchModelInputObjs[i] = inputObject
-		inferDataObjs[i] = [][]int32{feature.TypeIDs, feature.TokenIDs, feature.Mask}
+	features []*InputFeature, seqLength int, inferInputs []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor,
+) ([]HTTPBatchInput, error) {
+	inputFields, fieldErr := m.inputTensorFieldList(inferInputs)
+	if fieldErr != nil {
+		return nil, fieldErr
 	}
-	inferDataObjs = utils.SliceTransposeFor3D(inferDataObjs)
-
+	batchRequestInputs := make([]HTTPBatchInput, len(inferInputs))
 	for i, input := range inferInputs {
 		batchRequestInputs[i] = HTTPBatchInput{
 			Name:     input.Name,
 			Shape:    input.Shape,
 			DataType: input.Datatype,
-			Data:     inferDataObjs[i],
+			Data:     make([][]int32, len(features)),
+		}
+		for j, feature := range features {
+			batchRequestInputs[i].Data[j] = featureFieldData(feature, inputFields[i])[:seqLength]
 		}
 	}
-	return batchRequestInputs, batchModelInputObjs
+	return batchRequestInputs, nil
 }
 
 // generateHTTPRequest HTTP Request Data Generate
 func (m *ModelService) generateHTTPRequest(
-	inferDataArr []string,
+	features []*InputFeature, seqLength int,
 	inferInputs []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor,
 	inferOutputs []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor,
-) ([]byte, []*InputObjects, error) {
+) ([]byte, error) {
 	// Generate batch request json body
-	requestInputBody, modelInputObj := m.generateHTTPInputs(inferDataArr, inferInputs)
+	requestInputBody, inputErr := m.generateHTTPInputs(features, seqLength, inferInputs)
+	if inputErr != nil {
+		return nil, inputErr
+	}
 	jsonBody, jsonEncodeErr := json.Marshal(&HTTPRequestBody{
 		Inputs:  requestInputBody,
 		Outputs: m.generateHTTPOutputs(inferOutputs),
 	})
 	if jsonEncodeErr != nil {
-		return nil, nil, jsonEncodeErr
+		return nil, jsonEncodeErr
 	}
-	return jsonBody, modelInputObj, nil
+	return jsonBody, nil
 }
 
 // grpcInt32SliceToLittleEndianByteSlice int32 slice to byte slice with little endian
 func (m *ModelService) grpcInt32SliceToLittleEndianByteSlice(
 	maxLen int, input []int32, inputType string,
 ) []byte {
-	if inputType == ModelInt32DataType {
-		var returnByte []byte
-		bs := make([]byte, 4)
-		for i := 0; i < maxLen; i++ {
-			binary.LittleEndian.PutUint32(bs, uint32(input[i]))
-			returnByte = append(returnByte, bs...)
-		}
-		bs = nil
-		return returnByte
+	returnByte, encodeErr := nvidia_inferenceserver.CastNumericToRawContents(inputType, input[:maxLen])
+	if encodeErr != nil {
+		return nil
 	}
-	if inputType == ModelInt64DataType {
-		var returnByte []byte
-		bs := make([]byte, 8)
-		for i := 0; i < maxLen; i++ {
-			binary.LittleEndian.PutUint64(bs, uint64(input[i]))
-			returnByte = append(returnByte, bs...)
-		}
-		bs = nil
-		return returnByte
-	}
-	return nil
+	return returnByte
 }
 
 // generateGRPCRequest GRPC Request Data Generate
+// Raw inputs are in the same order as inferInputTensor, also used by HTTP binary tensor data extension.
 func (m *ModelService) generateGRPCRequest(
-	inferDataArr []string,
+	features []*InputFeature, seqLength int,
 	inferInputTensor []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor,
-) ([][]byte, []*InputObjects, error) {
-	// size is: len(inferDataArr) * m.maxSeqLength * 4
-	var segmentIdsBytes, inputIdsBytes, inputMaskBytes []byte
-	batchModelInputObjs := make([]*InputObjects, len(inferDataArr))
-	for i, data := range inferDataArr {
-		feature, inputObject := m.getBertInputFeature(data)
-		// feature.TypeIDs  == segment_ids
-		// feature.TokenIDs == input_ids
-		// feature.Mask     == input_mask
+) ([][]byte, error) {
+	inputFields, fieldErr := m.inputTensorFieldList(inferInputTensor)
+	if fieldErr != nil {
+		return nil, fieldErr
+	}
+	// size is: len(features) * seqLength * 4
+	rawInputs := make([][]byte, len(inferInputTensor))
+	for _, feature := range features {
 		// Temp variable to hold out converted int32 -> []byte
-		for _, inputTensor := range inferInputTensor {
-			switch inputTensor.Name {
-			case ModelBertModelSegmentIdsKey:
-				segmentIdsBytes = append(
-					m.grpcInt32SliceToLittleEndianByteSlice(m.maxSeqLength, feature.TypeIDs, inputTensor.Datatype),
-					segmentIdsBytes...,
-				)
-			case ModelBertModelInputIdsKey:
-				inputIdsBytes = append(
-					m.grpcInt32SliceToLittleEndianByteSlice(m.maxSeqLength, feature.TokenIDs, inputTensor.Datatype),
-					inputIdsBytes...,
-				)
-			case ModelBertModelInputMaskKey:
-				inputMaskBytes = append(
-					m.grpcInt32SliceToLittleEndianByteSlice(m.maxSeqLength, feature.Mask, inputTensor.Datatype),
-					inputMaskBytes...,
-				)
-			}
+		for j, inputTensor := range inferInputTensor {
+			rawInputs[j] = append(rawInputs[j], m.grpcInt32SliceToLittleEndianByteSlice(
+				seqLength, featureFieldData(feature, inputFields[j]), inputTensor.Datatype)...)
 		}
-		batchModelInputObjs[i] = inputObject
 	}
-	return [][]byte{segmentIdsBytes, inputIdsBytes, inputMaskBytes}, batchModelInputObjs, nil
+	return rawInputs, nil
 }
 
 ///////////////////////////////////////// Bert Service Pre-Process Function /////////////////////////////////////////
@@ -362,25 +404,146 @@ func (m *ModelService) ModelInfer(
 	modelName, modelVersion string,
 	requestTimeout time.Duration,
 	params ...interface{},
+) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	return m.ModelInferCtx(ctx, inferData, modelName, modelVersion, params...)
+}
+
+// ModelInferCtx API to call Triton Inference Server with context.
+// Infer data like "text_a ||| text_b" (DataSplitString) is inferred as sentence pair.
+func (m *ModelService) ModelInferCtx(
+	ctx context.Context,
+	inferData []string,
+	modelName, modelVersion string,
+	params ...interface{},
+) ([]interface{}, error) {
+	batchTexts := make([][]string, len(inferData))
+	for i, data := range inferData {
+		batchTexts[i] = splitTextPair(data)
+	}
+	return m.modelInferCtx(ctx, batchTexts, modelName, modelVersion, params...)
+}
+
+// ModelInferPair API to call Triton Inference Server with sentence pairs
+func (m *ModelService) ModelInferPair(
+	inferData []TextPair,
+	modelName, modelVersion string,
+	requestTimeout time.Duration,
+	params ...interface{},
+) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	return m.ModelInferPairCtx(ctx, inferData, modelName, modelVersion, params...)
+}
+
+// ModelInferPairCtx API to call Triton Inference Server with sentence pairs and context.
+// Every pair is encoded as [CLS] TextA [SEP] TextB [SEP] with segment_ids 0 for TextA and 1 for TextB.
+func (m *ModelService) ModelInferPairCtx(
+	ctx context.Context,
+	inferData []TextPair,
+	modelName, modelVersion string,
+	params ...interface{},
+) ([]interface{}, error) {
+	batchTexts := make([][]string, len(inferData))
+	for i, pair := range inferData {
+		batchTexts[i] = []string{pair.TextA, pair.TextB}
+	}
+	return m.modelInferCtx(ctx, batchTexts, modelName, modelVersion, params...)
+}
+
+// modelInferCtx infer batch of single texts or sentence pairs
+func (m *ModelService) modelInferCtx(
+	ctx context.Context,
+	inferData [][]string,
+	modelName, modelVersion string,
+	params ...interface{},
+) ([]interface{}, error) {
+	if m.isOverflow {
+		return m.overflowInferCtx(ctx, inferData, modelName, modelVersion, params...)
+	}
+	features := make([]*InputFeature, len(inferData))
+	inputObjects := make([]*InputObjects, len(inferData))
+	for i, texts := range inferData {
+		features[i], inputObjects[i] = m.getBertInputFeature(texts...)
+	}
+	return m.paddingInferCtx(ctx, features, inputObjects, modelName, modelVersion, params...)
+}
+
+// paddingInferCtx infer batch of features, batch is split into sub-batches by padding strategy
+func (m *ModelService) paddingInferCtx(
+	ctx context.Context,
+	features []*InputFeature,
+	inputObjects []*InputObjects,
+	modelName, modelVersion string,
+	params ...interface{},
+) ([]interface{}, error) {
+	seqLengths, batches := m.paddingBatches(features)
+	if len(batches) == 1 {
+		return m.inferFeatures(ctx, features, inputObjects, seqLengths[0], modelName, modelVersion, params...)
+	}
+	results := make([]interface{}, len(features))
+	for i, batch := range batches {
+		batchFeatures := make([]*InputFeature, len(batch))
+		batchInputObjects := make([]*InputObjects, len(batch))
+		for j, index := range batch {
+			batchFeatures[j], batchInputObjects[j] = features[index], inputObjects[index]
+		}
+		batchResults, inferErr := m.inferFeatures(
+			ctx, batchFeatures, batchInputObjects, seqLengths[i], modelName, modelVersion, params...)
+		if inferErr != nil {
+			return nil, inferErr
+		}
+		if len(batchResults) != len(batch) {
+			return nil, errors.New("infer callback should return one result per batch item")
+		}
+		for j, index := range batch {
+			results[index] = batchResults[j]
+		}
+	}
+	return results, nil
+}
+
+// inferFeatures infer batch of features padded to seqLength
+func (m *ModelService) inferFeatures(
+	ctx context.Context,
+	features []*InputFeature,
+	inputObjects []*InputObjects,
+	seqLength int,
+	modelName, modelVersion string,
+	params ...interface{},
 ) ([]interface{}, error) {
 	// Create request input/output tensors
-	inferInputs := m.generateModelInferRequest(len(inferData), m.maxSeqLength)
+	inferInputs := m.generateModelInferRequest(len(features), seqLength)
 	inferOutputs := m.generateModelInferOutputRequest(params...)
 	if m.isGRPC {
 		// GRPC Infer
-		grpcRawInputs, grpcInputData, err := m.generateGRPCRequest(inferData, inferInputs)
+		grpcRawInputs, err := m.generateGRPCRequest(features, seqLength, inferInputs)
 		if err != nil {
 			return nil, err
 		}
 		if grpcRawInputs == nil {
 			return nil, errors.New("grpc request body is nil")
 		}
-		return m.tritonService.ModelGRPCInfer(
-			inferInputs, inferOutputs, grpcRawInputs, modelName, modelVersion, requestTimeout,
-			m.inferCallback, m, grpcInputData, params,
+		return m.tritonService.ModelGRPCInferCtx(
+			ctx, inferInputs, inferOutputs, grpcRawInputs, modelName, modelVersion,
+			m.inferCallback, m, inputObjects, params,
+		)
+	}
+	if m.isHTTPBinary {
+		// HTTP Infer with binary tensor data extension
+		httpRawInputs, err := m.generateGRPCRequest(features, seqLength, inferInputs)
+		if err != nil {
+			return nil, err
+		}
+		return m.tritonService.ModelHTTPBinaryInferCtx(
+			ctx, inferInputs, inferOutputs, httpRawInputs, modelName, modelVersion,
+			m.inferCallback, m, inputObjects, params,
 		)
 	}
-	httpRequestBody, httpInputData, err := m.generateHTTPRequest(inferData, inferInputs, inferOutputs)
+	httpRequestBody, err := m.generateHTTPRequest(features, seqLength, inferInputs, inferOutputs)
 	if err != nil {
 		return nil, err
 	}
@@ -388,9 +551,9 @@ func (m *ModelService) ModelInfer(
 		return nil, errors.New("http request body is nil")
 	}
 	// HTTP Infer
-	return m.tritonService.ModelHTTPInfer(
-		httpRequestBody, modelName, modelVersion, requestTimeout,
-		m.inferCallback, m, httpInputData, params,
+	return m.tritonService.ModelHTTPInferCtx(
+		ctx, httpRequestBody, modelName, modelVersion,
+		m.inferCallback, m, inputObjects, params,
 	)
 }
 
@@ -402,6 +565,7 @@ func NewModelService(
 	modelInputCallback GenerateModelInferRequest,
 	modelOutputCallback GenerateModelInferOutputRequest,
 	modelInferCallback nvidia_inferenceserver.DecoderFunc,
+	clientOpts ...nvidia_inferenceserver.TritonClientOption,
 ) (*ModelService, error) {
 	// 0、callback function validation
 	if modelInputCallback == nil || modelOutputCallback == nil || modelInferCallback == nil {
@@ -415,12 +579,13 @@ func NewModelService(
 	// 2、Init Service
 	srv := &ModelService{
 		maxSeqLength:                    DefaultMaxSeqLength,
-		tritonService:                   nvidia_inferenceserver.NewTritonClientForAll(httpAddr, httpClient, grpcConn),
+		tritonService:                   nvidia_inferenceserver.NewTritonClientForAll(httpAddr, httpClient, grpcConn, clientOpts...),
 		inferCallback:                   modelInferCallback,
 		BertVocab:                       voc,
 		BertTokenizer:                   NewWordPieceTokenizer(voc),
 		generateModelInferRequest:       modelInputCallback,
 		generateModelInferOutputRequest: modelOutputCallback,
+		inputTensorFields:               defaultInputTensorFields(),
 	}
 	return srv, nil
 }
@@ -0,0 +1,302 @@
+package bert
+
+import (
+	"context"
+	"errors"
+)
+
+// InputWindow sliding window of input in overflow mode
+type InputWindow struct {
+	InputIndex  int // index of original input in infer data
+	WindowIndex int // index of window in windows of original input
+	Segment     int // segment of windowed text, 0 for single text and 1 for TextB of sentence pair
+	// TokenStart / TokenEnd token range of window in tokens of windowed text, end is exclusive
+	TokenStart int
+	TokenEnd   int
+	// FeatureStart position of the first window token in InputObjects.Tokens
+	FeatureStart int
+	// Offsets character offsets of window in windowed text
+	Offsets OffsetsType
+}
+
+// WindowResult infer result of one sliding window
+type WindowResult struct {
+	Input  *InputObjects // Input.Window is the window of original input
+	Result interface{}   // result of infer callback
+}
+
+// WindowResults infer results of sliding windows of one input in window order
+type WindowResults []WindowResult
+
+// SpanLogits start / end logits of every token (position of InputObjects.Tokens) of window, like output of
+// extractive QA model
+type SpanLogits struct {
+	Start []float32
+	End   []float32
+}
+
+// AnswerSpan answer span in windowed text
+type AnswerSpan struct {
+	WindowIndex int
+	// TokenStart / TokenEnd token range in tokens of windowed text, end is exclusive
+	TokenStart int
+	TokenEnd   int
+	Offsets    OffsetsType // character offsets in windowed text
+	Text       string
+	Score      float32 // start logit + end logit
+}
+
+// spanSegment segment of text which answer span is searched in, the windowed text in overflow mode,
+// otherwise TextB of sentence pair or the single text
+func (o *InputObjects) spanSegment() int {
+	if o.Window != nil {
+		return o.Window.Segment
+	}
+	if o.PairPosArray != nil {
+		return 1
+	}
+	return 0
+}
+
+// windowText windowed text of input objects
+func (o *InputObjects) windowText() string {
+	if o.spanSegment() == 1 {
+		return o.PairInput
+	}
+	return o.Input
+}
+
+// windowPosArray offsets of window tokens in windowed text
+func (o *InputObjects) windowPosArray() []OffsetsType {
+	if o.spanSegment() == 1 {
+		return o.PairPosArray
+	}
+	return o.PosArray
+}
+
+// bestInputSpan answer span with the highest start + end logit in windowed text of input, nil if no candidate
+func bestInputSpan(inputObjects *InputObjects, logits *SpanLogits, maxAnswerTokens int) *AnswerSpan {
+	featureStart, windowIndex, tokenStart := 1, 0, 0
+	if inputObjects.spanSegment() == 1 {
+		featureStart = len(inputObjects.PosArray) + 2
+	}
+	if window := inputObjects.Window; window != nil {
+		featureStart, windowIndex, tokenStart = window.FeatureStart, window.WindowIndex, window.TokenStart
+	}
+	var best *AnswerSpan
+	posArray := inputObjects.windowPosArray()
+	for i := 0; i < len(posArray) && featureStart+i < len(logits.Start); i++ {
+		for j := i; j < len(posArray) && j < i+maxAnswerTokens && featureStart+j < len(logits.End); j++ {
+			score := logits.Start[featureStart+i] + logits.End[featureStart+j]
+			if best != nil && score <= best.Score {
+				continue
+			}
+			best = &AnswerSpan{
+				WindowIndex: windowIndex,
+				TokenStart:  tokenStart + i,
+				TokenEnd:    tokenStart + j + 1,
+				Offsets:     OffsetsType{Start: posArray[i].Start, End: posArray[j].End},
+				Score:       score,
+			}
+		}
+	}
+	// offsets are rune offsets
+	if runes := []rune(inputObjects.windowText()); best != nil && best.Offsets.End <= len(runes) {
+		best.Text = string(runes[best.Offsets.Start:best.Offsets.End])
+	}
+	return best
+}
+
+// SetOverflowStride enable overflow mode, text longer than max sequence length is split into overlapping windows
+// (stride tokens overlap between windows) instead of truncation, TextB is split for sentence pair.
+// Windows of all inputs are inferred as one batch, result of every input is WindowResults of its windows
+// and offsets of tokens are always reported.
+func (m *ModelService) SetOverflowStride(stride int) *ModelService {
+	if stride >= 0 {
+		m.isOverflow = true
+		m.overflowStride = stride
+	}
+	return m
+}
+
+// UnsetOverflow truncate text longer than max sequence length
+func (m *ModelService) UnsetOverflow() *ModelService {
+	m.isOverflow = false
+	return m
+}
+
+// GetOverflowStride Get overflow stride and whether overflow mode is enabled
+func (m *ModelService) GetOverflowStride() (int, bool) {
+	return m.overflowStride, m.isOverflow
+}
+
+// newInputWindow window info of input objects built from window tokens (of segment) starting at tokenStart
+func newInputWindow(inputIndex, windowIndex, segment, tokenStart int, inputObjects *InputObjects) *InputWindow {
+	window := &InputWindow{
+		InputIndex: inputIndex, WindowIndex: windowIndex, Segment: segment, TokenStart: tokenStart, FeatureStart: 1,
+	}
+	if segment == 1 {
+		window.FeatureStart = len(inputObjects.PosArray) + 2
+	}
+	inputObjects.Window = window
+	posArray := inputObjects.windowPosArray()
+	window.TokenEnd = tokenStart + len(posArray)
+	if len(posArray) > 0 {
+		window.Offsets = OffsetsType{Start: posArray[0].Start, End: posArray[len(posArray)-1].End}
+	}
+	return window
+}
+
+// getBertInputWindows Get Bert Feature of every sliding window of texts, the last text is windowed
+func (m *ModelService) getBertInputWindows(inputIndex int, texts []string) ([]*InputFeature, []*InputObjects) {
+	sequence, offsets := m.tokenizeTexts(texts, true)
+	last := len(texts) - 1
+	// space for CLS, one SEP after every text and TextA of sentence pair
+	capacity := m.maxSeqLength - 1 - len(texts)
+	for _, tokens := range sequence[:last] {
+		capacity -= len(tokens)
+	}
+	tokens, tokenOffsets := sequence[last], offsets[last]
+	// fits in one window, or TextA leaves no room to slide, truncate as usual
+	if len(tokens) <= capacity || capacity <= m.overflowStride {
+		feature, inputObjects := m.buildBertInputFeature(texts, sequence, offsets)
+		newInputWindow(inputIndex, 0, last, 0, inputObjects)
+		return []*InputFeature{feature}, []*InputObjects{inputObjects}
+	}
+	var features []*InputFeature
+	var batchInputObjects []*InputObjects
+	for start := 0; ; start += capacity - m.overflowStride {
+		end := start + capacity
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		windowSequence := append(append([][]string{}, sequence[:last]...), tokens[start:end])
+		windowOffsets := append(append([][]OffsetsType{}, offsets[:last]...), tokenOffsets[start:end])
+		feature, inputObjects := m.buildBertInputFeature(texts, windowSequence, windowOffsets)
+		newInputWindow(inputIndex, len(features), last, start, inputObjects)
+		features = append(features, feature)
+		batchInputObjects = append(batchInputObjects, inputObjects)
+		if end == len(tokens) {
+			break
+		}
+	}
+	return features, batchInputObjects
+}
+
+// overflowInferCtx infer windows of all inputs as one batch, results are grouped into WindowResults of every input
+func (m *ModelService) overflowInferCtx(
+	ctx context.Context,
+	inferData [][]string,
+	modelName, modelVersion string,
+	params ...interface{},
+) ([]interface{}, error) {
+	var features []*InputFeature
+	var inputObjects []*InputObjects
+	for i, texts := range inferData {
+		windowFeatures, windowInputObjects := m.getBertInputWindows(i, texts)
+		features = append(features, windowFeatures...)
+		inputObjects = append(inputObjects, windowInputObjects...)
+	}
+	results, inferErr := m.paddingInferCtx(ctx, features, inputObjects, modelName, modelVersion, params...)
+	if inferErr != nil {
+		return nil, inferErr
+	}
+	if len(results) != len(features) {
+		return nil, errors.New("infer callback should return one result per batch item")
+	}
+	windowResults := make([]WindowResults, len(inferData))
+	for i, inputObject := range inputObjects {
+		inputIndex := inputObject.Window.InputIndex
+		windowResults[inputIndex] = append(windowResults[inputIndex], WindowResult{Input: inputObject, Result: results[i]})
+	}
+	groupedResults := make([]interface{}, len(inferData))
+	for i := range windowResults {
+		groupedResults[i] = windowResults[i]
+	}
+	return groupedResults, nil
+}
+
+//////////////////////////////////////////////// Window Aggregation API ////////////////////////////////////////////////
+
+// windowLogits logits of every window, result of every window must be []float32 with the same size
+func (w WindowResults) windowLogits() ([][]float32, error) {
+	if len(w) == 0 {
+		return nil, errors.New("no window result")
+	}
+	logits := make([][]float32, len(w))
+	for i, result := range w {
+		values, ok := result.Result.([]float32)
+		if !ok {
+			return nil, errors.New("window result is not []float32 logits")
+		}
+		if i > 0 && len(values) != len(logits[0]) {
+			return nil, errors.New("window logits have different sizes")
+		}
+		logits[i] = values
+	}
+	return logits, nil
+}
+
+// MeanLogits element-wise mean of window logits, result of every window must be []float32
+func (w WindowResults) MeanLogits() ([]float32, error) {
+	logits, logitsErr := w.windowLogits()
+	if logitsErr != nil {
+		return nil, logitsErr
+	}
+	mean := make([]float32, len(logits[0]))
+	for _, values := range logits {
+		for i, value := range values {
+			mean[i] += value
+		}
+	}
+	for i := range mean {
+		mean[i] /= float32(len(logits))
+	}
+	return mean, nil
+}
+
+// MaxLogits element-wise max of window logits, result of every window must be []float32
+func (w WindowResults) MaxLogits() ([]float32, error) {
+	logits, logitsErr := w.windowLogits()
+	if logitsErr != nil {
+		return nil, logitsErr
+	}
+	maxLogits := append([]float32{}, logits[0]...)
+	for _, values := range logits[1:] {
+		for i, value := range values {
+			if value > maxLogits[i] {
+				maxLogits[i] = value
+			}
+		}
+	}
+	return maxLogits, nil
+}
+
+// BestSpan answer span with the highest start + end logit across windows, span has at most maxAnswerTokens tokens.
+// Result of every window must be SpanLogits / *SpanLogits, or *AnswerSpan (like result of NewQuestionAnsweringDecoder),
+// only tokens of windowed text are candidates.
+func (w WindowResults) BestSpan(maxAnswerTokens int) (*AnswerSpan, error) {
+	var best *AnswerSpan
+	for _, result := range w {
+		var span *AnswerSpan
+		switch value := result.Result.(type) {
+		case SpanLogits:
+			span = bestInputSpan(result.Input, &value, maxAnswerTokens)
+		case *SpanLogits:
+			span = bestInputSpan(result.Input, value, maxAnswerTokens)
+		case *AnswerSpan:
+			span = value
+		default:
+			return nil, errors.New("window result is not SpanLogits or AnswerSpan")
+		}
+		if span != nil && (best == nil || span.Score > best.Score) {
+			best = span
+		}
+	}
+	if best == nil {
+		return nil, errors.New("no answer span found")
+	}
+	return best, nil
+}
+
+//////////////////////////////////////////////// Window Aggregation API ////////////////////////////////////////////////
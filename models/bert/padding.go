@@ -0,0 +1,177 @@
+package bert
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// PaddingStrategy how sequences of a batch are padded
+type PaddingStrategy int
+
+const (
+	PaddingMaxLength PaddingStrategy = iota // pad to max sequence length (default)
+	PaddingLongest                          // pad to the longest sequence in batch
+	PaddingMultiple                         // pad to the longest sequence in batch rounded up to a multiple of N
+	PaddingBucket                           // bucket sequences by length into sub-batches, pad to bucket boundary
+)
+
+// String name of padding strategy
+func (p PaddingStrategy) String() string {
+	switch p {
+	case PaddingMaxLength:
+		return "MaxLength"
+	case PaddingLongest:
+		return "Longest"
+	case PaddingMultiple:
+		return "Multiple"
+	case PaddingBucket:
+		return "Bucket"
+	}
+	return "Unknown"
+}
+
+// SetPaddingLongest pad sequences to the longest sequence in batch
+func (m *ModelService) SetPaddingLongest() *ModelService {
+	m.paddingStrategy = PaddingLongest
+	return m
+}
+
+// SetPaddingMultiple pad sequences to the longest sequence in batch rounded up to a multiple of n,
+// like 8 for tensor core friendly shapes
+func (m *ModelService) SetPaddingMultiple(n int) *ModelService {
+	if n > 0 {
+		m.paddingStrategy = PaddingMultiple
+		m.paddingMultiple = n
+	}
+	return m
+}
+
+// SetPaddingBuckets bucket sequences by length, sequences of the same bucket are inferred as one sub-batch
+// padded to bucket boundary, like 16 / 32 / 64. Sequences longer than the largest boundary are padded to
+// max sequence length. Results of sub-batches are returned in input order.
+func (m *ModelService) SetPaddingBuckets(boundaries ...int) *ModelService {
+	buckets := make([]int, 0, len(boundaries))
+	for _, boundary := range boundaries {
+		if boundary > 0 {
+			buckets = append(buckets, boundary)
+		}
+	}
+	if len(buckets) > 0 {
+		sort.Ints(buckets)
+		m.paddingStrategy = PaddingBucket
+		m.paddingBuckets = buckets
+	}
+	return m
+}
+
+// UnsetPadding pad sequences to max sequence length
+func (m *ModelService) UnsetPadding() *ModelService {
+	m.paddingStrategy = PaddingMaxLength
+	return m
+}
+
+// GetPaddingStrategy Get padding strategy
+func (m *ModelService) GetPaddingStrategy() PaddingStrategy {
+	return m.paddingStrategy
+}
+
+// SetSeqLengthFromModelConfig read sequence dim of mapped input tensors from model config.
+// Fixed dim (like [128]) becomes the max sequence length and disables dynamic padding because the model
+// only accepts that length, variable dim (-1) keeps max sequence length of ModelService.
+// Calling SetMaxSeqLength afterwards overrides the fixed dim and restores dynamic padding.
+func (m *ModelService) SetSeqLengthFromModelConfig(
+	modelName, modelVersion string, requestTimeout time.Duration,
+) error {
+	modelConfig, configErr := m.tritonService.ModelConfiguration(modelName, modelVersion, requestTimeout)
+	if configErr != nil {
+		return configErr
+	}
+	modelSeqLength := 0
+	for _, input := range modelConfig.GetConfig().GetInput() {
+		if _, ok := m.inputTensorFields[input.Name]; !ok || len(input.Dims) == 0 {
+			continue
+		}
+		dim := int(input.Dims[len(input.Dims)-1])
+		if dim <= 0 {
+			continue
+		}
+		if modelSeqLength > 0 && modelSeqLength != dim {
+			return errors.New("input tensor " + input.Name + " sequence dim " + strconv.Itoa(dim) +
+				" is different from other inputs " + strconv.Itoa(modelSeqLength))
+		}
+		modelSeqLength = dim
+	}
+	m.modelSeqLength = modelSeqLength
+	if modelSeqLength > 0 {
+		m.maxSeqLength = modelSeqLength
+	}
+	return nil
+}
+
+// inputFeatureLength sequence length of feature (tokens with CLS/SEP), mask is 1 for every token
+func inputFeatureLength(feature *InputFeature) int {
+	length := 0
+	for length < len(feature.Mask) && feature.Mask[length] == 1 {
+		length++
+	}
+	return length
+}
+
+// paddingSeqLength padded sequence length of sequence (or batch with the longest sequence) of length
+func (m *ModelService) paddingSeqLength(length int) int {
+	// features are never longer than max sequence length
+	if m.modelSeqLength > 0 && m.modelSeqLength <= m.maxSeqLength {
+		return m.modelSeqLength
+	}
+	seqLength := m.maxSeqLength
+	switch m.paddingStrategy {
+	case PaddingLongest:
+		seqLength = length
+	case PaddingMultiple:
+		seqLength = (length + m.paddingMultiple - 1) / m.paddingMultiple * m.paddingMultiple
+	case PaddingBucket:
+		for _, boundary := range m.paddingBuckets {
+			if length <= boundary {
+				seqLength = boundary
+				break
+			}
+		}
+	}
+	if seqLength > m.maxSeqLength {
+		seqLength = m.maxSeqLength
+	}
+	return seqLength
+}
+
+// paddingBatches split batch into sub-batches of padded sequence length, sub-batches are in ascending order of
+// sequence length and items of sub-batch are indexes of batch. Only PaddingBucket has more than one sub-batch.
+func (m *ModelService) paddingBatches(features []*InputFeature) ([]int, [][]int) {
+	if m.paddingStrategy != PaddingBucket {
+		longest := 0
+		indexes := make([]int, len(features))
+		for i, feature := range features {
+			if length := inputFeatureLength(feature); length > longest {
+				longest = length
+			}
+			indexes[i] = i
+		}
+		return []int{m.paddingSeqLength(longest)}, [][]int{indexes}
+	}
+	buckets := make(map[int][]int)
+	for i, feature := range features {
+		seqLength := m.paddingSeqLength(inputFeatureLength(feature))
+		buckets[seqLength] = append(buckets[seqLength], i)
+	}
+	seqLengths := make([]int, 0, len(buckets))
+	for seqLength := range buckets {
+		seqLengths = append(seqLengths, seqLength)
+	}
+	sort.Ints(seqLengths)
+	batches := make([][]int, len(seqLengths))
+	for i, seqLength := range seqLengths {
+		batches[i] = buckets[seqLength]
+	}
+	return seqLengths, batches
+}
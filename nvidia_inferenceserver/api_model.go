@@ -1,10 +1,16 @@
 package nvidia_inferenceserver
 
+import "github.com/goccy/go-json"
+
 type ModelIndexRequestHTTPObj struct {
 	RepoName string `json:"repository_name"`
 	Ready    bool   `json:"ready"`
 }
 
+type ModelRepositoryRequestHTTPObj struct {
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
 type CudaMemoryRegisterBodyHTTPObj struct {
 	RawHandle interface{} `json:"raw_handle"`
 	DeviceId  int64       `json:"device_id"`
@@ -20,3 +26,43 @@ type SystemMemoryRegisterBodyHTTPObj struct {
 type TraceSettingRequestHTTPObj struct {
 	TraceSetting interface{} `json:"trace_setting"`
 }
+
+type InferInputTensorHTTPObj struct {
+	Name       string                 `json:"name"`
+	Shape      []int64                `json:"shape"`
+	Datatype   string                 `json:"datatype"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Data       interface{}            `json:"data,omitempty"`
+}
+
+type InferOutputTensorHTTPObj struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type InferRequestHTTPObj struct {
+	ID         string                     `json:"id,omitempty"`
+	Parameters map[string]interface{}     `json:"parameters,omitempty"`
+	Inputs     []InferInputTensorHTTPObj  `json:"inputs"`
+	Outputs    []InferOutputTensorHTTPObj `json:"outputs,omitempty"`
+}
+
+type InferResponseOutputHTTPObj struct {
+	Name       string                 `json:"name"`
+	Shape      []int64                `json:"shape"`
+	Datatype   string                 `json:"datatype"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Data       json.RawMessage        `json:"data,omitempty"`
+}
+
+type InferResponseHTTPObj struct {
+	ModelName    string                       `json:"model_name"`
+	ModelVersion string                       `json:"model_version"`
+	ID           string                       `json:"id,omitempty"`
+	Parameters   map[string]interface{}       `json:"parameters,omitempty"`
+	Outputs      []InferResponseOutputHTTPObj `json:"outputs"`
+}
+
+type ErrorResponseHTTPObj struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,189 @@
+package nvidia_inferenceserver
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// AsyncInferFunc infer function called by async API
+type AsyncInferFunc func(ctx context.Context) ([]interface{}, error)
+
+// InferFuture result of async infer
+type InferFuture struct {
+	doneChan chan struct{}
+	result   []interface{}
+	err      error
+}
+
+// newInferFuture create pending future
+func newInferFuture() *InferFuture {
+	return &InferFuture{doneChan: make(chan struct{})}
+}
+
+// complete set result of future and wake up waiters
+func (f *InferFuture) complete(result []interface{}, err error) {
+	f.result, f.err = result, err
+	close(f.doneChan)
+}
+
+// Done return a channel which is closed when the infer is finished
+func (f *InferFuture) Done() <-chan struct{} {
+	return f.doneChan
+}
+
+// Wait wait for the infer finished and return the result of decoderFunc
+func (f *InferFuture) Wait() ([]interface{}, error) {
+	<-f.doneChan
+	return f.result, f.err
+}
+
+// Get wait for the infer finished or ctx is done
+func (f *InferFuture) Get(ctx context.Context) ([]interface{}, error) {
+	select {
+	case <-f.doneChan:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WithMaxInFlight is an option to limit the number of in-flight async infer requests of the client,
+// requests over the limit wait until a slot is released or ctx is done. maxInFlight <= 0 means no limit.
+func WithMaxInFlight(maxInFlight int) TritonClientOption {
+	return func(t *TritonClientService) {
+		if maxInFlight > 0 {
+			t.inFlightLimiter = make(chan struct{}, maxInFlight)
+		} else {
+			t.inFlightLimiter = nil
+		}
+	}
+}
+
+// InferAsync call inferFunc in a new goroutine under the in-flight limit of the client, return future of the result
+func (t *TritonClientService) InferAsync(ctx context.Context, inferFunc AsyncInferFunc) *InferFuture {
+	future := newInferFuture()
+	go func() {
+		if t.inFlightLimiter != nil {
+			select {
+			case t.inFlightLimiter <- struct{}{}:
+				defer func() { <-t.inFlightLimiter }()
+			case <-ctx.Done():
+				future.complete(nil, ctx.Err())
+				return
+			}
+		}
+		future.complete(inferFunc(ctx))
+	}()
+	return future
+}
+
+// ModelHTTPInferAsync async version of ModelHTTPInferCtx
+func (t *TritonClientService) ModelHTTPInferAsync(
+	ctx context.Context,
+	requestBody []byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) *InferFuture {
+	return t.InferAsync(ctx, func(ctx context.Context) ([]interface{}, error) {
+		return t.ModelHTTPInferCtx(ctx, requestBody, modelName, modelVersion, decoderFunc, params...)
+	})
+}
+
+// ModelHTTPBinaryInferAsync async version of ModelHTTPBinaryInferCtx
+func (t *TritonClientService) ModelHTTPBinaryInferAsync(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) *InferFuture {
+	return t.InferAsync(ctx, func(ctx context.Context) ([]interface{}, error) {
+		return t.ModelHTTPBinaryInferCtx(
+			ctx, inferInputs, inferOutputs, rawInputs, modelName, modelVersion, decoderFunc, params...)
+	})
+}
+
+// ModelGRPCInferAsync async version of ModelGRPCInferCtx
+func (t *TritonClientService) ModelGRPCInferAsync(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) *InferFuture {
+	return t.InferAsync(ctx, func(ctx context.Context) ([]interface{}, error) {
+		return t.ModelGRPCInferCtx(
+			ctx, inferInputs, inferOutputs, rawInputs, modelName, modelVersion, decoderFunc, params...)
+	})
+}
+
+// InferGroup wait for a group of async infer, the context of group is canceled when the first error happens.
+// Async infer added to the group should be created with the context of group (Context).
+type InferGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock    sync.Mutex
+	futures []*InferFuture
+	errOnce sync.Once
+	err     error
+	wg      sync.WaitGroup
+}
+
+// Context context of group, canceled when the first error happens or Wait returns
+func (g *InferGroup) Context() context.Context {
+	return g.ctx
+}
+
+// Add add futures to group
+func (g *InferGroup) Add(futures ...*InferFuture) {
+	g.lock.Lock()
+	g.futures = append(g.futures, futures...)
+	g.lock.Unlock()
+
+	for _, future := range futures {
+		g.wg.Add(1)
+		go func(future *InferFuture) {
+			defer g.wg.Done()
+			if _, err := future.Wait(); err != nil {
+				g.errOnce.Do(func() {
+					g.err = err
+					g.cancel()
+				})
+			}
+		}(future)
+	}
+}
+
+// Go call inferFunc asynchronously with the context of group through client and add the future to group
+func (g *InferGroup) Go(client *TritonClientService, inferFunc AsyncInferFunc) *InferFuture {
+	future := client.InferAsync(g.ctx, inferFunc)
+	g.Add(future)
+	return future
+}
+
+// Wait wait for all futures finished, return results in the order of futures added and the first error
+func (g *InferGroup) Wait() ([][]interface{}, error) {
+	g.wg.Wait()
+	g.cancel()
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	results := make([][]interface{}, len(g.futures))
+	for i, future := range g.futures {
+		results[i] = future.result
+	}
+	return results, g.err
+}
+
+// NewInferGroup create infer group with parent context
+func NewInferGroup(ctx context.Context) *InferGroup {
+	groupCtx, cancel := context.WithCancel(ctx)
+	return &InferGroup{ctx: groupCtx, cancel: cancel}
+}
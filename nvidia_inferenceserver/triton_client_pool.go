@@ -0,0 +1,424 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+// PoolBalancePolicy policy to pick endpoint for request
+type PoolBalancePolicy int
+
+const (
+	// BalanceRoundRobin pick healthy endpoints one by one
+	BalanceRoundRobin PoolBalancePolicy = iota
+	// BalanceLeastOutstanding pick the healthy endpoint with the least outstanding requests
+	BalanceLeastOutstanding
+)
+
+const (
+	DefaultPoolHealthCheckInterval = 5 * time.Second
+	DefaultPoolHealthCheckTimeout  = time.Second
+)
+
+// ErrNoHealthyEndpoint all endpoints of pool are ejected
+var ErrNoHealthyEndpoint = errors.New("[Pool]no healthy endpoint")
+
+// poolEndpoint endpoint of TritonClientPool
+type poolEndpoint struct {
+	client      *TritonClientService
+	healthy     int32
+	outstanding int64
+	// consecutive probe result counter, only used by health check goroutine
+	failures  int
+	successes int
+}
+
+// isHealthy endpoint can be picked or not
+func (e *poolEndpoint) isHealthy() bool {
+	return atomic.LoadInt32(&e.healthy) == 1
+}
+
+// setHealthy eject or re-admit endpoint
+func (e *poolEndpoint) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&e.healthy, 1)
+	} else {
+		atomic.StoreInt32(&e.healthy, 0)
+	}
+}
+
+// PoolEndpointStatus status snapshot of pool endpoint
+type PoolEndpointStatus struct {
+	Client      *TritonClientService
+	Healthy     bool
+	Outstanding int64
+}
+
+// TritonClientPool balance requests across several triton replicas and eject unhealthy replicas
+type TritonClientPool struct {
+	endpoints   []*poolEndpoint
+	policy      PoolBalancePolicy
+	next        uint64
+	retryPolicy *RetryPolicy
+
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	healthCheckModels   map[string]string
+	unhealthyThreshold  int
+	healthyThreshold    int
+	healthCheckLock     sync.Mutex
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+	closeWait sync.WaitGroup
+}
+
+// TritonClientPoolOption option of TritonClientPool
+type TritonClientPoolOption func(*TritonClientPool)
+
+// WithPoolBalancePolicy set balance policy, default is BalanceRoundRobin
+func WithPoolBalancePolicy(policy PoolBalancePolicy) TritonClientPoolOption {
+	return func(p *TritonClientPool) {
+		p.policy = policy
+	}
+}
+
+// WithPoolHealthCheck set health check interval and timeout of every probe, interval <= 0 disable background health check
+func WithPoolHealthCheck(interval, timeout time.Duration) TritonClientPoolOption {
+	return func(p *TritonClientPool) {
+		p.healthCheckInterval = interval
+		p.healthCheckTimeout = timeout
+	}
+}
+
+// WithPoolHealthCheckModel probe CheckModelReady of model in addition to CheckServerReady
+func WithPoolHealthCheckModel(modelName, modelVersion string) TritonClientPoolOption {
+	return func(p *TritonClientPool) {
+		p.healthCheckModels[modelName] = modelVersion
+	}
+}
+
+// WithPoolHealthThreshold set consecutive probe failures to eject and consecutive probe successes to re-admit endpoint
+func WithPoolHealthThreshold(unhealthyThreshold, healthyThreshold int) TritonClientPoolOption {
+	return func(p *TritonClientPool) {
+		if unhealthyThreshold > 0 {
+			p.unhealthyThreshold = unhealthyThreshold
+		}
+		if healthyThreshold > 0 {
+			p.healthyThreshold = healthyThreshold
+		}
+	}
+}
+
+// WithPoolRetryPolicy retry call failed with transport error on the next healthy endpoint, up to MaxAttempts of policy.
+// Infer calls are retried only if RetryInference is true, calls of Do are never retried.
+func WithPoolRetryPolicy(policy *RetryPolicy) TritonClientPoolOption {
+	return func(p *TritonClientPool) {
+		p.retryPolicy = policy
+	}
+}
+
+// isEndpointFailure error shows endpoint is unreachable: HTTP connection error or GRPC UNAVAILABLE.
+// Timeout, cancellation and model state errors are caused by the request or model, endpoint is kept.
+func isEndpointFailure(err error) bool {
+	var tritonErr *TritonError
+	if !errors.As(err, &tritonErr) || errors.Is(err, ErrTimeout) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, ErrModelNotReady) || errors.Is(err, ErrModelNotFound) {
+		return false
+	}
+	if tritonErr.Transport == TransportGRPC {
+		return tritonErr.GRPCCode == codes.Unavailable
+	}
+	var netErr net.Error
+	return tritonErr.StatusCode == 0 && (errors.As(tritonErr.Err, &netErr) ||
+		errors.Is(tritonErr.Err, fasthttp.ErrConnectionClosed) || errors.Is(tritonErr.Err, io.EOF))
+}
+
+// pick pick a healthy endpoint by policy
+func (p *TritonClientPool) pick() (*poolEndpoint, error) {
+	var picked *poolEndpoint
+	switch p.policy {
+	case BalanceLeastOutstanding:
+		// start from round-robin offset so that idle endpoints are used evenly
+		offset := int(atomic.AddUint64(&p.next, 1) - 1)
+		for i := range p.endpoints {
+			endpoint := p.endpoints[(offset+i)%len(p.endpoints)]
+			if !endpoint.isHealthy() {
+				continue
+			}
+			if picked == nil || atomic.LoadInt64(&endpoint.outstanding) < atomic.LoadInt64(&picked.outstanding) {
+				picked = endpoint
+			}
+		}
+	default:
+		for range p.endpoints {
+			endpoint := p.endpoints[int(atomic.AddUint64(&p.next, 1)-1)%len(p.endpoints)]
+			if endpoint.isHealthy() {
+				picked = endpoint
+				break
+			}
+		}
+	}
+	if picked == nil {
+		return nil, ErrNoHealthyEndpoint
+	}
+	return picked, nil
+}
+
+// probe check endpoint server ready and models ready
+func (p *TritonClientPool) probe(ctx context.Context, endpoint *poolEndpoint) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, p.healthCheckTimeout)
+	defer cancel()
+
+	if ready, readyErr := endpoint.client.CheckServerReadyCtx(probeCtx); readyErr != nil || !ready {
+		return false
+	}
+	for modelName, modelVersion := range p.healthCheckModels {
+		if ready, readyErr := endpoint.client.CheckModelReadyCtx(probeCtx, modelName, modelVersion); readyErr != nil || !ready {
+			return false
+		}
+	}
+	return true
+}
+
+// healthCheckLoop probe endpoints periodically until pool is closed
+func (p *TritonClientPool) healthCheckLoop() {
+	defer p.closeWait.Done()
+
+	ticker := time.NewTicker(p.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closeChan:
+			return
+		case <-ticker.C:
+			p.CheckHealth(context.Background())
+		}
+	}
+}
+
+// CheckHealth probe all endpoints once, eject unhealthy endpoints and re-admit recovered endpoints
+func (p *TritonClientPool) CheckHealth(ctx context.Context) {
+	p.healthCheckLock.Lock()
+	defer p.healthCheckLock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, endpoint := range p.endpoints {
+		wg.Add(1)
+		go func(endpoint *poolEndpoint) {
+			defer wg.Done()
+			if p.probe(ctx, endpoint) {
+				endpoint.failures = 0
+				// successes are counted since ejection, endpoint may be ejected by request between probes
+				if endpoint.isHealthy() {
+					endpoint.successes = 0
+				} else if endpoint.successes++; endpoint.successes >= p.healthyThreshold {
+					endpoint.setHealthy(true)
+				}
+			} else {
+				endpoint.successes = 0
+				endpoint.failures++
+				if endpoint.isHealthy() && endpoint.failures >= p.unhealthyThreshold {
+					endpoint.setHealthy(false)
+				}
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// Acquire pick a healthy client, release must be called after request is finished
+func (p *TritonClientPool) Acquire() (client *TritonClientService, release func(), err error) {
+	endpoint, pickErr := p.pick()
+	if pickErr != nil {
+		return nil, nil, pickErr
+	}
+	atomic.AddInt64(&endpoint.outstanding, 1)
+	var releaseOnce sync.Once
+	return endpoint.client, func() { releaseOnce.Do(func() { atomic.AddInt64(&endpoint.outstanding, -1) }) }, nil
+}
+
+// callEndpoint call fn with client of endpoint, count outstanding request of endpoint
+func (p *TritonClientPool) callEndpoint(endpoint *poolEndpoint, fn func(client *TritonClientService) error) error {
+	atomic.AddInt64(&endpoint.outstanding, 1)
+	defer atomic.AddInt64(&endpoint.outstanding, -1)
+
+	return fn(endpoint.client)
+}
+
+// doWithFailover call fn with healthy endpoints picked by balance policy. Endpoint failed with transport error is
+// ejected until health check re-admits it, and the call is retried on the next healthy endpoint if retry policy
+// allows the call kind. The last error is returned if no healthy endpoint is left for retry.
+func (p *TritonClientPool) doWithFailover(
+	ctx context.Context, kind retryCallKind, fn func(client *TritonClientService) error,
+) error {
+	maxAttempts := 1
+	if policy := p.retryPolicy; policy != nil && policy.MaxAttempts > 1 && kind != retryCallNever &&
+		(kind != retryCallInference || policy.RetryInference) {
+		maxAttempts = policy.MaxAttempts
+	}
+	var callErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		endpoint, pickErr := p.pick()
+		if pickErr != nil {
+			if callErr != nil {
+				return callErr
+			}
+			return pickErr
+		}
+		callErr = p.callEndpoint(endpoint, fn)
+		if !isEndpointFailure(callErr) {
+			return callErr
+		}
+		endpoint.setHealthy(false)
+		if ctx.Err() != nil {
+			return callErr
+		}
+	}
+	return callErr
+}
+
+// Do call fn with a healthy client picked by balance policy, endpoint failed with transport error is ejected.
+// fn is not retried, use DoIdempotent for health / metadata / config calls.
+func (p *TritonClientPool) Do(fn func(client *TritonClientService) error) error {
+	return p.doWithFailover(context.Background(), retryCallNever, fn)
+}
+
+// DoIdempotent like Do, but fn failed with transport error is retried on the next healthy endpoint if
+// WithPoolRetryPolicy is set, fn must be idempotent (health / metadata / config / stats calls)
+func (p *TritonClientPool) DoIdempotent(ctx context.Context, fn func(client *TritonClientService) error) error {
+	return p.doWithFailover(ctx, retryCallIdempotent, fn)
+}
+
+// ModelGRPCInferCtx Call Triton Infer with GRPC on a healthy endpoint
+func (p *TritonClientPool) ModelGRPCInferCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) (response []interface{}, err error) {
+	err = p.doWithFailover(ctx, retryCallInference, func(client *TritonClientService) error {
+		response, err = client.ModelGRPCInferCtx(ctx, inferInputs, inferOutputs, rawInputs, modelName, modelVersion, decoderFunc, params...)
+		return err
+	})
+	return response, err
+}
+
+// ModelHTTPInferCtx Call Triton Infer with HTTP on a healthy endpoint
+func (p *TritonClientPool) ModelHTTPInferCtx(
+	ctx context.Context,
+	requestBody []byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) (response []interface{}, err error) {
+	err = p.doWithFailover(ctx, retryCallInference, func(client *TritonClientService) error {
+		response, err = client.ModelHTTPInferCtx(ctx, requestBody, modelName, modelVersion, decoderFunc, params...)
+		return err
+	})
+	return response, err
+}
+
+// ModelHTTPBinaryInferCtx Call Triton Infer with HTTP binary tensor data extension on a healthy endpoint
+func (p *TritonClientPool) ModelHTTPBinaryInferCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) (response []interface{}, err error) {
+	err = p.doWithFailover(ctx, retryCallInference, func(client *TritonClientService) error {
+		response, err = client.ModelHTTPBinaryInferCtx(ctx, inferInputs, inferOutputs, rawInputs, modelName, modelVersion, decoderFunc, params...)
+		return err
+	})
+	return response, err
+}
+
+// Endpoints status snapshot of all endpoints
+func (p *TritonClientPool) Endpoints() []PoolEndpointStatus {
+	status := make([]PoolEndpointStatus, len(p.endpoints))
+	for i, endpoint := range p.endpoints {
+		status[i] = PoolEndpointStatus{
+			Client:      endpoint.client,
+			Healthy:     endpoint.isHealthy(),
+			Outstanding: atomic.LoadInt64(&endpoint.outstanding),
+		}
+	}
+	return status
+}
+
+// HealthyCount count of healthy endpoints
+func (p *TritonClientPool) HealthyCount() int {
+	count := 0
+	for _, endpoint := range p.endpoints {
+		if endpoint.isHealthy() {
+			count++
+		}
+	}
+	return count
+}
+
+// Close stop health check, connections of clients are not closed
+func (p *TritonClientPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closeChan)
+		p.closeWait.Wait()
+	})
+}
+
+// ShutdownTritonConnection stop health check and shutdown connections of all clients
+func (p *TritonClientPool) ShutdownTritonConnection() (disconnectionErr error) {
+	p.Close()
+	for _, endpoint := range p.endpoints {
+		if shutdownErr := endpoint.client.ShutdownTritonConnection(); shutdownErr != nil {
+			disconnectionErr = shutdownErr
+		}
+	}
+	return disconnectionErr
+}
+
+// NewTritonClientPool init client pool with clients of every triton replica, all endpoints are healthy at beginning
+func NewTritonClientPool(clients []*TritonClientService, opts ...TritonClientPoolOption) (*TritonClientPool, error) {
+	pool := &TritonClientPool{
+		endpoints:           make([]*poolEndpoint, 0, len(clients)),
+		policy:              BalanceRoundRobin,
+		healthCheckInterval: DefaultPoolHealthCheckInterval,
+		healthCheckTimeout:  DefaultPoolHealthCheckTimeout,
+		healthCheckModels:   make(map[string]string),
+		unhealthyThreshold:  1,
+		healthyThreshold:    1,
+		closeChan:           make(chan struct{}),
+	}
+	for _, client := range clients {
+		if client == nil {
+			return nil, errors.New("[Pool]client is nil")
+		}
+		endpoint := &poolEndpoint{client: client}
+		endpoint.setHealthy(true)
+		pool.endpoints = append(pool.endpoints, endpoint)
+	}
+	if len(pool.endpoints) == 0 {
+		return nil, errors.New("[Pool]endpoints is empty")
+	}
+	for _, opt := range opts {
+		opt(pool)
+	}
+	if pool.healthCheckInterval > 0 {
+		pool.closeWait.Add(1)
+		go pool.healthCheckLoop()
+	}
+	return pool, nil
+}
@@ -0,0 +1,132 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	TransportHTTP string = "HTTP"
+	TransportGRPC string = "GRPC"
+)
+
+// Sentinel errors, use errors.Is(err, ErrXXX) to check the error returned by TritonClientService
+var (
+	ErrModelNotFound = errors.New("model not found")
+	ErrModelNotReady = errors.New("model not ready")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrTimeout       = errors.New("timeout")
+)
+
+// TritonError error returned by triton server or transport
+type TritonError struct {
+	// Transport TransportHTTP or TransportGRPC
+	Transport string
+	// StatusCode HTTP status code, 0 for GRPC or request is not sent
+	StatusCode int
+	// GRPCCode GRPC status code, codes.OK for HTTP
+	GRPCCode codes.Code
+	// Message triton error message, from HTTP response body {"error": "..."} or GRPC status message
+	Message string
+	// Operation triton API name, same as GRPC method name, like ModelInfer / ModelReady
+	Operation string
+	// ModelName / ModelVersion model of the request, empty for server API
+	ModelName    string
+	ModelVersion string
+	// Err underlying transport error
+	Err error
+}
+
+// Error format is compatible with the former string error: "[HTTP]code: xxx; error: xxx" and "[GRPC]error: xxx"
+func (e *TritonError) Error() string {
+	if e.Transport == TransportGRPC {
+		if e.Err != nil {
+			return "[GRPC]error: " + e.Err.Error()
+		}
+		return "[GRPC]error: " + e.Message
+	}
+	errMsg := "[HTTP]code: " + strconv.Itoa(e.StatusCode)
+	if e.Err != nil {
+		return errMsg + "; error: " + e.Err.Error()
+	}
+	if e.Message != "" {
+		return errMsg + "; error: " + e.Message
+	}
+	return errMsg
+}
+
+// Unwrap return underlying transport error
+func (e *TritonError) Unwrap() error {
+	return e.Err
+}
+
+// Is match sentinel errors by HTTP status code, GRPC code and triton error message,
+// GRPC call canceled by context matches context.Canceled like HTTP
+func (e *TritonError) Is(target error) bool {
+	switch target {
+	case context.Canceled:
+		return e.GRPCCode == codes.Canceled
+	case ErrModelNotFound:
+		return e.isModelNotFound()
+	case ErrModelNotReady:
+		return e.isModelNotReady()
+	case ErrInvalidInput:
+		return !e.isModelNotFound() && !e.isModelNotReady() &&
+			(e.StatusCode == fasthttp.StatusBadRequest || e.GRPCCode == codes.InvalidArgument)
+	case ErrTimeout:
+		return e.StatusCode == fasthttp.StatusRequestTimeout || e.StatusCode == fasthttp.StatusGatewayTimeout ||
+			e.GRPCCode == codes.DeadlineExceeded ||
+			errors.Is(e.Err, context.DeadlineExceeded) || errors.Is(e.Err, fasthttp.ErrTimeout)
+	}
+	return false
+}
+
+// modelNotReadyMessages triton messages of model which is known but not available, checked before "unknown model"
+// since triton also prefix them with "Request for unknown model"
+var modelNotReadyMessages = []string{"not at ready state", "no available versions", "not ready"}
+
+// isModelNotReadyMessage triton message of known but unavailable model
+func isModelNotReadyMessage(message string) bool {
+	for _, notReadyMessage := range modelNotReadyMessages {
+		if strings.Contains(message, notReadyMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// isModelNotFound triton report unknown model with 400 / NOT_FOUND and message "Request for unknown model: ...",
+// status code is not checked since 404 is also returned for wrong URL path and NOT_FOUND for other resources
+func (e *TritonError) isModelNotFound() bool {
+	message := strings.ToLower(e.Message)
+	return strings.Contains(message, "unknown model") && !isModelNotReadyMessage(message)
+}
+
+// isModelNotReady triton report model not ready with message "... is not at ready state" / "... has no available
+// versions" / "... is not ready" or non-200 status of ModelReady API
+func (e *TritonError) isModelNotReady() bool {
+	if isModelNotReadyMessage(strings.ToLower(e.Message)) {
+		return true
+	}
+	return e.Operation == "ModelReady" && e.Err == nil && !e.isModelNotFound() &&
+		(e.StatusCode == fasthttp.StatusBadRequest || e.StatusCode == fasthttp.StatusServiceUnavailable ||
+			e.GRPCCode == codes.Unavailable)
+}
+
+// parseHTTPErrorMessage parse triton error response body {"error": "..."}
+func parseHTTPErrorMessage(respBody []byte) string {
+	if len(respBody) == 0 {
+		return ""
+	}
+	errorResponse := new(ErrorResponseHTTPObj)
+	if jsonDecodeErr := json.Unmarshal(respBody, errorResponse); jsonDecodeErr != nil {
+		return ""
+	}
+	return errorResponse.Error
+}
@@ -0,0 +1,161 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	AuthorizationHeaderKey string = "Authorization"
+	BearerTokenPrefix      string = "Bearer "
+)
+
+// requestHeadersCtxKey context key for per-call headers
+type requestHeadersCtxKey struct{}
+
+// HeaderProvider provide headers for every request, headers will be set to http request headers
+// and grpc outgoing metadata.
+type HeaderProvider interface {
+	GetHeaders(ctx context.Context) (map[string]string, error)
+}
+
+// StaticHeaderProvider static headers, like tenant id
+type StaticHeaderProvider map[string]string
+
+// GetHeaders return static headers
+func (p StaticHeaderProvider) GetHeaders(_ context.Context) (map[string]string, error) {
+	return p, nil
+}
+
+// HeaderProviderFunc adapter to allow the use of ordinary functions as HeaderProvider
+type HeaderProviderFunc func(ctx context.Context) (map[string]string, error)
+
+// GetHeaders call f(ctx)
+func (f HeaderProviderFunc) GetHeaders(ctx context.Context) (map[string]string, error) {
+	return f(ctx)
+}
+
+// TokenRefreshFunc fetch a new token and its expiration time
+type TokenRefreshFunc func(ctx context.Context) (token string, expireAt time.Time, err error)
+
+// BearerTokenProvider provide "Authorization: Bearer <token>" header, token is refreshed before it expires.
+type BearerTokenProvider struct {
+	refreshFunc   TokenRefreshFunc
+	refreshBefore time.Duration
+
+	lock     sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+// GetHeaders return authorization header, refresh token if needed
+func (p *BearerTokenProvider) GetHeaders(ctx context.Context) (map[string]string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.token == "" || (!p.expireAt.IsZero() && time.Now().Add(p.refreshBefore).After(p.expireAt)) {
+		token, expireAt, refreshErr := p.refreshFunc(ctx)
+		if refreshErr != nil {
+			return nil, errors.New("[Auth]refresh token error: " + refreshErr.Error())
+		}
+		p.token, p.expireAt = token, expireAt
+	}
+	return map[string]string{AuthorizationHeaderKey: BearerTokenPrefix + p.token}, nil
+}
+
+// NewBearerTokenProvider create a BearerTokenProvider.
+// refreshBefore: refresh token when it will expire in refreshBefore duration.
+func NewBearerTokenProvider(refreshFunc TokenRefreshFunc, refreshBefore time.Duration) *BearerTokenProvider {
+	return &BearerTokenProvider{refreshFunc: refreshFunc, refreshBefore: refreshBefore}
+}
+
+// WithHeaderProvider is an option to inject headers into every http request and grpc metadata.
+// Providers are applied in order, the latter one overrides the former one with the same key.
+func WithHeaderProvider(providers ...HeaderProvider) TritonClientOption {
+	return func(t *TritonClientService) {
+		t.headerProviders = append(t.headerProviders, providers...)
+	}
+}
+
+// WithRequestHeaders return a copy of ctx carry per-call headers, which override headers from HeaderProvider.
+func WithRequestHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if existHeaders, ok := ctx.Value(requestHeadersCtxKey{}).(map[string]string); ok {
+		mergedHeaders := make(map[string]string, len(existHeaders)+len(headers))
+		for k, v := range existHeaders {
+			mergedHeaders[k] = v
+		}
+		for k, v := range headers {
+			mergedHeaders[k] = v
+		}
+		headers = mergedHeaders
+	}
+	return context.WithValue(ctx, requestHeadersCtxKey{}, headers)
+}
+
+// getRequestHeaders merge headers from providers and per-call headers
+func (t *TritonClientService) getRequestHeaders(ctx context.Context) (map[string]string, error) {
+	callHeaders, _ := ctx.Value(requestHeadersCtxKey{}).(map[string]string)
+	if len(t.headerProviders) == 0 {
+		return callHeaders, nil
+	}
+	headers := make(map[string]string)
+	for _, provider := range t.headerProviders {
+		providerHeaders, getErr := provider.GetHeaders(ctx)
+		if getErr != nil {
+			return nil, getErr
+		}
+		for k, v := range providerHeaders {
+			headers[k] = v
+		}
+	}
+	for k, v := range callHeaders {
+		headers[k] = v
+	}
+	return headers, nil
+}
+
+// appendGRPCMetadata append request headers to grpc outgoing metadata
+func (t *TritonClientService) appendGRPCMetadata(ctx context.Context) (context.Context, error) {
+	headers, getErr := t.getRequestHeaders(ctx)
+	if getErr != nil || len(headers) == 0 {
+		return ctx, getErr
+	}
+	kv := make([]string, 0, len(headers)*2)
+	for k, v := range headers {
+		kv = append(kv, k, v)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...), nil
+}
+
+// tritonGRPCConn wrap grpc.ClientConn to inject metadata for every call
+type tritonGRPCConn struct {
+	conn   *grpc.ClientConn
+	client *TritonClientService
+}
+
+// Invoke inject metadata and performs a unary RPC with retry policy
+func (c *tritonGRPCConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) (invokeErr error) {
+	ctx, headerErr := c.client.appendGRPCMetadata(ctx)
+	if headerErr != nil {
+		return headerErr
+	}
+	c.client.doWithRetry(ctx, grpcRetryCallKind(method), func(ctx context.Context) bool {
+		invokeErr = c.conn.Invoke(ctx, method, args, reply, opts...)
+		return ctx.Err() == nil && c.client.retryPolicy.isRetryableGRPC(invokeErr)
+	})
+	return invokeErr
+}
+
+// NewStream inject metadata and begins a streaming RPC
+func (c *tritonGRPCConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, headerErr := c.client.appendGRPCMetadata(ctx)
+	if headerErr != nil {
+		return nil, headerErr
+	}
+	return c.conn.NewStream(ctx, desc, method, opts...)
+}
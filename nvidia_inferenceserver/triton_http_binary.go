@@ -0,0 +1,533 @@
+package nvidia_inferenceserver
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	InferHeaderContentLengthKey  string = "Inference-Header-Content-Length"
+	OctetStreamContentType       string = "application/octet-stream"
+	BinaryDataParamKey           string = "binary_data"
+	BinaryDataSizeParamKey       string = "binary_data_size"
+	TritonDataTypeBool           string = "BOOL"
+	TritonDataTypeUint8          string = "UINT8"
+	TritonDataTypeUint16         string = "UINT16"
+	TritonDataTypeUint32         string = "UINT32"
+	TritonDataTypeUint64         string = "UINT64"
+	TritonDataTypeInt8           string = "INT8"
+	TritonDataTypeInt16          string = "INT16"
+	TritonDataTypeInt32          string = "INT32"
+	TritonDataTypeInt64          string = "INT64"
+	TritonDataTypeFP16           string = "FP16"
+	TritonDataTypeBF16           string = "BF16"
+	TritonDataTypeFP32           string = "FP32"
+	TritonDataTypeFP64           string = "FP64"
+	TritonDataTypeBytes          string = "BYTES"
+	bytesElementLengthPrefixSize int    = 4
+)
+
+// inferParameterToHTTPValue convert grpc InferParameter to http json value
+func inferParameterToHTTPValue(param *InferParameter) interface{} {
+	switch choice := param.GetParameterChoice().(type) {
+	case *InferParameter_BoolParam:
+		return choice.BoolParam
+	case *InferParameter_Int64Param:
+		return choice.Int64Param
+	case *InferParameter_StringParam:
+		return choice.StringParam
+	}
+	return nil
+}
+
+// inferParametersToHTTPObj convert grpc InferParameter map to http json parameters
+func inferParametersToHTTPObj(params map[string]*InferParameter) map[string]interface{} {
+	if len(params) == 0 {
+		return nil
+	}
+	httpParams := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		httpParams[k] = inferParameterToHTTPValue(v)
+	}
+	return httpParams
+}
+
+// httpValueToInferParameter convert http json value to grpc InferParameter
+func httpValueToInferParameter(value interface{}) *InferParameter {
+	switch v := value.(type) {
+	case bool:
+		return &InferParameter{ParameterChoice: &InferParameter_BoolParam{BoolParam: v}}
+	case string:
+		return &InferParameter{ParameterChoice: &InferParameter_StringParam{StringParam: v}}
+	case float64:
+		return &InferParameter{ParameterChoice: &InferParameter_Int64Param{Int64Param: int64(v)}}
+	case json.Number:
+		if intValue, parseErr := v.Int64(); parseErr == nil {
+			return &InferParameter{ParameterChoice: &InferParameter_Int64Param{Int64Param: intValue}}
+		}
+		return &InferParameter{ParameterChoice: &InferParameter_StringParam{StringParam: v.String()}}
+	}
+	return nil
+}
+
+// httpObjToInferParameters convert http json parameters to grpc InferParameter map
+func httpObjToInferParameters(httpParams map[string]interface{}) map[string]*InferParameter {
+	if len(httpParams) == 0 {
+		return nil
+	}
+	params := make(map[string]*InferParameter, len(httpParams))
+	for k, v := range httpParams {
+		if param := httpValueToInferParameter(v); param != nil {
+			params[k] = param
+		}
+	}
+	return params
+}
+
+// mergeHTTPInferRequestParameters merge request id and request level parameters into http json infer request body,
+// id is kept if requestID is empty, other fields of the body (inputs / outputs) are kept as they are.
+func mergeHTTPInferRequestParameters(
+	requestBody []byte, requestID string, params map[string]*InferParameter,
+) ([]byte, error) {
+	if requestID == "" && len(params) == 0 {
+		return requestBody, nil
+	}
+	requestObj := make(map[string]json.RawMessage)
+	if jsonDecodeErr := json.Unmarshal(requestBody, &requestObj); jsonDecodeErr != nil {
+		return nil, jsonDecodeErr
+	}
+	if requestID != "" {
+		rawID, jsonEncodeErr := json.Marshal(requestID)
+		if jsonEncodeErr != nil {
+			return nil, jsonEncodeErr
+		}
+		requestObj["id"] = rawID
+	}
+	if len(params) == 0 {
+		return json.Marshal(requestObj)
+	}
+	httpParams := make(map[string]interface{})
+	if rawParams, ok := requestObj["parameters"]; ok {
+		decoder := json.NewDecoder(bytes.NewReader(rawParams))
+		decoder.UseNumber()
+		if jsonDecodeErr := decoder.Decode(&httpParams); jsonDecodeErr != nil {
+			return nil, jsonDecodeErr
+		}
+	}
+	for k, v := range inferParametersToHTTPObj(params) {
+		httpParams[k] = v
+	}
+	rawParams, jsonEncodeErr := json.Marshal(httpParams)
+	if jsonEncodeErr != nil {
+		return nil, jsonEncodeErr
+	}
+	requestObj["parameters"] = rawParams
+	return json.Marshal(requestObj)
+}
+
+// getHTTPParamInt get integer parameter from http json parameters
+func getHTTPParamInt(httpParams map[string]interface{}, key string) (int, bool) {
+	switch v := httpParams[key].(type) {
+	case float64:
+		return int(v), true
+	case json.Number:
+		intValue, parseErr := v.Int64()
+		return int(intValue), parseErr == nil
+	}
+	return 0, false
+}
+
+// inferTensorContentsToHTTPData convert typed tensor contents to http json data
+func inferTensorContentsToHTTPData(contents *InferTensorContents) interface{} {
+	switch {
+	case len(contents.BoolContents) > 0:
+		return contents.BoolContents
+	case len(contents.IntContents) > 0:
+		return contents.IntContents
+	case len(contents.Int64Contents) > 0:
+		return contents.Int64Contents
+	case len(contents.UintContents) > 0:
+		return contents.UintContents
+	case len(contents.Uint64Contents) > 0:
+		return contents.Uint64Contents
+	case len(contents.Fp32Contents) > 0:
+		return contents.Fp32Contents
+	case len(contents.Fp64Contents) > 0:
+		return contents.Fp64Contents
+	case len(contents.BytesContents) > 0:
+		strContents := make([]string, len(contents.BytesContents))
+		for i, b := range contents.BytesContents {
+			strContents[i] = string(b)
+		}
+		return strContents
+	}
+	return []interface{}{}
+}
+
+// EncodeHTTPBinaryInferRequest encode infer request with binary tensor data extension.
+// Inputs which have RawInputContents are appended to json header as raw bytes, others use json data from Contents.
+// Outputs without "binary_data" parameter will be requested as binary data (except outputs in shared memory).
+// Return http request body and the json header length (Inference-Header-Content-Length).
+func EncodeHTTPBinaryInferRequest(request *ModelInferRequest) ([]byte, int, error) {
+	requestObj := InferRequestHTTPObj{
+		ID:         request.Id,
+		Parameters: inferParametersToHTTPObj(request.Parameters),
+		Inputs:     make([]InferInputTensorHTTPObj, len(request.Inputs)),
+		Outputs:    make([]InferOutputTensorHTTPObj, len(request.Outputs)),
+	}
+	var binaryInputs [][]byte
+	for i, input := range request.Inputs {
+		inputObj := InferInputTensorHTTPObj{
+			Name:       input.Name,
+			Shape:      input.Shape,
+			Datatype:   input.Datatype,
+			Parameters: inferParametersToHTTPObj(input.Parameters),
+		}
+		if i < len(request.RawInputContents) && request.RawInputContents[i] != nil {
+			if inputObj.Parameters == nil {
+				inputObj.Parameters = make(map[string]interface{}, 1)
+			}
+			inputObj.Parameters[BinaryDataSizeParamKey] = len(request.RawInputContents[i])
+			binaryInputs = append(binaryInputs, request.RawInputContents[i])
+		} else if input.Contents != nil {
+			inputObj.Data = inferTensorContentsToHTTPData(input.Contents)
+		}
+		requestObj.Inputs[i] = inputObj
+	}
+	for i, output := range request.Outputs {
+		outputObj := InferOutputTensorHTTPObj{Name: output.Name, Parameters: inferParametersToHTTPObj(output.Parameters)}
+		if outputObj.Parameters == nil {
+			outputObj.Parameters = make(map[string]interface{}, 1)
+		}
+		// output written into shared memory is not returned in response body
+		if _, ok := outputObj.Parameters[BinaryDataParamKey]; !ok && !isSharedMemoryParameters(output.Parameters) {
+			outputObj.Parameters[BinaryDataParamKey] = true
+		}
+		requestObj.Outputs[i] = outputObj
+	}
+	jsonHeader, jsonEncodeErr := json.Marshal(&requestObj)
+	if jsonEncodeErr != nil {
+		return nil, 0, jsonEncodeErr
+	}
+	bodyLength := len(jsonHeader)
+	for _, binaryInput := range binaryInputs {
+		bodyLength += len(binaryInput)
+	}
+	requestBody := make([]byte, 0, bodyLength)
+	requestBody = append(requestBody, jsonHeader...)
+	for _, binaryInput := range binaryInputs {
+		requestBody = append(requestBody, binaryInput...)
+	}
+	return requestBody, len(jsonHeader), nil
+}
+
+// DecodeHTTPBinaryInferResponse decode infer response with binary tensor data extension to ModelInferResponse.
+// headerLength is the value of response header Inference-Header-Content-Length, 0 means the whole body is json.
+// Binary outputs are set to RawOutputContents (same index as Outputs), json outputs are set to Outputs[i].Contents.
+func DecodeHTTPBinaryInferResponse(responseBody []byte, headerLength int) (*ModelInferResponse, error) {
+	if headerLength <= 0 || headerLength > len(responseBody) {
+		headerLength = len(responseBody)
+	}
+	responseObj := new(InferResponseHTTPObj)
+	decoder := json.NewDecoder(bytes.NewReader(responseBody[:headerLength]))
+	decoder.UseNumber()
+	if jsonDecodeErr := decoder.Decode(responseObj); jsonDecodeErr != nil {
+		return nil, jsonDecodeErr
+	}
+	inferResponse := &ModelInferResponse{
+		ModelName:    responseObj.ModelName,
+		ModelVersion: responseObj.ModelVersion,
+		Id:           responseObj.ID,
+		Parameters:   httpObjToInferParameters(responseObj.Parameters),
+		Outputs:      make([]*ModelInferResponse_InferOutputTensor, len(responseObj.Outputs)),
+	}
+	binaryOffset := headerLength
+	hasBinaryOutput := false
+	rawOutputs := make([][]byte, len(responseObj.Outputs))
+	for i, outputObj := range responseObj.Outputs {
+		outputTensor := &ModelInferResponse_InferOutputTensor{
+			Name:     outputObj.Name,
+			Datatype: outputObj.Datatype,
+			Shape:    outputObj.Shape,
+		}
+		if binarySize, ok := getHTTPParamInt(outputObj.Parameters, BinaryDataSizeParamKey); ok {
+			if binaryOffset+binarySize > len(responseBody) {
+				return nil, errors.New("binary data of output " + outputObj.Name + " out of range, size: " +
+					strconv.Itoa(binarySize))
+			}
+			rawOutputs[i] = responseBody[binaryOffset : binaryOffset+binarySize]
+			binaryOffset += binarySize
+			hasBinaryOutput = true
+			delete(outputObj.Parameters, BinaryDataSizeParamKey)
+		} else if len(outputObj.Data) > 0 {
+			contents, decodeErr := decodeHTTPJSONDataToInferTensorContents(outputObj.Datatype, outputObj.Data)
+			if decodeErr != nil {
+				return nil, errors.New("decode output " + outputObj.Name + " error: " + decodeErr.Error())
+			}
+			outputTensor.Contents = contents
+		}
+		outputTensor.Parameters = httpObjToInferParameters(outputObj.Parameters)
+		inferResponse.Outputs[i] = outputTensor
+	}
+	if hasBinaryOutput {
+		inferResponse.RawOutputContents = rawOutputs
+	}
+	return inferResponse, nil
+}
+
+// DecodeHTTPBinaryInferRequest decode infer request with binary tensor data extension to ModelInferRequest.
+// headerLength is the value of request header Inference-Header-Content-Length, 0 means the whole body is json.
+// Binary inputs are set to RawInputContents (same index as Inputs), json inputs are set to Inputs[i].Contents.
+func DecodeHTTPBinaryInferRequest(requestBody []byte, headerLength int) (*ModelInferRequest, error) {
+	if headerLength <= 0 || headerLength > len(requestBody) {
+		headerLength = len(requestBody)
+	}
+	requestObj := new(InferRequestHTTPObj)
+	decoder := json.NewDecoder(bytes.NewReader(requestBody[:headerLength]))
+	decoder.UseNumber()
+	if jsonDecodeErr := decoder.Decode(requestObj); jsonDecodeErr != nil {
+		return nil, jsonDecodeErr
+	}
+	inferRequest := &ModelInferRequest{
+		Id:         requestObj.ID,
+		Parameters: httpObjToInferParameters(requestObj.Parameters),
+		Inputs:     make([]*ModelInferRequest_InferInputTensor, len(requestObj.Inputs)),
+		Outputs:    make([]*ModelInferRequest_InferRequestedOutputTensor, len(requestObj.Outputs)),
+	}
+	binaryOffset := headerLength
+	hasBinaryInput := false
+	rawInputs := make([][]byte, len(requestObj.Inputs))
+	for i, inputObj := range requestObj.Inputs {
+		inputTensor := &ModelInferRequest_InferInputTensor{
+			Name:     inputObj.Name,
+			Datatype: inputObj.Datatype,
+			Shape:    inputObj.Shape,
+		}
+		if binarySize, ok := getHTTPParamInt(inputObj.Parameters, BinaryDataSizeParamKey); ok {
+			if binaryOffset+binarySize > len(requestBody) {
+				return nil, errors.New("binary data of input " + inputObj.Name + " out of range, size: " +
+					strconv.Itoa(binarySize))
+			}
+			rawInputs[i] = requestBody[binaryOffset : binaryOffset+binarySize]
+			binaryOffset += binarySize
+			hasBinaryInput = true
+			delete(inputObj.Parameters, BinaryDataSizeParamKey)
+		} else if inputObj.Data != nil {
+			contents, decodeErr := jsonValueToInferTensorContents(inputObj.Datatype, inputObj.Data)
+			if decodeErr != nil {
+				return nil, errors.New("decode input " + inputObj.Name + " error: " + decodeErr.Error())
+			}
+			inputTensor.Contents = contents
+		}
+		inputTensor.Parameters = httpObjToInferParameters(inputObj.Parameters)
+		inferRequest.Inputs[i] = inputTensor
+	}
+	if hasBinaryInput {
+		inferRequest.RawInputContents = rawInputs
+	}
+	for i, outputObj := range requestObj.Outputs {
+		inferRequest.Outputs[i] = &ModelInferRequest_InferRequestedOutputTensor{
+			Name:       outputObj.Name,
+			Parameters: httpObjToInferParameters(outputObj.Parameters),
+		}
+	}
+	return inferRequest, nil
+}
+
+// EncodeHTTPBinaryInferResponse encode infer response with binary tensor data extension.
+// Outputs which have RawOutputContents are appended to json header as raw bytes, others use json data from Contents.
+// Return http response body and the json header length (Inference-Header-Content-Length), 0 if no binary output.
+func EncodeHTTPBinaryInferResponse(response *ModelInferResponse) ([]byte, int, error) {
+	responseObj := InferResponseHTTPObj{
+		ModelName:    response.ModelName,
+		ModelVersion: response.ModelVersion,
+		ID:           response.Id,
+		Parameters:   inferParametersToHTTPObj(response.Parameters),
+		Outputs:      make([]InferResponseOutputHTTPObj, len(response.Outputs)),
+	}
+	var binaryOutputs [][]byte
+	for i, output := range response.Outputs {
+		outputObj := InferResponseOutputHTTPObj{
+			Name:       output.Name,
+			Shape:      output.Shape,
+			Datatype:   output.Datatype,
+			Parameters: inferParametersToHTTPObj(output.Parameters),
+		}
+		if i < len(response.RawOutputContents) && response.RawOutputContents[i] != nil {
+			if outputObj.Parameters == nil {
+				outputObj.Parameters = make(map[string]interface{}, 1)
+			}
+			outputObj.Parameters[BinaryDataSizeParamKey] = len(response.RawOutputContents[i])
+			binaryOutputs = append(binaryOutputs, response.RawOutputContents[i])
+		} else if output.Contents != nil {
+			data, jsonEncodeErr := json.Marshal(inferTensorContentsToHTTPData(output.Contents))
+			if jsonEncodeErr != nil {
+				return nil, 0, jsonEncodeErr
+			}
+			outputObj.Data = data
+		}
+		responseObj.Outputs[i] = outputObj
+	}
+	jsonHeader, jsonEncodeErr := json.Marshal(&responseObj)
+	if jsonEncodeErr != nil {
+		return nil, 0, jsonEncodeErr
+	}
+	if len(binaryOutputs) == 0 {
+		return jsonHeader, 0, nil
+	}
+	responseBody := jsonHeader
+	for _, binaryOutput := range binaryOutputs {
+		responseBody = append(responseBody, binaryOutput...)
+	}
+	return responseBody, len(jsonHeader), nil
+}
+
+// flattenJSONData flatten nested json array into a flat slice
+func flattenJSONData(data interface{}, result []interface{}) []interface{} {
+	if arr, ok := data.([]interface{}); ok {
+		for _, item := range arr {
+			result = flattenJSONData(item, result)
+		}
+		return result
+	}
+	return append(result, data)
+}
+
+// jsonNumberToFloat64 convert json number to float64
+func jsonNumberToFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case float64:
+		return v, nil
+	}
+	return 0, errors.New("value is not a number")
+}
+
+// jsonNumberToInt64 convert json number to int64
+func jsonNumberToInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Int64()
+	case float64:
+		return int64(v), nil
+	}
+	return 0, errors.New("value is not a number")
+}
+
+// jsonNumberToUint64 convert json number to uint64
+func jsonNumberToUint64(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return strconv.ParseUint(v.String(), 10, 64)
+	case float64:
+		return uint64(v), nil
+	}
+	return 0, errors.New("value is not a number")
+}
+
+// decodeHTTPJSONDataToInferTensorContents decode http json tensor data to typed tensor contents
+func decodeHTTPJSONDataToInferTensorContents(datatype string, data json.RawMessage) (*InferTensorContents, error) {
+	var rawData interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if jsonDecodeErr := decoder.Decode(&rawData); jsonDecodeErr != nil {
+		return nil, jsonDecodeErr
+	}
+	return jsonValueToInferTensorContents(datatype, rawData)
+}
+
+// jsonValueToInferTensorContents convert decoded (nested) json array to typed tensor contents
+func jsonValueToInferTensorContents(datatype string, rawData interface{}) (*InferTensorContents, error) {
+	flatData := flattenJSONData(rawData, nil)
+	contents := new(InferTensorContents)
+	for _, value := range flatData {
+		switch datatype {
+		case TritonDataTypeBool:
+			boolValue, ok := value.(bool)
+			if !ok {
+				return nil, errors.New("value is not a bool")
+			}
+			contents.BoolContents = append(contents.BoolContents, boolValue)
+		case TritonDataTypeInt8, TritonDataTypeInt16, TritonDataTypeInt32:
+			intValue, convertErr := jsonNumberToInt64(value)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			contents.IntContents = append(contents.IntContents, int32(intValue))
+		case TritonDataTypeInt64:
+			intValue, convertErr := jsonNumberToInt64(value)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			contents.Int64Contents = append(contents.Int64Contents, intValue)
+		case TritonDataTypeUint8, TritonDataTypeUint16, TritonDataTypeUint32:
+			uintValue, convertErr := jsonNumberToUint64(value)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			contents.UintContents = append(contents.UintContents, uint32(uintValue))
+		case TritonDataTypeUint64:
+			uintValue, convertErr := jsonNumberToUint64(value)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			contents.Uint64Contents = append(contents.Uint64Contents, uintValue)
+		case TritonDataTypeFP16, TritonDataTypeBF16, TritonDataTypeFP32:
+			floatValue, convertErr := jsonNumberToFloat64(value)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			contents.Fp32Contents = append(contents.Fp32Contents, float32(floatValue))
+		case TritonDataTypeFP64:
+			floatValue, convertErr := jsonNumberToFloat64(value)
+			if convertErr != nil {
+				return nil, convertErr
+			}
+			contents.Fp64Contents = append(contents.Fp64Contents, floatValue)
+		case TritonDataTypeBytes:
+			strValue, ok := value.(string)
+			if !ok {
+				return nil, errors.New("value is not a string")
+			}
+			contents.BytesContents = append(contents.BytesContents, []byte(strValue))
+		default:
+			return nil, errors.New("unsupported datatype: " + datatype)
+		}
+	}
+	return contents, nil
+}
+
+// RawContentsToInferTensorContents decode little-endian raw tensor bytes to typed tensor contents.
+// FP16 / BF16 are converted to Fp32Contents, BYTES elements are 4-bytes length prefixed.
+func RawContentsToInferTensorContents(datatype string, raw []byte) (*InferTensorContents, error) {
+	var decodeErr error
+	contents := new(InferTensorContents)
+	switch datatype {
+	case TritonDataTypeBool:
+		contents.BoolContents = DecodeBoolRawContents(raw)
+	case TritonDataTypeInt8, TritonDataTypeInt16, TritonDataTypeInt32:
+		contents.IntContents, decodeErr = DecodeRawContentsToNumeric[int32](datatype, raw)
+	case TritonDataTypeInt64:
+		contents.Int64Contents, decodeErr = DecodeRawContentsToNumeric[int64](datatype, raw)
+	case TritonDataTypeUint8, TritonDataTypeUint16, TritonDataTypeUint32:
+		contents.UintContents, decodeErr = DecodeRawContentsToNumeric[uint32](datatype, raw)
+	case TritonDataTypeUint64:
+		contents.Uint64Contents, decodeErr = DecodeRawContentsToNumeric[uint64](datatype, raw)
+	case TritonDataTypeFP16, TritonDataTypeBF16, TritonDataTypeFP32:
+		contents.Fp32Contents, decodeErr = DecodeRawContentsToNumeric[float32](datatype, raw)
+	case TritonDataTypeFP64:
+		contents.Fp64Contents, decodeErr = DecodeRawContentsToNumeric[float64](datatype, raw)
+	case TritonDataTypeBytes:
+		contents.BytesContents, decodeErr = DecodeBytesRawContents(raw)
+	default:
+		return nil, errors.New("unsupported datatype: " + datatype)
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return contents, nil
+}
@@ -0,0 +1,127 @@
+package nvidia_inferenceserver
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	InferPriorityParamKey string = "priority"
+	InferTimeoutParamKey  string = "timeout"
+)
+
+// InferOptions per-request inference options
+type InferOptions struct {
+	// RequestID request id, triton echoes it back in the response id
+	RequestID string
+	// Priority request priority, 0 means default priority of the model, 1 is the highest priority
+	Priority int64
+	// Timeout server side timeout (queue timeout of the request), sent in microseconds, 0 means no timeout
+	Timeout time.Duration
+	// Parameters custom request parameters, priority / timeout in it will be overridden by the fields above
+	Parameters map[string]*InferParameter
+}
+
+// inferParameters build request parameters of options, return nil if options is empty
+func (o *InferOptions) inferParameters() map[string]*InferParameter {
+	if o == nil || (len(o.Parameters) == 0 && o.Priority == 0 && o.Timeout == 0) {
+		return nil
+	}
+	params := make(map[string]*InferParameter, len(o.Parameters)+2)
+	for k, v := range o.Parameters {
+		params[k] = v
+	}
+	if o.Priority > 0 {
+		params[InferPriorityParamKey] = &InferParameter{
+			ParameterChoice: &InferParameter_Int64Param{Int64Param: o.Priority},
+		}
+	}
+	if o.Timeout > 0 {
+		params[InferTimeoutParamKey] = &InferParameter{
+			ParameterChoice: &InferParameter_Int64Param{Int64Param: o.Timeout.Microseconds()},
+		}
+	}
+	return params
+}
+
+// newInferRequest create infer request with options
+func (o *InferOptions) newInferRequest(
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+) *ModelInferRequest {
+	request := &ModelInferRequest{
+		ModelName:        modelName,
+		ModelVersion:     modelVersion,
+		Parameters:       o.inferParameters(),
+		Inputs:           inferInputs,
+		Outputs:          inferOutputs,
+		RawInputContents: rawInputs,
+	}
+	if o != nil {
+		request.Id = o.RequestID
+	}
+	return request
+}
+
+// echoInferResponseID set response id to request id if server does not return it
+func echoInferResponseID(request *ModelInferRequest, response *ModelInferResponse) {
+	if response != nil && response.Id == "" {
+		response.Id = request.Id
+	}
+}
+
+// ModelHTTPInferWithOptionsCtx Call Triton Infer with HTTP, context and options.
+// Request id and parameters of options are merged into requestBody.
+func (t *TritonClientService) ModelHTTPInferWithOptionsCtx(
+	ctx context.Context,
+	requestBody []byte,
+	modelName, modelVersion string,
+	options *InferOptions,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	requestID := ""
+	if options != nil {
+		requestID = options.RequestID
+	}
+	optionsRequestBody, mergeErr := mergeHTTPInferRequestParameters(requestBody, requestID, options.inferParameters())
+	if mergeErr != nil {
+		return nil, mergeErr
+	}
+	return t.ModelHTTPInferCtx(ctx, optionsRequestBody, modelName, modelVersion, decoderFunc, params...)
+}
+
+// ModelHTTPBinaryInferWithOptionsCtx Call Triton Infer with HTTP binary tensor data extension, context and options
+func (t *TritonClientService) ModelHTTPBinaryInferWithOptionsCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	options *InferOptions,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	return t.modelHTTPBinaryInfer(
+		ctx, options.newInferRequest(inferInputs, inferOutputs, rawInputs, modelName, modelVersion),
+		decoderFunc, params...)
+}
+
+// ModelGRPCInferWithOptionsCtx Call Triton Infer with GRPC, context and options
+func (t *TritonClientService) ModelGRPCInferWithOptionsCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	options *InferOptions,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	return t.modelGRPCInfer(
+		ctx, options.newInferRequest(inferInputs, inferOutputs, rawInputs, modelName, modelVersion),
+		decoderFunc, params...)
+}
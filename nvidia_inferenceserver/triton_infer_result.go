@@ -0,0 +1,268 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+)
+
+// InferResultDecoderFunc transport-agnostic infer callback function, receive decoded InferResult
+type InferResultDecoderFunc func(result *InferResult, params ...interface{}) ([]interface{}, error)
+
+// InferOutput named output tensor of InferResult
+type InferOutput struct {
+	Name       string
+	Datatype   string
+	Shape      []int64
+	Parameters map[string]*InferParameter
+
+	raw      []byte
+	contents *InferTensorContents
+}
+
+// InferResult uniform infer result decoded from GRPC ModelInferResponse or HTTP json / binary response
+type InferResult struct {
+	ModelName    string
+	ModelVersion string
+	ID           string
+	Parameters   map[string]*InferParameter
+	Outputs      []*InferOutput
+}
+
+// contentsToNumeric convert typed tensor contents to go numeric slice
+func contentsToNumeric[T TensorNumeric](contents *InferTensorContents) ([]T, error) {
+	switch {
+	case len(contents.IntContents) > 0:
+		return convertNumericSlice[int32, T](contents.IntContents), nil
+	case len(contents.Int64Contents) > 0:
+		return convertNumericSlice[int64, T](contents.Int64Contents), nil
+	case len(contents.UintContents) > 0:
+		return convertNumericSlice[uint32, T](contents.UintContents), nil
+	case len(contents.Uint64Contents) > 0:
+		return convertNumericSlice[uint64, T](contents.Uint64Contents), nil
+	case len(contents.Fp32Contents) > 0:
+		return convertNumericSlice[float32, T](contents.Fp32Contents), nil
+	case len(contents.Fp64Contents) > 0:
+		return convertNumericSlice[float64, T](contents.Fp64Contents), nil
+	case len(contents.BoolContents) > 0:
+		data := make([]T, len(contents.BoolContents))
+		for i, value := range contents.BoolContents {
+			if value {
+				data[i] = 1
+			}
+		}
+		return data, nil
+	case len(contents.BytesContents) > 0:
+		return nil, errors.New("BYTES tensor can not convert to numeric")
+	}
+	return []T{}, nil
+}
+
+// convertNumericSlice cast numeric slice from S to T
+func convertNumericSlice[S, T TensorNumeric](data []S) []T {
+	result := make([]T, len(data))
+	for i, value := range data {
+		result[i] = T(value)
+	}
+	return result
+}
+
+// asNumeric decode output to go numeric slice
+func asNumeric[T TensorNumeric](o *InferOutput) ([]T, error) {
+	if o.Datatype == TritonDataTypeBytes {
+		return nil, errors.New("output " + o.Name + " is BYTES tensor, can not convert to numeric")
+	}
+	if o.raw != nil {
+		data, decodeErr := DecodeRawContentsToNumeric[T](o.Datatype, o.raw)
+		if decodeErr != nil {
+			return nil, errors.New("output " + o.Name + ": " + decodeErr.Error())
+		}
+		return data, nil
+	}
+	if o.contents != nil {
+		return contentsToNumeric[T](o.contents)
+	}
+	return nil, errors.New("output " + o.Name + " has no data")
+}
+
+// Raw little-endian raw contents of output, nil if output is decoded from json data.
+func (o *InferOutput) Raw() []byte {
+	return o.raw
+}
+
+// AsFloat32 output data as float32 slice, numeric values are cast to float32
+func (o *InferOutput) AsFloat32() ([]float32, error) { return asNumeric[float32](o) }
+
+// AsFloat64 output data as float64 slice, numeric values are cast to float64
+func (o *InferOutput) AsFloat64() ([]float64, error) { return asNumeric[float64](o) }
+
+// AsInt32 output data as int32 slice, numeric values are cast to int32
+func (o *InferOutput) AsInt32() ([]int32, error) { return asNumeric[int32](o) }
+
+// AsInt64 output data as int64 slice, numeric values are cast to int64
+func (o *InferOutput) AsInt64() ([]int64, error) { return asNumeric[int64](o) }
+
+// AsUint64 output data as uint64 slice, numeric values are cast to uint64
+func (o *InferOutput) AsUint64() ([]uint64, error) { return asNumeric[uint64](o) }
+
+// AsBool output data as bool slice
+func (o *InferOutput) AsBool() ([]bool, error) {
+	if o.Datatype != TritonDataTypeBool {
+		return nil, errors.New("output " + o.Name + " is " + o.Datatype + " tensor, not BOOL")
+	}
+	if o.raw != nil {
+		return DecodeBoolRawContents(o.raw), nil
+	}
+	if o.contents != nil {
+		return o.contents.BoolContents, nil
+	}
+	return nil, errors.New("output " + o.Name + " has no data")
+}
+
+// AsBytes output data as BYTES elements
+func (o *InferOutput) AsBytes() ([][]byte, error) {
+	if o.Datatype != TritonDataTypeBytes {
+		return nil, errors.New("output " + o.Name + " is " + o.Datatype + " tensor, not BYTES")
+	}
+	if o.raw != nil {
+		data, decodeErr := DecodeBytesRawContents(o.raw)
+		if decodeErr != nil {
+			return nil, errors.New("output " + o.Name + ": " + decodeErr.Error())
+		}
+		return data, nil
+	}
+	if o.contents != nil {
+		return o.contents.BytesContents, nil
+	}
+	return nil, errors.New("output " + o.Name + " has no data")
+}
+
+// AsStrings output data as string slice, only for BYTES tensor
+func (o *InferOutput) AsStrings() ([]string, error) {
+	data, decodeErr := o.AsBytes()
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	strData := make([]string, len(data))
+	for i, element := range data {
+		strData[i] = string(element)
+	}
+	return strData, nil
+}
+
+// ElementCount element count of output
+func (o *InferOutput) ElementCount() int64 {
+	count, _ := ShapeElementCount(o.Shape)
+	return count
+}
+
+// Output get output by name
+func (r *InferResult) Output(name string) (*InferOutput, error) {
+	for _, output := range r.Outputs {
+		if output.Name == name {
+			return output, nil
+		}
+	}
+	return nil, errors.New("output " + name + " not found in infer result")
+}
+
+// OutputNames names of all outputs
+func (r *InferResult) OutputNames() []string {
+	names := make([]string, len(r.Outputs))
+	for i, output := range r.Outputs {
+		names[i] = output.Name
+	}
+	return names
+}
+
+// NewInferResultFromGRPC decode GRPC ModelInferResponse to InferResult
+func NewInferResultFromGRPC(response *ModelInferResponse) (*InferResult, error) {
+	if response == nil {
+		return nil, errors.New("infer response is nil")
+	}
+	result := &InferResult{
+		ModelName:    response.ModelName,
+		ModelVersion: response.ModelVersion,
+		ID:           response.Id,
+		Parameters:   response.Parameters,
+		Outputs:      make([]*InferOutput, len(response.Outputs)),
+	}
+	for i, outputTensor := range response.Outputs {
+		output := &InferOutput{
+			Name:       outputTensor.Name,
+			Datatype:   outputTensor.Datatype,
+			Shape:      outputTensor.Shape,
+			Parameters: outputTensor.Parameters,
+			contents:   outputTensor.Contents,
+		}
+		if i < len(response.RawOutputContents) && response.RawOutputContents[i] != nil {
+			output.raw = response.RawOutputContents[i]
+		}
+		result.Outputs[i] = output
+	}
+	return result, nil
+}
+
+// NewInferResultFromHTTP decode HTTP json / binary response to InferResult.
+// headerLength is the value of response header Inference-Header-Content-Length, 0 means the whole body is json.
+func NewInferResultFromHTTP(responseBody []byte, headerLength int) (*InferResult, error) {
+	inferResponse, decodeErr := DecodeHTTPBinaryInferResponse(responseBody, headerLength)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return NewInferResultFromGRPC(inferResponse)
+}
+
+// NewInferResult decode response received by DecoderFunc to InferResult.
+// response can be *ModelInferResponse (GRPC / HTTP binary infer) or []byte (HTTP infer),
+// json header length of []byte response with binary data is detected automatically.
+func NewInferResult(response interface{}) (*InferResult, error) {
+	switch resp := response.(type) {
+	case *ModelInferResponse:
+		return NewInferResultFromGRPC(resp)
+	case []byte:
+		return NewInferResultFromHTTP(resp, findJSONHeaderLength(resp))
+	case *InferResult:
+		return resp, nil
+	}
+	return nil, errors.New("unsupported infer response type")
+}
+
+// NewInferResultDecoder wrap InferResultDecoderFunc to DecoderFunc, so decoder can be shared between GRPC and HTTP.
+func NewInferResultDecoder(decoderFunc InferResultDecoderFunc) DecoderFunc {
+	return func(response interface{}, params ...interface{}) ([]interface{}, error) {
+		result, decodeErr := NewInferResult(response)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		return decoderFunc(result, params...)
+	}
+}
+
+// findJSONHeaderLength find the end of the top-level json object at the beginning of body
+func findJSONHeaderLength(body []byte) int {
+	depth, inString, isEscaped := 0, false, false
+	for i, c := range body {
+		if inString {
+			switch {
+			case isEscaped:
+				isEscaped = false
+			case c == '\\':
+				isEscaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(body)
+}
@@ -0,0 +1,503 @@
+package nvidia_inferenceserver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const EnsemblePlatform string = "ensemble"
+
+// ErrInvalidModelConfig model config does not pass ValidateModelConfig
+var ErrInvalidModelConfig = errors.New("[Config]invalid model config")
+
+// ModelConfigBuilder build ModelConfig step by step, errors of every step are reported by Build
+type ModelConfigBuilder struct {
+	config *ModelConfig
+	errs   []string
+}
+
+// Platform set platform, like "onnxruntime_onnx" / "tensorrt_plan" / EnsemblePlatform
+func (b *ModelConfigBuilder) Platform(platform string) *ModelConfigBuilder {
+	b.config.Platform = platform
+	return b
+}
+
+// Backend set backend, like "onnxruntime" / "python"
+func (b *ModelConfigBuilder) Backend(backend string) *ModelConfigBuilder {
+	b.config.Backend = backend
+	return b
+}
+
+// MaxBatchSize set max batch size, 0 means model does not support batching and dims include batch dimension
+func (b *ModelConfigBuilder) MaxBatchSize(maxBatchSize int32) *ModelConfigBuilder {
+	b.config.MaxBatchSize = maxBatchSize
+	return b
+}
+
+// Input add input tensor, dims exclude batch dimension when max batch size > 0, -1 means variable-size dimension
+func (b *ModelConfigBuilder) Input(name string, dataType DataType, dims ...int64) *ModelConfigBuilder {
+	return b.AddInput(&ModelInput{Name: name, DataType: dataType, Dims: dims})
+}
+
+// AddInput add input tensor with full settings (format / reshape / optional ...)
+func (b *ModelConfigBuilder) AddInput(input *ModelInput) *ModelConfigBuilder {
+	b.config.Input = append(b.config.Input, input)
+	return b
+}
+
+// Output add output tensor, dims exclude batch dimension when max batch size > 0, -1 means variable-size dimension
+func (b *ModelConfigBuilder) Output(name string, dataType DataType, dims ...int64) *ModelConfigBuilder {
+	return b.AddOutput(&ModelOutput{Name: name, DataType: dataType, Dims: dims})
+}
+
+// AddOutput add output tensor with full settings (reshape / label file ...)
+func (b *ModelConfigBuilder) AddOutput(output *ModelOutput) *ModelConfigBuilder {
+	b.config.Output = append(b.config.Output, output)
+	return b
+}
+
+// InstanceGroup add instance group with count instances of kind on gpus (all gpus if empty)
+func (b *ModelConfigBuilder) InstanceGroup(kind ModelInstanceGroup_Kind, count int32, gpus ...int32) *ModelConfigBuilder {
+	b.config.InstanceGroup = append(b.config.InstanceGroup, &ModelInstanceGroup{Kind: kind, Count: count, Gpus: gpus})
+	return b
+}
+
+// DynamicBatching enable dynamic batcher, replaces sequence batching or ensemble scheduling
+func (b *ModelConfigBuilder) DynamicBatching(maxQueueDelay time.Duration, preferredBatchSizes ...int32) *ModelConfigBuilder {
+	b.config.SchedulingChoice = &ModelConfig_DynamicBatching{DynamicBatching: &ModelDynamicBatching{
+		PreferredBatchSize:        preferredBatchSizes,
+		MaxQueueDelayMicroseconds: uint64(maxQueueDelay.Microseconds()),
+	}}
+	return b
+}
+
+// SequenceBatching enable sequence batcher with direct strategy, replaces dynamic batching or ensemble scheduling
+func (b *ModelConfigBuilder) SequenceBatching(maxSequenceIdle time.Duration) *ModelConfigBuilder {
+	b.config.SchedulingChoice = &ModelConfig_SequenceBatching{SequenceBatching: &ModelSequenceBatching{
+		StrategyChoice:              &ModelSequenceBatching_Direct{Direct: &ModelSequenceBatching_StrategyDirect{}},
+		MaxSequenceIdleMicroseconds: uint64(maxSequenceIdle.Microseconds()),
+	}}
+	return b
+}
+
+// SequenceBatchingOldest enable sequence batcher with oldest strategy, replaces dynamic batching or ensemble scheduling
+func (b *ModelConfigBuilder) SequenceBatchingOldest(
+	maxSequenceIdle time.Duration, maxCandidateSequences int32, maxQueueDelay time.Duration, preferredBatchSizes ...int32,
+) *ModelConfigBuilder {
+	b.config.SchedulingChoice = &ModelConfig_SequenceBatching{SequenceBatching: &ModelSequenceBatching{
+		StrategyChoice: &ModelSequenceBatching_Oldest{Oldest: &ModelSequenceBatching_StrategyOldest{
+			MaxCandidateSequences:     maxCandidateSequences,
+			PreferredBatchSize:        preferredBatchSizes,
+			MaxQueueDelayMicroseconds: uint64(maxQueueDelay.Microseconds()),
+		}},
+		MaxSequenceIdleMicroseconds: uint64(maxSequenceIdle.Microseconds()),
+	}}
+	return b
+}
+
+// SequenceControl add control input tensor of sequence batcher, SequenceBatching must be called before.
+// START / END / READY controls use false / true value of dataType (TYPE_INT32, TYPE_FP32 or TYPE_BOOL),
+// CORRID control use dataType as correlation id type (TYPE_UINT64 or TYPE_STRING).
+func (b *ModelConfigBuilder) SequenceControl(
+	tensorName string, kind ModelSequenceBatching_Control_Kind, dataType DataType,
+) *ModelConfigBuilder {
+	sequenceBatching := b.config.GetSequenceBatching()
+	if sequenceBatching == nil {
+		b.errs = append(b.errs, "sequence control "+tensorName+" requires sequence batching")
+		return b
+	}
+	control := &ModelSequenceBatching_Control{Kind: kind}
+	switch {
+	case kind == ModelSequenceBatching_Control_CONTROL_SEQUENCE_CORRID:
+		control.DataType = dataType
+	case dataType == DataType_TYPE_INT32:
+		control.Int32FalseTrue = []int32{0, 1}
+	case dataType == DataType_TYPE_FP32:
+		control.Fp32FalseTrue = []float32{0, 1}
+	case dataType == DataType_TYPE_BOOL:
+		control.BoolFalseTrue = []bool{false, true}
+	default:
+		b.errs = append(b.errs, "unsupported data type "+dataType.String()+" of sequence control "+tensorName)
+		return b
+	}
+	sequenceBatching.ControlInput = append(sequenceBatching.ControlInput, &ModelSequenceBatching_ControlInput{
+		Name: tensorName, Control: []*ModelSequenceBatching_Control{control},
+	})
+	return b
+}
+
+// EnsembleStep add step of ensemble and set platform to EnsemblePlatform.
+// inputMap / outputMap map tensor name of step model to ensemble tensor name, modelVersion -1 means latest version.
+func (b *ModelConfigBuilder) EnsembleStep(
+	modelName string, modelVersion int64, inputMap, outputMap map[string]string,
+) *ModelConfigBuilder {
+	ensemble := b.config.GetEnsembleScheduling()
+	if ensemble == nil {
+		ensemble = new(ModelEnsembling)
+		b.config.SchedulingChoice = &ModelConfig_EnsembleScheduling{EnsembleScheduling: ensemble}
+	}
+	ensemble.Step = append(ensemble.Step, &ModelEnsembling_Step{
+		ModelName: modelName, ModelVersion: modelVersion, InputMap: inputMap, OutputMap: outputMap,
+	})
+	b.config.Platform = EnsemblePlatform
+	return b
+}
+
+// Parameter set model parameter which is passed to backend
+func (b *ModelConfigBuilder) Parameter(key, value string) *ModelConfigBuilder {
+	if b.config.Parameters == nil {
+		b.config.Parameters = make(map[string]*ModelParameter)
+	}
+	b.config.Parameters[key] = &ModelParameter{StringValue: value}
+	return b
+}
+
+// Build validate and return the model config
+func (b *ModelConfigBuilder) Build() (*ModelConfig, error) {
+	problems := append(append([]string(nil), b.errs...), modelConfigProblems(b.config)...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidModelConfig, strings.Join(problems, "; "))
+	}
+	return b.config, nil
+}
+
+// JSON build and render config as json for HTTP / GRPC load request
+func (b *ModelConfigBuilder) JSON() ([]byte, error) {
+	config, buildErr := b.Build()
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	return MarshalModelConfigJSON(config)
+}
+
+// Pbtxt build and render config as config.pbtxt text format
+func (b *ModelConfigBuilder) Pbtxt() ([]byte, error) {
+	config, buildErr := b.Build()
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	return MarshalModelConfigText(config)
+}
+
+// NewModelConfigBuilder create builder of model config with model name
+func NewModelConfigBuilder(modelName string) *ModelConfigBuilder {
+	return &ModelConfigBuilder{config: &ModelConfig{Name: modelName}}
+}
+
+// MarshalModelConfigJSON render config as json with proto field names, the format of triton "config" load parameter
+func MarshalModelConfigJSON(config *ModelConfig) ([]byte, error) {
+	configBody, jsonEncodeErr := protojson.MarshalOptions{UseProtoNames: true}.Marshal(config)
+	if jsonEncodeErr != nil {
+		return nil, errors.New("[Config]encode model config error: " + jsonEncodeErr.Error())
+	}
+	return configBody, nil
+}
+
+// MarshalModelConfigText render config as canonical protobuf text format of config.pbtxt,
+// fields are in field number order, map entries are sorted by key and every field takes one line.
+func MarshalModelConfigText(config *ModelConfig) ([]byte, error) {
+	return marshalCanonicalText(config.ProtoReflect()), nil
+}
+
+// marshalCanonicalText render message as multiline text format. prototext output is unstable on purpose
+// (random spaces), so message is written by protoreflect with fixed layout.
+func marshalCanonicalText(message protoreflect.Message) []byte {
+	var buf bytes.Buffer
+	writeTextMessage(&buf, "", message)
+	return buf.Bytes()
+}
+
+// writeTextMessage write set fields of message in field number order, one field per line with indent
+func writeTextMessage(buf *bytes.Buffer, indent string, message protoreflect.Message) {
+	fields := message.Descriptor().Fields()
+	numbers := make([]int, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		numbers = append(numbers, int(fields.Get(i).Number()))
+	}
+	sort.Ints(numbers)
+	for _, number := range numbers {
+		field := fields.ByNumber(protoreflect.FieldNumber(number))
+		value := message.Get(field)
+		switch {
+		case field.IsList():
+			for i := 0; i < value.List().Len(); i++ {
+				writeTextField(buf, indent, field.TextName(), field, value.List().Get(i))
+			}
+		case field.IsMap():
+			writeTextMap(buf, indent, field, value.Map())
+		case message.Has(field):
+			writeTextField(buf, indent, field.TextName(), field, value)
+		}
+	}
+}
+
+// writeTextMap write map entries sorted by key as repeated {key, value} messages
+func writeTextMap(buf *bytes.Buffer, indent string, field protoreflect.FieldDescriptor, entries protoreflect.Map) {
+	keys := make([]protoreflect.MapKey, 0, entries.Len())
+	entries.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, key := range keys {
+		buf.WriteString(indent + field.TextName() + ": {\n")
+		writeTextField(buf, indent+"  ", "key", field.MapKey(), key.Value())
+		writeTextField(buf, indent+"  ", "value", field.MapValue(), entries.Get(key))
+		buf.WriteString(indent + "}\n")
+	}
+}
+
+// writeTextField write "name: value" line, message value is written as nested block
+func writeTextField(
+	buf *bytes.Buffer, indent, name string, field protoreflect.FieldDescriptor, value protoreflect.Value,
+) {
+	if field.Message() != nil {
+		buf.WriteString(indent + name + ": {\n")
+		writeTextMessage(buf, indent+"  ", value.Message())
+		buf.WriteString(indent + "}\n")
+		return
+	}
+	buf.WriteString(indent + name + ": " + scalarText(field, value) + "\n")
+}
+
+// scalarText text format of scalar value, enum values use names
+func scalarText(field protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	switch field.Kind() {
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+		return strconv.Itoa(int(value.Enum()))
+	case protoreflect.StringKind:
+		return strconv.Quote(value.String())
+	case protoreflect.BytesKind:
+		return strconv.Quote(string(value.Bytes()))
+	case protoreflect.FloatKind:
+		return floatText(value.Float(), 32)
+	case protoreflect.DoubleKind:
+		return floatText(value.Float(), 64)
+	}
+	return value.String()
+}
+
+// floatText text format of float value, special values use inf / -inf / nan
+func floatText(v float64, bitSize int) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	case math.IsNaN(v):
+		return "nan"
+	}
+	return strconv.FormatFloat(v, 'g', -1, bitSize)
+}
+
+// problems of model config which triton resolves by itself when config is loaded from model repository
+const (
+	modelNameEmptyProblem       string = "model name is empty"
+	modelPlatformMissingProblem string = "platform or backend must be specified"
+)
+
+// ValidateModelConfig check names, dims, batching and ensemble tensor wiring of config, error wraps ErrInvalidModelConfig
+func ValidateModelConfig(config *ModelConfig) error {
+	if problems := modelConfigProblems(config); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidModelConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// modelConfigProblems list all problems of config
+func modelConfigProblems(config *ModelConfig) []string {
+	if config == nil {
+		return []string{"config is nil"}
+	}
+	var problems []string
+	if config.Name == "" {
+		problems = append(problems, modelNameEmptyProblem)
+	}
+	if config.Platform == "" && config.Backend == "" {
+		problems = append(problems, modelPlatformMissingProblem)
+	}
+	if config.MaxBatchSize < 0 {
+		problems = append(problems, "max_batch_size must be non-negative")
+	}
+
+	inputNames := make(map[string]bool, len(config.Input))
+	for _, input := range config.Input {
+		problems = append(problems, tensorProblems("input", input.Name, input.Dims, input.Reshape, inputNames)...)
+	}
+	outputNames := make(map[string]bool, len(config.Output))
+	for _, output := range config.Output {
+		problems = append(problems, tensorProblems("output", output.Name, output.Dims, output.Reshape, outputNames)...)
+	}
+
+	for i, group := range config.InstanceGroup {
+		if group.Count < 0 {
+			problems = append(problems, "instance_group "+strconv.Itoa(i)+" count must be non-negative")
+		}
+		for _, gpu := range group.Gpus {
+			if gpu < 0 {
+				problems = append(problems, "instance_group "+strconv.Itoa(i)+" has negative gpu id")
+			}
+		}
+	}
+
+	if dynamicBatching := config.GetDynamicBatching(); dynamicBatching != nil {
+		if config.MaxBatchSize == 0 {
+			problems = append(problems, "dynamic_batching requires max_batch_size > 0")
+		}
+		problems = append(problems, preferredBatchSizeProblems(config.MaxBatchSize, dynamicBatching.PreferredBatchSize)...)
+	}
+	if sequenceBatching := config.GetSequenceBatching(); sequenceBatching != nil {
+		problems = append(problems,
+			preferredBatchSizeProblems(config.MaxBatchSize, sequenceBatching.GetOldest().GetPreferredBatchSize())...)
+		controlNames := make(map[string]bool, len(sequenceBatching.ControlInput))
+		for _, controlInput := range sequenceBatching.ControlInput {
+			if controlInput.Name == "" {
+				problems = append(problems, "sequence control input name is empty")
+			} else if controlNames[controlInput.Name] || inputNames[controlInput.Name] {
+				problems = append(problems, "duplicate sequence control input "+controlInput.Name)
+			}
+			controlNames[controlInput.Name] = true
+		}
+	}
+
+	ensemble := config.GetEnsembleScheduling()
+	switch {
+	case config.Platform == EnsemblePlatform && ensemble == nil:
+		problems = append(problems, "ensemble model requires ensemble_scheduling")
+	case config.Platform != EnsemblePlatform && ensemble != nil:
+		problems = append(problems, "ensemble_scheduling requires platform "+EnsemblePlatform)
+	case ensemble != nil:
+		problems = append(problems, ensembleProblems(config, ensemble)...)
+	}
+	return problems
+}
+
+// tensorProblems check tensor name is unique and dims are valid
+func tensorProblems(kind, name string, dims []int64, reshape *ModelTensorReshape, names map[string]bool) []string {
+	var problems []string
+	if name == "" {
+		return []string{kind + " name is empty"}
+	}
+	if names[name] {
+		problems = append(problems, "duplicate "+kind+" "+name)
+	}
+	names[name] = true
+	if len(dims) == 0 && reshape == nil {
+		problems = append(problems, kind+" "+name+" dims is empty")
+	}
+	for _, dim := range dims {
+		if dim == 0 || dim < -1 {
+			problems = append(problems, kind+" "+name+" has invalid dim "+strconv.FormatInt(dim, 10))
+			break
+		}
+	}
+	if reshape != nil {
+		if size, reshapeSize := staticElementCount(dims), staticElementCount(reshape.Shape); size > 0 && reshapeSize > 0 && size != reshapeSize {
+			problems = append(problems, kind+" "+name+" reshape element count does not match dims")
+		}
+	}
+	return problems
+}
+
+// staticElementCount element count of dims, -1 if dims has variable-size dimension
+func staticElementCount(dims []int64) int64 {
+	count := int64(1)
+	for _, dim := range dims {
+		if dim < 0 {
+			return -1
+		}
+		count *= dim
+	}
+	return count
+}
+
+// preferredBatchSizeProblems preferred batch sizes must be in (0, maxBatchSize]
+func preferredBatchSizeProblems(maxBatchSize int32, preferredBatchSizes []int32) []string {
+	for _, batchSize := range preferredBatchSizes {
+		if batchSize <= 0 || batchSize > maxBatchSize {
+			return []string{"preferred_batch_size " + strconv.Itoa(int(batchSize)) +
+				" must be in (0, max_batch_size " + strconv.Itoa(int(maxBatchSize)) + "]"}
+		}
+	}
+	return nil
+}
+
+// ensembleProblems check every step input is an ensemble input or produced by another step without cycle,
+// every ensemble tensor is produced once and every ensemble output is produced.
+func ensembleProblems(config *ModelConfig, ensemble *ModelEnsembling) []string {
+	if len(ensemble.Step) == 0 {
+		return []string{"ensemble_scheduling has no step"}
+	}
+	var problems []string
+	available := make(map[string]bool, len(config.Input))
+	for _, input := range config.Input {
+		available[input.Name] = true
+	}
+	producers := make(map[string]int)
+	for i, step := range ensemble.Step {
+		if step.ModelName == "" {
+			problems = append(problems, "ensemble step "+strconv.Itoa(i)+" model name is empty")
+		}
+		for _, tensorName := range step.OutputMap {
+			if available[tensorName] {
+				problems = append(problems, "ensemble tensor "+tensorName+" is an ensemble input and step output")
+			} else if _, ok := producers[tensorName]; ok {
+				problems = append(problems, "ensemble tensor "+tensorName+" is produced by more than one step")
+			}
+			producers[tensorName] = i
+		}
+	}
+	// schedule steps whose inputs are available until no more step can run
+	scheduled := make([]bool, len(ensemble.Step))
+	for progress := true; progress; {
+		progress = false
+		for i, step := range ensemble.Step {
+			if scheduled[i] || !stepInputsAvailable(step, available) {
+				continue
+			}
+			scheduled[i], progress = true, true
+			for _, tensorName := range step.OutputMap {
+				available[tensorName] = true
+			}
+		}
+	}
+	for i, step := range ensemble.Step {
+		if scheduled[i] {
+			continue
+		}
+		for _, tensorName := range step.InputMap {
+			if _, ok := producers[tensorName]; !ok && !available[tensorName] {
+				problems = append(problems, "ensemble step "+strconv.Itoa(i)+" input "+tensorName+" is not produced")
+			}
+		}
+		problems = append(problems, "ensemble step "+strconv.Itoa(i)+" ("+step.ModelName+") can not be scheduled")
+	}
+	for _, output := range config.Output {
+		if _, ok := producers[output.Name]; !ok {
+			problems = append(problems, "ensemble output "+output.Name+" is not produced by any step")
+		}
+	}
+	return problems
+}
+
+// stepInputsAvailable all input tensors of step are available
+func stepInputsAvailable(step *ModelEnsembling_Step, available map[string]bool) bool {
+	for _, tensorName := range step.InputMap {
+		if !available[tensorName] {
+			return false
+		}
+	}
+	return true
+}
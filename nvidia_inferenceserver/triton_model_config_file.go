@@ -0,0 +1,196 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const ModelConfigFileName string = "config.pbtxt"
+
+// ModelConfigDiffUnset value of ModelConfigDiff when field is not set on one side
+const ModelConfigDiffUnset string = "<unset>"
+
+// pbtxtLineBreakRegexp line break with indent of multiline text format
+var pbtxtLineBreakRegexp = regexp.MustCompile(`\n\s*`)
+
+// ModelConfigDiff difference of one field between two model configs
+type ModelConfigDiff struct {
+	// Path field path with proto field names, like "max_batch_size" / "input[0].dims[1]" / `parameters["key"]`
+	Path string
+	// Old / New text of field value, ModelConfigDiffUnset if field is not set
+	Old string
+	New string
+}
+
+// String format diff as "path: old -> new"
+func (d ModelConfigDiff) String() string {
+	return d.Path + ": " + d.Old + " -> " + d.New
+}
+
+// ParseModelConfigText parse config.pbtxt content (protobuf text format) into ModelConfig
+func ParseModelConfigText(data []byte) (*ModelConfig, error) {
+	config := new(ModelConfig)
+	if textDecodeErr := prototext.Unmarshal(data, config); textDecodeErr != nil {
+		return nil, errors.New("[Config]decode model config error: " + textDecodeErr.Error())
+	}
+	return config, nil
+}
+
+// ReadModelConfigFile read config.pbtxt file into ModelConfig
+func ReadModelConfigFile(path string) (*ModelConfig, error) {
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		return nil, errors.New("[Config]read model config error: " + readErr.Error())
+	}
+	config, parseErr := ParseModelConfigText(data)
+	if parseErr != nil {
+		return nil, fmt.Errorf("%w (%s)", parseErr, path)
+	}
+	return config, nil
+}
+
+// WriteModelConfigFile write config into config.pbtxt file in canonical text format of MarshalModelConfigText
+func WriteModelConfigFile(path string, config *ModelConfig) error {
+	data, marshalErr := MarshalModelConfigText(config)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	if writeErr := os.WriteFile(path, data, 0644); writeErr != nil {
+		return errors.New("[Config]write model config error: " + writeErr.Error())
+	}
+	return nil
+}
+
+// DiffModelConfig compare two configs field by field, return nil if they are equal.
+// Repeated fields are compared by index and map fields by key, diffs are in proto declaration order.
+func DiffModelConfig(oldConfig, newConfig *ModelConfig) []ModelConfigDiff {
+	if oldConfig == nil {
+		oldConfig = new(ModelConfig)
+	}
+	if newConfig == nil {
+		newConfig = new(ModelConfig)
+	}
+	var diffs []ModelConfigDiff
+	diffMessage("", oldConfig.ProtoReflect(), newConfig.ProtoReflect(), &diffs)
+	return diffs
+}
+
+// diffMessage append diffs of every field of message
+func diffMessage(path string, oldMessage, newMessage protoreflect.Message, diffs *[]ModelConfigDiff) {
+	fields := oldMessage.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		fieldPath := field.TextName()
+		if path != "" {
+			fieldPath = path + "." + fieldPath
+		}
+		switch {
+		case field.IsList():
+			diffList(fieldPath, field, oldMessage.Get(field).List(), newMessage.Get(field).List(), diffs)
+		case field.IsMap():
+			diffMap(fieldPath, field, oldMessage.Get(field).Map(), newMessage.Get(field).Map(), diffs)
+		case field.Message() != nil:
+			oldHas, newHas := oldMessage.Has(field), newMessage.Has(field)
+			if oldHas && newHas {
+				diffMessage(fieldPath, oldMessage.Get(field).Message(), newMessage.Get(field).Message(), diffs)
+			} else if oldHas != newHas {
+				*diffs = append(*diffs, ModelConfigDiff{
+					Path: fieldPath,
+					Old:  presentValueText(oldHas, field, oldMessage.Get(field)),
+					New:  presentValueText(newHas, field, newMessage.Get(field)),
+				})
+			}
+		default:
+			diffValue(fieldPath, field, oldMessage.Get(field), newMessage.Get(field), diffs)
+		}
+	}
+}
+
+// diffList compare list elements by index, extra elements are reported as unset on the other side
+func diffList(path string, field protoreflect.FieldDescriptor, oldList, newList protoreflect.List, diffs *[]ModelConfigDiff) {
+	for i := 0; i < oldList.Len() || i < newList.Len(); i++ {
+		elementPath := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= oldList.Len():
+			*diffs = append(*diffs, ModelConfigDiff{
+				Path: elementPath, Old: ModelConfigDiffUnset, New: valueText(field, newList.Get(i)),
+			})
+		case i >= newList.Len():
+			*diffs = append(*diffs, ModelConfigDiff{
+				Path: elementPath, Old: valueText(field, oldList.Get(i)), New: ModelConfigDiffUnset,
+			})
+		case field.Message() != nil:
+			diffMessage(elementPath, oldList.Get(i).Message(), newList.Get(i).Message(), diffs)
+		default:
+			diffValue(elementPath, field, oldList.Get(i), newList.Get(i), diffs)
+		}
+	}
+}
+
+// diffMap compare map values by sorted keys
+func diffMap(path string, field protoreflect.FieldDescriptor, oldMap, newMap protoreflect.Map, diffs *[]ModelConfigDiff) {
+	keys := make(map[string]protoreflect.MapKey, oldMap.Len()+newMap.Len())
+	collectKeys := func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys[key.String()] = key
+		return true
+	}
+	oldMap.Range(collectKeys)
+	newMap.Range(collectKeys)
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	valueField := field.MapValue()
+	for _, key := range sortedKeys {
+		mapKey := keys[key]
+		entryPath := path + "[" + strconv.Quote(key) + "]"
+		oldHas, newHas := oldMap.Has(mapKey), newMap.Has(mapKey)
+		switch {
+		case oldHas != newHas:
+			*diffs = append(*diffs, ModelConfigDiff{
+				Path: entryPath,
+				Old:  presentValueText(oldHas, valueField, oldMap.Get(mapKey)),
+				New:  presentValueText(newHas, valueField, newMap.Get(mapKey)),
+			})
+		case valueField.Message() != nil:
+			diffMessage(entryPath, oldMap.Get(mapKey).Message(), newMap.Get(mapKey).Message(), diffs)
+		default:
+			diffValue(entryPath, valueField, oldMap.Get(mapKey), newMap.Get(mapKey), diffs)
+		}
+	}
+}
+
+// diffValue compare scalar values
+func diffValue(path string, field protoreflect.FieldDescriptor, oldValue, newValue protoreflect.Value, diffs *[]ModelConfigDiff) {
+	oldText, newText := valueText(field, oldValue), valueText(field, newValue)
+	if oldText != newText {
+		*diffs = append(*diffs, ModelConfigDiff{Path: path, Old: oldText, New: newText})
+	}
+}
+
+// presentValueText value text if field is present, otherwise ModelConfigDiffUnset
+func presentValueText(isPresent bool, field protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	if !isPresent {
+		return ModelConfigDiffUnset
+	}
+	return valueText(field, value)
+}
+
+// valueText text of value like protobuf text format, enum values use names and messages use single line text
+func valueText(field protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	if field.Message() != nil {
+		text := marshalCanonicalText(value.Message())
+		return "{" + strings.TrimSpace(pbtxtLineBreakRegexp.ReplaceAllString(string(text), " ")) + "}"
+	}
+	return scalarText(field, value)
+}
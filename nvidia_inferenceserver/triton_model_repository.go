@@ -0,0 +1,252 @@
+package nvidia_inferenceserver
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/net/context"
+)
+
+const (
+	ModelLoadConfigParamKey       string = "config"
+	ModelLoadFileParamPrefix      string = "file:"
+	ModelUnloadDependentsParamKey string = "unload_dependents"
+
+	DefaultModelReadyPollInterval = 200 * time.Millisecond
+)
+
+// ErrModelReadyDeadline model is not ready before deadline of context or ModelLoadOptions.ReadyTimeout
+var ErrModelReadyDeadline = errors.New("[Repo]model is not ready before deadline")
+
+// ModelLoadOptions options to load model by ModelRepositoryManager
+type ModelLoadOptions struct {
+	// RepositoryName repository to load model from (GRPC only), empty means any repository
+	RepositoryName string
+	// Config override model config, sent as json string in "config" parameter
+	Config *ModelConfig
+	// Files override model files, key is the file path relative to model directory like "1/model.onnx".
+	// Triton requires Config when Files is not empty.
+	Files map[string][]byte
+	// ModelVersion version to wait ready, empty means the version chosen by version policy
+	ModelVersion string
+	// ReadyTimeout max time to wait model ready after load request, 0 means wait until ctx is done
+	ReadyTimeout time.Duration
+	// NoWait return after load request without waiting model ready
+	NoWait bool
+}
+
+// validate check options before sending load request
+func (o *ModelLoadOptions) validate() error {
+	if o != nil && len(o.Files) > 0 && o.Config == nil {
+		return errors.New("[Repo]model config is required when override model files")
+	}
+	return nil
+}
+
+// configJSON serialize override config as json string of proto field names
+func (o *ModelLoadOptions) configJSON() (string, error) {
+	configBody, encodeErr := MarshalModelConfigJSON(o.Config)
+	if encodeErr != nil {
+		return "", encodeErr
+	}
+	return string(configBody), nil
+}
+
+// grpcParameters build RepositoryModelLoadRequest parameters, config is string and files are bytes
+func (o *ModelLoadOptions) grpcParameters() (map[string]*ModelRepositoryParameter, error) {
+	if o == nil || o.Config == nil {
+		return nil, nil
+	}
+	configBody, encodeErr := o.configJSON()
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	params := make(map[string]*ModelRepositoryParameter, len(o.Files)+1)
+	params[ModelLoadConfigParamKey] = &ModelRepositoryParameter{
+		ParameterChoice: &ModelRepositoryParameter_StringParam{StringParam: configBody},
+	}
+	for path, content := range o.Files {
+		params[ModelLoadFileParamPrefix+strings.TrimPrefix(path, "/")] = &ModelRepositoryParameter{
+			ParameterChoice: &ModelRepositoryParameter_BytesParam{BytesParam: content},
+		}
+	}
+	return params, nil
+}
+
+// httpBody build load request body, config is string and files are base64 strings, nil body if no override
+func (o *ModelLoadOptions) httpBody() ([]byte, error) {
+	if o == nil || o.Config == nil {
+		return nil, nil
+	}
+	configBody, encodeErr := o.configJSON()
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	requestObj := ModelRepositoryRequestHTTPObj{Parameters: make(map[string]interface{}, len(o.Files)+1)}
+	requestObj.Parameters[ModelLoadConfigParamKey] = configBody
+	for path, content := range o.Files {
+		requestObj.Parameters[ModelLoadFileParamPrefix+strings.TrimPrefix(path, "/")] =
+			base64.StdEncoding.EncodeToString(content)
+	}
+	return json.Marshal(requestObj)
+}
+
+// ModelRepositoryManager load / unload models of one triton server and wait model ready
+type ModelRepositoryManager struct {
+	client       *TritonClientService
+	pollInterval time.Duration
+}
+
+// ModelRepositoryOption option of ModelRepositoryManager
+type ModelRepositoryOption func(*ModelRepositoryManager)
+
+// WithModelReadyPollInterval set interval to poll CheckModelReady, default is DefaultModelReadyPollInterval
+func WithModelReadyPollInterval(interval time.Duration) ModelRepositoryOption {
+	return func(m *ModelRepositoryManager) {
+		if interval > 0 {
+			m.pollInterval = interval
+		}
+	}
+}
+
+// Client triton client of manager
+func (m *ModelRepositoryManager) Client() *TritonClientService { return m.client }
+
+// LoadModel load (or reload) model with optional config and files override, then wait model ready.
+// GRPC is used if client has GRPC connection, otherwise HTTP.
+func (m *ModelRepositoryManager) LoadModel(ctx context.Context, modelName string, options *ModelLoadOptions) error {
+	if validateErr := options.validate(); validateErr != nil {
+		return validateErr
+	}
+	if m.client.grpcClient != nil {
+		params, encodeErr := options.grpcParameters()
+		if encodeErr != nil {
+			return encodeErr
+		}
+		repoName := ""
+		if options != nil {
+			repoName = options.RepositoryName
+		}
+		if _, loadErr := m.client.ModelLoadWithGRPCCtx(ctx, repoName, modelName, params); loadErr != nil {
+			return loadErr
+		}
+	} else {
+		requestBody, encodeErr := options.httpBody()
+		if encodeErr != nil {
+			return encodeErr
+		}
+		if _, loadErr := m.client.ModelLoadWithHTTPCtx(ctx, modelName, requestBody); loadErr != nil {
+			return loadErr
+		}
+	}
+	if options != nil && options.NoWait {
+		return nil
+	}
+	modelVersion := ""
+	if options != nil {
+		modelVersion = options.ModelVersion
+		if options.ReadyTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.ReadyTimeout)
+			defer cancel()
+		}
+	}
+	return m.WaitModelReady(ctx, modelName, modelVersion)
+}
+
+// WaitModelReady poll CheckModelReady until model is ready or ctx is done.
+// Not ready / not found / transport errors are retried, ErrModelReadyDeadline wraps the last error when ctx is done.
+func (m *ModelRepositoryManager) WaitModelReady(ctx context.Context, modelName, modelVersion string) error {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		isReady, readyErr := m.client.CheckModelReadyCtx(ctx, modelName, modelVersion)
+		if readyErr == nil && isReady {
+			return nil
+		}
+		if readyErr != nil && ctx.Err() == nil {
+			lastErr = readyErr
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%w: %s, last error: %v", ErrModelReadyDeadline, modelName, lastErr)
+			}
+			return fmt.Errorf("%w: %s", ErrModelReadyDeadline, modelName)
+		case <-ticker.C:
+		}
+	}
+}
+
+// UnloadModel unload model, unloadDependents also unloads the models depending on it (like ensembles)
+func (m *ModelRepositoryManager) UnloadModel(ctx context.Context, modelName string, unloadDependents bool) error {
+	if m.client.grpcClient != nil {
+		var params map[string]*ModelRepositoryParameter
+		if unloadDependents {
+			params = map[string]*ModelRepositoryParameter{
+				ModelUnloadDependentsParamKey: {ParameterChoice: &ModelRepositoryParameter_BoolParam{BoolParam: true}},
+			}
+		}
+		_, unloadErr := m.client.ModelUnloadWithGRPCCtx(ctx, "", modelName, params)
+		return unloadErr
+	}
+	var requestBody []byte
+	if unloadDependents {
+		var encodeErr error
+		requestBody, encodeErr = json.Marshal(ModelRepositoryRequestHTTPObj{
+			Parameters: map[string]interface{}{ModelUnloadDependentsParamKey: true},
+		})
+		if encodeErr != nil {
+			return encodeErr
+		}
+	}
+	_, unloadErr := m.client.ModelUnloadWithHTTPCtx(ctx, modelName, requestBody)
+	return unloadErr
+}
+
+// NewModelRepositoryManager create model repository manager of client
+func NewModelRepositoryManager(client *TritonClientService, opts ...ModelRepositoryOption) *ModelRepositoryManager {
+	manager := &ModelRepositoryManager{client: client, pollInterval: DefaultModelReadyPollInterval}
+	for _, opt := range opts {
+		opt(manager)
+	}
+	return manager
+}
+
+// RollingReloadModel reload model on servers one by one, every server must be ready before moving to the next one.
+// It stops at the first failed server so that the remaining servers keep serving the previous model.
+func RollingReloadModel(
+	ctx context.Context,
+	clients []*TritonClientService,
+	modelName string,
+	options *ModelLoadOptions,
+	opts ...ModelRepositoryOption,
+) error {
+	for i, client := range clients {
+		if client == nil {
+			return errors.New("[Repo]client is nil")
+		}
+		if loadErr := NewModelRepositoryManager(client, opts...).LoadModel(ctx, modelName, options); loadErr != nil {
+			return fmt.Errorf("[Repo]rolling reload stopped at server %d/%d (%s): %w",
+				i+1, len(clients), client.ServerURL, loadErr)
+		}
+	}
+	return nil
+}
+
+// RollingReloadModel reload model on every endpoint of pool one by one, see RollingReloadModel
+func (p *TritonClientPool) RollingReloadModel(
+	ctx context.Context, modelName string, options *ModelLoadOptions, opts ...ModelRepositoryOption,
+) error {
+	clients := make([]*TritonClientService, len(p.endpoints))
+	for i, endpoint := range p.endpoints {
+		clients[i] = endpoint.client
+	}
+	return RollingReloadModel(ctx, clients, modelName, options, opts...)
+}
@@ -0,0 +1,330 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultModelFilenames default model file name of platform / backend, checked in every version directory
+var defaultModelFilenames = map[string]string{
+	"onnxruntime_onnx":      "model.onnx",
+	"onnxruntime":           "model.onnx",
+	"tensorrt_plan":         "model.plan",
+	"tensorrt":              "model.plan",
+	"tensorflow_graphdef":   "model.graphdef",
+	"tensorflow_savedmodel": "model.savedmodel",
+	"pytorch_libtorch":      "model.pt",
+	"pytorch":               "model.pt",
+	"python":                "model.py",
+	"openvino":              "model.xml",
+}
+
+// autoCompleteModelFilenames model files of backends which triton can auto-complete config from
+// (missing config.pbtxt, or config without platform / backend)
+var autoCompleteModelFilenames = []string{"model.onnx", "model.plan", "model.savedmodel", "model.xml", "model.py"}
+
+// RepositoryProblem problem found by ScanModelRepository
+type RepositoryProblem struct {
+	// Model model directory name, empty for problem of repository
+	Model string
+	// Path file or directory of the problem
+	Path string
+	// Message problem description
+	Message string
+}
+
+// String format problem as "model: message (path)"
+func (p RepositoryProblem) String() string {
+	if p.Model == "" {
+		return p.Message + " (" + p.Path + ")"
+	}
+	return p.Model + ": " + p.Message + " (" + p.Path + ")"
+}
+
+// RepositoryModel model found in repository
+type RepositoryModel struct {
+	// Name model directory name
+	Name string
+	// Path model directory
+	Path string
+	// Config parsed config.pbtxt, nil if config is missing (auto-completed by triton) or invalid
+	Config *ModelConfig
+	// Versions numeric version directories in ascending order
+	Versions []int64
+}
+
+// hasVersion version directory exists
+func (m *RepositoryModel) hasVersion(version int64) bool {
+	for _, v := range m.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// RepositoryReport models, problems and warnings of model repository.
+// Warnings (like config auto-completed by triton) do not fail the check.
+type RepositoryReport struct {
+	Path     string
+	Models   []*RepositoryModel
+	Problems []RepositoryProblem
+	Warnings []RepositoryProblem
+}
+
+// OK repository has no problem
+func (r *RepositoryReport) OK() bool { return len(r.Problems) == 0 }
+
+// Model get model by name
+func (r *RepositoryReport) Model(modelName string) (*RepositoryModel, bool) {
+	for _, model := range r.Models {
+		if model.Name == modelName {
+			return model, true
+		}
+	}
+	return nil, false
+}
+
+// addProblem record problem
+func (r *RepositoryReport) addProblem(modelName, path, message string) {
+	r.Problems = append(r.Problems, RepositoryProblem{Model: modelName, Path: path, Message: message})
+}
+
+// addWarning record warning
+func (r *RepositoryReport) addWarning(modelName, path, message string) {
+	r.Warnings = append(r.Warnings, RepositoryProblem{Model: modelName, Path: path, Message: message})
+}
+
+// ScanModelRepository walk triton model repository (<model>/config.pbtxt, <model>/<version>/...) and check
+// config, version directories against version policy, backend model files and ensemble references.
+// Error is returned only if repository directory can not be read, problems are reported in RepositoryReport.
+func ScanModelRepository(repositoryPath string) (*RepositoryReport, error) {
+	entries, readErr := os.ReadDir(repositoryPath)
+	if readErr != nil {
+		return nil, errors.New("[Repo]read model repository error: " + readErr.Error())
+	}
+	report := &RepositoryReport{Path: repositoryPath}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		report.Models = append(report.Models, scanModel(report, filepath.Join(repositoryPath, entry.Name())))
+	}
+	if len(report.Models) == 0 {
+		report.addProblem("", repositoryPath, "no model directory found")
+	}
+	for _, model := range report.Models {
+		checkEnsembleReferences(report, model)
+	}
+	return report, nil
+}
+
+// scanModel parse config and check version directories of model directory
+func scanModel(report *RepositoryReport, modelPath string) *RepositoryModel {
+	model := &RepositoryModel{Name: filepath.Base(modelPath), Path: modelPath}
+	entries, readErr := os.ReadDir(modelPath)
+	if readErr != nil {
+		report.addProblem(model.Name, modelPath, "read model directory error: "+readErr.Error())
+		return model
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		version, parseErr := strconv.ParseInt(entry.Name(), 10, 64)
+		if parseErr != nil || version <= 0 {
+			report.addProblem(model.Name, filepath.Join(modelPath, entry.Name()),
+				"version directory name must be a positive integer, directory is ignored by triton")
+			continue
+		}
+		model.Versions = append(model.Versions, version)
+	}
+	sort.Slice(model.Versions, func(i, j int) bool { return model.Versions[i] < model.Versions[j] })
+
+	configPath := filepath.Join(modelPath, ModelConfigFileName)
+	if _, statErr := os.Stat(configPath); errors.Is(statErr, os.ErrNotExist) {
+		if filename := autoCompleteModelFile(model); filename != "" {
+			report.addWarning(model.Name, configPath,
+				ModelConfigFileName+" not found, triton auto-completes config from model file "+filename)
+		} else {
+			report.addProblem(model.Name, configPath, ModelConfigFileName+
+				" not found and no model file which triton can auto-complete config from ("+
+				strings.Join(autoCompleteModelFilenames, ", ")+")")
+		}
+		checkVersionPolicy(report, model)
+		return model
+	}
+	config, configErr := ReadModelConfigFile(configPath)
+	if configErr != nil {
+		report.addProblem(model.Name, configPath, configErr.Error())
+		return model
+	}
+	model.Config = config
+	if config.Name != "" && config.Name != model.Name {
+		report.addProblem(model.Name, configPath, "config name "+config.Name+" does not match model directory name")
+	}
+	checkConfig(report, model, configPath)
+	checkVersionPolicy(report, model)
+	checkModelFiles(report, model)
+	return model
+}
+
+// autoCompleteModelFile model file of served version which triton can auto-complete config from, empty if not found
+func autoCompleteModelFile(model *RepositoryModel) string {
+	for _, version := range servedVersions(model) {
+		for _, filename := range autoCompleteModelFilenames {
+			if _, statErr := os.Stat(filepath.Join(model.Path, strconv.FormatInt(version, 10), filename)); statErr == nil {
+				return filename
+			}
+		}
+	}
+	return ""
+}
+
+// checkConfig validate config as triton loads it from repository: empty name is the model directory name,
+// platform / backend can be auto-completed from model file
+func checkConfig(report *RepositoryReport, model *RepositoryModel, configPath string) {
+	var problems []string
+	for _, problem := range modelConfigProblems(model.Config) {
+		switch problem {
+		case modelNameEmptyProblem:
+			continue
+		case modelPlatformMissingProblem:
+			if filename := autoCompleteModelFile(model); filename != "" {
+				report.addWarning(model.Name, configPath,
+					"platform / backend is not specified, triton auto-completes it from model file "+filename)
+				continue
+			}
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) > 0 {
+		report.addProblem(model.Name, configPath,
+			fmt.Errorf("%w: %s", ErrInvalidModelConfig, strings.Join(problems, "; ")).Error())
+	}
+}
+
+// checkVersionPolicy check version directories satisfy version policy, default policy is latest 1 version
+func checkVersionPolicy(report *RepositoryReport, model *RepositoryModel) {
+	if len(model.Versions) == 0 {
+		report.addProblem(model.Name, model.Path, "no version directory found")
+		return
+	}
+	if specific := model.Config.GetVersionPolicy().GetSpecific(); specific != nil {
+		if len(specific.Versions) == 0 {
+			report.addProblem(model.Name, model.Path, "specific version policy has no version")
+		}
+		for _, version := range specific.Versions {
+			if !model.hasVersion(version) {
+				report.addProblem(model.Name, model.Path,
+					"version "+strconv.FormatInt(version, 10)+" of specific version policy has no version directory")
+			}
+		}
+	}
+	if latest := model.Config.GetVersionPolicy().GetLatest(); latest != nil && latest.NumVersions == 0 {
+		report.addProblem(model.Name, model.Path, "latest version policy num_versions must be positive")
+	}
+}
+
+// servedVersions versions loaded by triton according to version policy
+func servedVersions(model *RepositoryModel) []int64 {
+	policy := model.Config.GetVersionPolicy()
+	switch {
+	case policy.GetAll() != nil:
+		return model.Versions
+	case policy.GetSpecific() != nil:
+		var versions []int64
+		for _, version := range policy.GetSpecific().Versions {
+			if model.hasVersion(version) {
+				versions = append(versions, version)
+			}
+		}
+		return versions
+	}
+	numVersions := 1
+	if latest := policy.GetLatest(); latest != nil && latest.NumVersions > 0 {
+		numVersions = int(latest.NumVersions)
+	}
+	if numVersions > len(model.Versions) {
+		numVersions = len(model.Versions)
+	}
+	return model.Versions[len(model.Versions)-numVersions:]
+}
+
+// checkModelFiles check model file of platform / backend exists in every served version directory
+func checkModelFiles(report *RepositoryReport, model *RepositoryModel) {
+	config := model.Config
+	filename := config.GetDefaultModelFilename()
+	if filename == "" {
+		if filename = defaultModelFilenames[config.GetPlatform()]; filename == "" {
+			filename = defaultModelFilenames[config.GetBackend()]
+		}
+	}
+	// ensemble and custom backends have no well-known model file
+	if filename == "" || config.Platform == EnsemblePlatform {
+		return
+	}
+	for _, version := range servedVersions(model) {
+		filePath := filepath.Join(model.Path, strconv.FormatInt(version, 10), filename)
+		if _, statErr := os.Stat(filePath); statErr != nil {
+			report.addProblem(model.Name, filePath, "model file "+filename+" not found")
+		}
+	}
+}
+
+// checkEnsembleReferences check ensemble steps reference existing models, versions and tensors
+func checkEnsembleReferences(report *RepositoryReport, model *RepositoryModel) {
+	ensemble := model.Config.GetEnsembleScheduling()
+	if ensemble == nil {
+		return
+	}
+	configPath := filepath.Join(model.Path, ModelConfigFileName)
+	for i, step := range ensemble.Step {
+		stepName := "ensemble step " + strconv.Itoa(i) + " (" + step.ModelName + ")"
+		stepModel, ok := report.Model(step.ModelName)
+		if !ok {
+			report.addProblem(model.Name, configPath, stepName+" references model not in repository")
+			continue
+		}
+		if step.ModelVersion > 0 && !stepModel.hasVersion(step.ModelVersion) {
+			report.addProblem(model.Name, configPath,
+				stepName+" references version "+strconv.FormatInt(step.ModelVersion, 10)+" which does not exist")
+		}
+		if stepModel.Config == nil {
+			continue
+		}
+		inputNames := make(map[string]bool, len(stepModel.Config.Input))
+		for _, input := range stepModel.Config.Input {
+			inputNames[input.Name] = true
+		}
+		outputNames := make(map[string]bool, len(stepModel.Config.Output))
+		for _, output := range stepModel.Config.Output {
+			outputNames[output.Name] = true
+		}
+		for _, tensorName := range sortedMapKeys(step.InputMap) {
+			if !inputNames[tensorName] {
+				report.addProblem(model.Name, configPath, stepName+" input "+tensorName+" is not an input of the model")
+			}
+		}
+		for _, tensorName := range sortedMapKeys(step.OutputMap) {
+			if !outputNames[tensorName] {
+				report.addProblem(model.Name, configPath, stepName+" output "+tensorName+" is not an output of the model")
+			}
+		}
+	}
+}
+
+// sortedMapKeys keys of map in ascending order
+func sortedMapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultRetryMaxAttempts       = 3
+	DefaultRetryInitialBackoff    = 100 * time.Millisecond
+	DefaultRetryMaxBackoff        = 2 * time.Second
+	DefaultRetryBackoffMultiplier = 2.0
+	DefaultRetryJitter            = 0.2
+)
+
+// retryCallKind kind of call, decide whether a call can be retried
+type retryCallKind int
+
+const (
+	// retryCallIdempotent health / metadata / config / stats / status calls
+	retryCallIdempotent retryCallKind = iota
+	// retryCallInference infer calls, only retried if RetryPolicy.RetryInference is true
+	retryCallInference
+	// retryCallNever load / unload / register / unregister calls, never retried
+	retryCallNever
+)
+
+// grpcNonIdempotentMethods grpc methods which change server state
+var grpcNonIdempotentMethods = map[string]struct{}{
+	"/inference.GRPCInferenceService/RepositoryModelLoad":          {},
+	"/inference.GRPCInferenceService/RepositoryModelUnload":        {},
+	"/inference.GRPCInferenceService/SystemSharedMemoryRegister":   {},
+	"/inference.GRPCInferenceService/SystemSharedMemoryUnregister": {},
+	"/inference.GRPCInferenceService/CudaSharedMemoryRegister":     {},
+	"/inference.GRPCInferenceService/CudaSharedMemoryUnregister":   {},
+}
+
+// HTTPRetryableFunc classify http result is retryable or not
+type HTTPRetryableFunc func(statusCode int, httpErr error) bool
+
+// GRPCRetryableFunc classify grpc error is retryable or not
+type GRPCRetryableFunc func(grpcErr error) bool
+
+// RetryPolicy retry policy with exponential backoff and jitter.
+// Health / metadata calls are retried, infer calls are retried only if RetryInference is true,
+// load / unload / shared memory register calls are never retried.
+type RetryPolicy struct {
+	// MaxAttempts max attempts of a call, including the first attempt
+	MaxAttempts int
+	// InitialBackoff backoff before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff max backoff between two attempts
+	MaxBackoff time.Duration
+	// BackoffMultiplier backoff growth factor of every retry
+	BackoffMultiplier float64
+	// Jitter random factor in [0, 1], backoff is randomized in [backoff * (1 - Jitter), backoff]
+	Jitter float64
+	// Budget overall deadline of all attempts, 0 means no limit except the deadline of ctx
+	Budget time.Duration
+	// RetryInference retry infer calls, make sure the model is stateless before enable it
+	RetryInference bool
+	// HTTPRetryable classify http result, default is DefaultHTTPRetryable
+	HTTPRetryable HTTPRetryableFunc
+	// GRPCRetryable classify grpc error, default is DefaultGRPCRetryable
+	GRPCRetryable GRPCRetryableFunc
+}
+
+// DefaultHTTPRetryable retry on connection error, 429, 502, 503 and 504
+func DefaultHTTPRetryable(statusCode int, httpErr error) bool {
+	if httpErr != nil {
+		return !errors.Is(httpErr, context.Canceled) && !errors.Is(httpErr, context.DeadlineExceeded)
+	}
+	switch statusCode {
+	case fasthttp.StatusTooManyRequests, fasthttp.StatusBadGateway,
+		fasthttp.StatusServiceUnavailable, fasthttp.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// DefaultGRPCRetryable retry on UNAVAILABLE
+func DefaultGRPCRetryable(grpcErr error) bool {
+	return status.Code(grpcErr) == codes.Unavailable
+}
+
+// DefaultRetryPolicy retry policy with default value, inference is not retried
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:       DefaultRetryMaxAttempts,
+		InitialBackoff:    DefaultRetryInitialBackoff,
+		MaxBackoff:        DefaultRetryMaxBackoff,
+		BackoffMultiplier: DefaultRetryBackoffMultiplier,
+		Jitter:            DefaultRetryJitter,
+	}
+}
+
+// WithRetryPolicy is an option to retry transient failures with policy, policy can be created by DefaultRetryPolicy.
+func WithRetryPolicy(policy *RetryPolicy) TritonClientOption {
+	return func(t *TritonClientService) {
+		t.retryPolicy = policy
+	}
+}
+
+// backoff backoff duration before retry, retry start from 1
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	backoff := float64(p.InitialBackoff)
+	multiplier := p.BackoffMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || backoff < float64(p.MaxBackoff)); i++ {
+		backoff *= multiplier
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * p.Jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// isRetryableHTTP classify http result with policy
+func (p *RetryPolicy) isRetryableHTTP(statusCode int, httpErr error) bool {
+	if p == nil {
+		return false
+	}
+	if p.HTTPRetryable != nil {
+		return p.HTTPRetryable(statusCode, httpErr)
+	}
+	return DefaultHTTPRetryable(statusCode, httpErr)
+}
+
+// isRetryableGRPC classify grpc error with policy
+func (p *RetryPolicy) isRetryableGRPC(grpcErr error) bool {
+	if p == nil || grpcErr == nil {
+		return false
+	}
+	if p.GRPCRetryable != nil {
+		return p.GRPCRetryable(grpcErr)
+	}
+	return DefaultGRPCRetryable(grpcErr)
+}
+
+// httpRetryCallKind classify http call by uri
+func httpRetryCallKind(uri string) retryCallKind {
+	switch {
+	case strings.HasSuffix(uri, "/infer"):
+		return retryCallInference
+	case strings.HasSuffix(uri, "/load"), strings.HasSuffix(uri, "/unload"),
+		strings.HasSuffix(uri, "/register"), strings.HasSuffix(uri, "/unregister"):
+		return retryCallNever
+	}
+	return retryCallIdempotent
+}
+
+// grpcRetryCallKind classify grpc call by full method name
+func grpcRetryCallKind(method string) retryCallKind {
+	if method == "/inference.GRPCInferenceService/ModelInfer" {
+		return retryCallInference
+	}
+	if _, ok := grpcNonIdempotentMethods[method]; ok {
+		return retryCallNever
+	}
+	return retryCallIdempotent
+}
+
+// doWithRetry call attemptFunc until it is not retryable, attempts exhausted or budget / ctx is done.
+// attemptFunc return true if the attempt failed with retryable error.
+func (t *TritonClientService) doWithRetry(ctx context.Context, kind retryCallKind, attemptFunc func(ctx context.Context) bool) {
+	policy := t.retryPolicy
+	if policy == nil || policy.MaxAttempts <= 1 || kind == retryCallNever ||
+		(kind == retryCallInference && !policy.RetryInference) {
+		attemptFunc(ctx)
+		return
+	}
+	if policy.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Budget)
+		defer cancel()
+	}
+	for attempt := 1; ; attempt++ {
+		if !attemptFunc(ctx) || attempt >= policy.MaxAttempts {
+			return
+		}
+		backoffTimer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			backoffTimer.Stop()
+			return
+		case <-backoffTimer.C:
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	SequenceIDParamKey    string = "sequence_id"
+	SequenceStartParamKey string = "sequence_start"
+	SequenceEndParamKey   string = "sequence_end"
+)
+
+var (
+	ErrSequenceBatchingNotConfigured = errors.New("model is not configured for sequence batching")
+	ErrSequenceEnded                 = errors.New("sequence is ended")
+	ErrSequenceBroken                = errors.New("sequence is broken by a failed request which may be accepted by server")
+)
+
+// sequenceIDCounter correlation id allocator, seeded by current time (shifted to stay in the json safe integer range)
+// so that sequences of different client processes are unlikely to collide.
+var sequenceIDCounter = uint64(time.Now().UnixNano()) >> 12
+
+// NextSequenceID allocate a new non-zero correlation id for sequence
+func NextSequenceID() uint64 {
+	return atomic.AddUint64(&sequenceIDCounter, 1)
+}
+
+// SequenceSession infer session of stateful model with sequence batching.
+// Every request of the session carries sequence_id, the first request carries sequence_start
+// and the request sent after End is called carries sequence_end.
+// Requests of a session must be sent one by one, the session can be used with HTTP, GRPC and GRPC stream.
+// If a request fails after it is sent (timeout, transport or server error, including error of stream request
+// reported later on the stream), server state of the sequence is unknown,
+// the session is broken and the following requests return ErrSequenceBroken, start a new session to retry.
+type SequenceSession struct {
+	client       *TritonClientService
+	modelName    string
+	modelVersion string
+	sequenceID   interface{}
+
+	lock    sync.Mutex
+	started bool
+	endNext bool
+	ended   bool
+	broken  bool
+	// trackedStream / trackedRequestID the last request sent by Send, whose errors are tracked on the stream
+	trackedStream    *ModelStreamInferSession
+	trackedRequestID string
+	streamRequests   uint64
+}
+
+// sequenceIDToInferParameter convert uint64 / int64 / int / string correlation id to InferParameter
+func sequenceIDToInferParameter(sequenceID interface{}) (*InferParameter, error) {
+	switch v := sequenceID.(type) {
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, errors.New("sequence id " + strconv.FormatUint(v, 10) + " exceeds max int64")
+		}
+		return &InferParameter{ParameterChoice: &InferParameter_Int64Param{Int64Param: int64(v)}}, nil
+	case int64:
+		return &InferParameter{ParameterChoice: &InferParameter_Int64Param{Int64Param: v}}, nil
+	case int:
+		return &InferParameter{ParameterChoice: &InferParameter_Int64Param{Int64Param: int64(v)}}, nil
+	case string:
+		if v != "" {
+			return &InferParameter{ParameterChoice: &InferParameter_StringParam{StringParam: v}}, nil
+		}
+	}
+	return nil, errors.New("sequence id must be non-empty string or integer")
+}
+
+// ID correlation id of the sequence, uint64 or string
+func (s *SequenceSession) ID() interface{} { return s.sequenceID }
+
+// ModelName model name of the sequence
+func (s *SequenceSession) ModelName() string { return s.modelName }
+
+// End mark the next request as the last request of the sequence (sequence_end)
+func (s *SequenceSession) End() *SequenceSession {
+	s.lock.Lock()
+	s.endNext = true
+	s.lock.Unlock()
+	return s
+}
+
+// IsEnded check the last request of the sequence is sent
+func (s *SequenceSession) IsEnded() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.ended
+}
+
+// IsBroken check a request of the sequence failed after it is sent
+func (s *SequenceSession) IsBroken() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.broken
+}
+
+// nextParameters build sequence parameters of next request and advance the sequence state
+func (s *SequenceSession) nextParameters(requestParams map[string]*InferParameter) (map[string]*InferParameter, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.broken {
+		return nil, ErrSequenceBroken
+	}
+	if s.ended {
+		return nil, ErrSequenceEnded
+	}
+	sequenceIDParam, _ := sequenceIDToInferParameter(s.sequenceID)
+	params := make(map[string]*InferParameter, len(requestParams)+3)
+	for k, v := range requestParams {
+		params[k] = v
+	}
+	params[SequenceIDParamKey] = sequenceIDParam
+	if !s.started {
+		params[SequenceStartParamKey] = &InferParameter{ParameterChoice: &InferParameter_BoolParam{BoolParam: true}}
+		s.started = true
+	}
+	if s.endNext {
+		params[SequenceEndParamKey] = &InferParameter{ParameterChoice: &InferParameter_BoolParam{BoolParam: true}}
+		s.ended = true
+	}
+	return params, nil
+}
+
+// rollback restore the sequence state when the request is not sent, so the start / end flag is sent again
+func (s *SequenceSession) rollback(params map[string]*InferParameter) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := params[SequenceStartParamKey]; ok {
+		s.started = false
+	}
+	if _, ok := params[SequenceEndParamKey]; ok {
+		s.ended = false
+	}
+}
+
+// trackResponse wrap decoderFunc to record that response of request is received
+func trackResponse(decoderFunc DecoderFunc, isResponded *bool) DecoderFunc {
+	return func(response interface{}, params ...interface{}) ([]interface{}, error) {
+		*isResponded = true
+		return decoderFunc(response, params...)
+	}
+}
+
+// settle keep the sequence state if response of sent request is received (decoder error does not change server state),
+// otherwise the request may be accepted by server and the session is broken
+func (s *SequenceSession) settle(isResponded bool, inferErr error) {
+	if inferErr == nil || isResponded {
+		return
+	}
+	s.markBroken(inferErr)
+}
+
+// markBroken mark the session broken if err is not nil
+func (s *SequenceSession) markBroken(err error) {
+	if err == nil {
+		return
+	}
+	s.lock.Lock()
+	s.broken = true
+	s.lock.Unlock()
+}
+
+// trackStreamCallback wrap stream callback to mark the session broken when server reports an error of the request
+func (s *SequenceSession) trackStreamCallback(callback StreamInferCallback) StreamInferCallback {
+	return func(response *ModelInferResponse, err error) {
+		s.markBroken(err)
+		callback(response, err)
+	}
+}
+
+// trackStreamRequest track errors of request sent by Send, request id is generated if it is empty.
+// Requests of a session are sent one by one, so tracker of the former request is removed.
+func (s *SequenceSession) trackStreamRequest(stream *ModelStreamInferSession, request *ModelInferRequest) {
+	s.lock.Lock()
+	trackedStream, trackedRequestID := s.trackedStream, s.trackedRequestID
+	s.streamRequests++
+	if request.Id == "" {
+		request.Id = fmt.Sprintf("sequence-%v-%d", s.sequenceID, s.streamRequests)
+	}
+	s.trackedStream, s.trackedRequestID = stream, request.Id
+	s.lock.Unlock()
+
+	if trackedStream != nil {
+		trackedStream.untrackRequest(trackedRequestID)
+	}
+	stream.trackRequest(request.Id, s.markBroken)
+}
+
+// newRequest create infer request of the sequence
+func (s *SequenceSession) newRequest(
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+) (*ModelInferRequest, error) {
+	params, paramsErr := s.nextParameters(nil)
+	if paramsErr != nil {
+		return nil, paramsErr
+	}
+	return &ModelInferRequest{
+		ModelName:        s.modelName,
+		ModelVersion:     s.modelVersion,
+		Parameters:       params,
+		Inputs:           inferInputs,
+		Outputs:          inferOutputs,
+		RawInputContents: rawInputs,
+	}, nil
+}
+
+// ModelGRPCInferCtx Call Triton Infer with GRPC and context as the next request of the sequence
+func (s *SequenceSession) ModelGRPCInferCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	request, requestErr := s.newRequest(inferInputs, inferOutputs, rawInputs)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	var isResponded bool
+	response, inferErr := s.client.modelGRPCInfer(ctx, request, trackResponse(decoderFunc, &isResponded), params...)
+	s.settle(isResponded, inferErr)
+	return response, inferErr
+}
+
+// ModelHTTPBinaryInferCtx Call Triton Infer with HTTP binary tensor data extension and context
+// as the next request of the sequence
+func (s *SequenceSession) ModelHTTPBinaryInferCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	request, requestErr := s.newRequest(inferInputs, inferOutputs, rawInputs)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	requestBody, inferHeaderLength, encodeErr := EncodeHTTPBinaryInferRequest(request)
+	if encodeErr != nil {
+		s.rollback(request.Parameters)
+		return nil, encodeErr
+	}
+	var isResponded bool
+	response, inferErr := s.client.sendHTTPBinaryInferRequest(
+		ctx, request, requestBody, inferHeaderLength, trackResponse(decoderFunc, &isResponded), params...)
+	s.settle(isResponded, inferErr)
+	return response, inferErr
+}
+
+// ModelHTTPInferCtx Call Triton Infer with HTTP and context as the next request of the sequence,
+// sequence parameters are merged into the parameters of requestBody.
+func (s *SequenceSession) ModelHTTPInferCtx(
+	ctx context.Context,
+	requestBody []byte,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	sequenceParams, paramsErr := s.nextParameters(nil)
+	if paramsErr != nil {
+		return nil, paramsErr
+	}
+	sequenceRequestBody, mergeErr := mergeHTTPInferRequestParameters(requestBody, "", sequenceParams)
+	if mergeErr != nil {
+		s.rollback(sequenceParams)
+		return nil, mergeErr
+	}
+	var isResponded bool
+	response, inferErr := s.client.ModelHTTPInferCtx(
+		ctx, sequenceRequestBody, s.modelName, s.modelVersion, trackResponse(decoderFunc, &isResponded), params...)
+	s.settle(isResponded, inferErr)
+	return response, inferErr
+}
+
+// prepareStreamRequest set model and sequence parameters of stream request
+func (s *SequenceSession) prepareStreamRequest(request *ModelInferRequest) error {
+	params, paramsErr := s.nextParameters(request.Parameters)
+	if paramsErr != nil {
+		return paramsErr
+	}
+	if request.ModelName == "" {
+		request.ModelName, request.ModelVersion = s.modelName, s.modelVersion
+	}
+	request.Parameters = params
+	return nil
+}
+
+// Send send request on GRPC stream as the next request of the sequence,
+// model name / version is filled if request.ModelName is empty and request id is generated if it is empty.
+// Responses are delivered as ModelStreamInferSession.Send, error of the request reported by server breaks the session.
+func (s *SequenceSession) Send(stream *ModelStreamInferSession, request *ModelInferRequest) error {
+	if prepareErr := s.prepareStreamRequest(request); prepareErr != nil {
+		return prepareErr
+	}
+	s.trackStreamRequest(stream, request)
+	if sendErr := stream.Send(request); sendErr != nil {
+		stream.untrackRequest(request.Id)
+		s.rollback(request.Parameters)
+		return sendErr
+	}
+	return nil
+}
+
+// SendWithCallback send request on GRPC stream as the next request of the sequence and deliver the responses
+// of this request to callback, see ModelStreamInferSession.SendWithCallback.
+// Error of the request reported by server breaks the session.
+func (s *SequenceSession) SendWithCallback(
+	stream *ModelStreamInferSession, request *ModelInferRequest, callback StreamInferCallback,
+) error {
+	if prepareErr := s.prepareStreamRequest(request); prepareErr != nil {
+		return prepareErr
+	}
+	if sendErr := stream.SendWithCallback(request, s.trackStreamCallback(callback)); sendErr != nil {
+		s.rollback(request.Parameters)
+		return sendErr
+	}
+	return nil
+}
+
+// NewSequenceSession create sequence session of model, see NewSequenceSessionCtx
+func (t *TritonClientService) NewSequenceSession(
+	modelName, modelVersion string, sequenceID interface{}, timeout time.Duration,
+) (*SequenceSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.NewSequenceSessionCtx(ctx, modelName, modelVersion, sequenceID)
+}
+
+// NewSequenceSessionCtx create sequence session of model with context.
+// sequenceID is the correlation id (uint64 / int64 / int / string), a new id is allocated if it is nil.
+// Model config is fetched to make sure the model is configured with sequence_batching,
+// otherwise ErrSequenceBatchingNotConfigured is returned.
+func (t *TritonClientService) NewSequenceSessionCtx(
+	ctx context.Context, modelName, modelVersion string, sequenceID interface{},
+) (*SequenceSession, error) {
+	if sequenceID == nil {
+		sequenceID = NextSequenceID()
+	}
+	if _, idErr := sequenceIDToInferParameter(sequenceID); idErr != nil {
+		return nil, idErr
+	}
+	modelConfig, configErr := t.ModelConfigurationCtx(ctx, modelName, modelVersion)
+	if configErr != nil {
+		return nil, configErr
+	}
+	if modelConfig.GetConfig().GetSequenceBatching() == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSequenceBatchingNotConfigured, modelName)
+	}
+	return &SequenceSession{
+		client:       t,
+		modelName:    modelName,
+		modelVersion: modelVersion,
+		sequenceID:   sequenceID,
+	}, nil
+}
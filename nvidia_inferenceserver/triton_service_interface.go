@@ -1,6 +1,7 @@
 package nvidia_inferenceserver
 
 import (
+	"crypto/tls"
 	"errors"
 	"strconv"
 	"time"
@@ -9,10 +10,13 @@ import (
 	"github.com/valyala/fasthttp"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 const (
 	HTTPPrefix                           string = "http://"
+	HTTPSPrefix                          string = "https://"
 	HttpPostMethod                       string = "POST"
 	HttpGetMethod                        string = "GET"
 	JsonContentType                      string = "application/json"
@@ -23,10 +27,13 @@ const (
 	TritonAPIForRepoIndex                       = TritonAPIPrefix + "/repository/index"
 	TritonAPIForRepoModelPrefix                 = TritonAPIPrefix + "/repository/models/"
 	TritonAPIForModelPrefix                     = TritonAPIPrefix + "/models/"
-	TritonAPIForCudaMemoryRegionPrefix          = TritonAPIPrefix + "/cudasharememory/region/"
-	TritonAPIForSystemMemoryRegionPrefix        = TritonAPIPrefix + "/systemsharememory/region/"
+	TritonAPIForCudaMemoryRegionPrefix          = TritonAPIPrefix + "/cudasharedmemory/region/"
+	TritonAPIForSystemMemoryRegionPrefix        = TritonAPIPrefix + "/systemsharedmemory/region/"
 )
 
+// httpNoDeadlineTimeout deadline of http request whose context has no deadline
+const httpNoDeadlineTimeout time.Duration = 365 * 24 * time.Hour
+
 // DecoderFunc Infer Callback Function
 type DecoderFunc func(response interface{}, params ...interface{}) ([]interface{}, error)
 
@@ -56,6 +63,16 @@ type TritonGRPCService interface {
 		modelName, modelVersion string,
 		timeout time.Duration,
 		decoderFunc DecoderFunc, params ...interface{}) ([]interface{}, error)
+	// ModelHTTPBinaryInfer Call triton inference server infer with HTTP binary tensor data extension
+	ModelHTTPBinaryInfer(
+		inferInputs []*ModelInferRequest_InferInputTensor,
+		inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+		rawInputs [][]byte,
+		modelName, modelVersion string,
+		timeout time.Duration,
+		decoderFunc DecoderFunc,
+		params ...interface{},
+	) ([]interface{}, error)
 	// ModelMetadataRequest Get triton inference server`s model metadata.
 	ModelMetadataRequest(modelName, modelVersion string, timeout time.Duration) (*ModelMetadataResponse, error)
 	// ModelIndex Get triton inference server model index.
@@ -87,6 +104,71 @@ type TritonGRPCService interface {
 	// SetModelTracingSetting set the current trace setting
 	SetModelTracingSetting(modelName string, settingMap map[string]*TraceSettingRequest_SettingValue, timeout time.Duration) (*TraceSettingResponse, error)
 
+	// CheckServerAliveCtx Check triton inference server is alive with context.
+	CheckServerAliveCtx(ctx context.Context) (bool, error)
+	// CheckServerReadyCtx Check triton inference server is ready with context.
+	CheckServerReadyCtx(ctx context.Context) (bool, error)
+	// CheckModelReadyCtx Check triton inference server`s model is ready with context.
+	CheckModelReadyCtx(ctx context.Context, modelName, modelVersion string) (bool, error)
+	// ServerMetadataCtx Get triton inference server metadata with context.
+	ServerMetadataCtx(ctx context.Context) (*ServerMetadataResponse, error)
+	// ModelGRPCInferCtx Call triton inference server infer with GRPC and context
+	ModelGRPCInferCtx(
+		ctx context.Context,
+		inferInputs []*ModelInferRequest_InferInputTensor,
+		inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+		rawInputs [][]byte,
+		modelName, modelVersion string,
+		decoderFunc DecoderFunc,
+		params ...interface{},
+	) ([]interface{}, error)
+	// ModelHTTPInferCtx Call triton inference server infer with HTTP and context
+	ModelHTTPInferCtx(
+		ctx context.Context,
+		requestBody []byte,
+		modelName, modelVersion string,
+		decoderFunc DecoderFunc, params ...interface{}) ([]interface{}, error)
+	// ModelHTTPBinaryInferCtx Call triton inference server infer with HTTP binary tensor data extension and context
+	ModelHTTPBinaryInferCtx(
+		ctx context.Context,
+		inferInputs []*ModelInferRequest_InferInputTensor,
+		inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+		rawInputs [][]byte,
+		modelName, modelVersion string,
+		decoderFunc DecoderFunc,
+		params ...interface{},
+	) ([]interface{}, error)
+	// ModelMetadataRequestCtx Get triton inference server`s model metadata with context.
+	ModelMetadataRequestCtx(ctx context.Context, modelName, modelVersion string) (*ModelMetadataResponse, error)
+	// ModelIndexCtx Get triton inference server model index with context.
+	ModelIndexCtx(ctx context.Context, repoName string, isReady bool) (*RepositoryIndexResponse, error)
+	// ModelConfigurationCtx Get triton inference server model configuration with context.
+	ModelConfigurationCtx(ctx context.Context, modelName, modelVersion string) (*ModelConfigResponse, error)
+	// ModelInferStatsCtx Get triton inference server model infer stats with context.
+	ModelInferStatsCtx(ctx context.Context, modelName, modelVersion string) (*ModelStatisticsResponse, error)
+	// ModelLoadWithHTTPCtx Load model with http and context
+	ModelLoadWithHTTPCtx(ctx context.Context, modelName string, modelConfigBody []byte) (*RepositoryModelLoadResponse, error)
+	// ModelLoadWithGRPCCtx Load model with grpc and context
+	ModelLoadWithGRPCCtx(ctx context.Context, repoName, modelName string, modelConfigBody map[string]*ModelRepositoryParameter) (*RepositoryModelLoadResponse, error)
+	// ModelUnloadWithHTTPCtx Unload model with http and context
+	ModelUnloadWithHTTPCtx(ctx context.Context, modelName string, modelConfigBody []byte) (*RepositoryModelUnloadResponse, error)
+	// ModelUnloadWithGRPCCtx Unload model with grpc and context
+	ModelUnloadWithGRPCCtx(ctx context.Context, repoName, modelName string, modelConfigBody map[string]*ModelRepositoryParameter) (*RepositoryModelUnloadResponse, error)
+	// ShareMemoryStatusCtx Show share memory / share cuda memory status with context.
+	ShareMemoryStatusCtx(ctx context.Context, isCUDA bool, regionName string) (interface{}, error)
+	// ShareCUDAMemoryRegisterCtx Register share cuda memory with context.
+	ShareCUDAMemoryRegisterCtx(ctx context.Context, regionName string, cudaRawHandle []byte, cudaDeviceId int64, byteSize uint64) (*CudaSharedMemoryRegisterResponse, error)
+	// ShareCUDAMemoryUnRegisterCtx Unregister share cuda memory with context.
+	ShareCUDAMemoryUnRegisterCtx(ctx context.Context, regionName string) (*CudaSharedMemoryUnregisterResponse, error)
+	// ShareSystemMemoryRegisterCtx Register system share memory with context.
+	ShareSystemMemoryRegisterCtx(ctx context.Context, regionName, cpuMemRegionKey string, byteSize, cpuMemOffset uint64) (*SystemSharedMemoryRegisterResponse, error)
+	// ShareSystemMemoryUnRegisterCtx Unregister system share memory with context.
+	ShareSystemMemoryUnRegisterCtx(ctx context.Context, regionName string) (*SystemSharedMemoryUnregisterResponse, error)
+	// GetModelTracingSettingCtx get the current trace setting with context.
+	GetModelTracingSettingCtx(ctx context.Context, modelName string) (*TraceSettingResponse, error)
+	// SetModelTracingSettingCtx set the current trace setting with context.
+	SetModelTracingSettingCtx(ctx context.Context, modelName string, settingMap map[string]*TraceSettingRequest_SettingValue) (*TraceSettingResponse, error)
+
 	// ShutdownTritonConnection close client connection
 	ShutdownTritonConnection() (disconnectionErr error)
 }
@@ -95,9 +177,59 @@ type TritonGRPCService interface {
 type TritonClientService struct {
 	ServerURL string
 
-	grpcConn   *grpc.ClientConn
-	grpcClient GRPCInferenceServiceClient
-	httpClient *fasthttp.Client
+	grpcConn      *grpc.ClientConn
+	grpcClient    GRPCInferenceServiceClient
+	httpClient    *fasthttp.Client
+	httpPrefix    string
+	httpTLSConfig *tls.Config
+
+	headerProviders []HeaderProvider
+	retryPolicy     *RetryPolicy
+	inFlightLimiter chan struct{}
+}
+
+// TritonClientOption allows to configure a new TritonClientService with your specific needs.
+type TritonClientOption func(*TritonClientService)
+
+// WithHTTPSecure is an option to use https with tlsConfig for http client.
+// tlsConfig can be created by NewTLSConfig.
+func WithHTTPSecure(tlsConfig *tls.Config) TritonClientOption {
+	return func(t *TritonClientService) {
+		t.httpPrefix = HTTPSPrefix
+		t.httpTLSConfig = tlsConfig
+	}
+}
+
+// applyOptions apply client options after connection created
+func (t *TritonClientService) applyOptions(opts ...TritonClientOption) {
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.httpClient != nil && t.httpTLSConfig != nil {
+		t.httpClient.TLSConfig = t.httpTLSConfig
+	}
+}
+
+// getServerURL get http server url with scheme prefix
+func (t *TritonClientService) getServerURL() string {
+	if t.httpPrefix == "" {
+		return HTTPPrefix + t.ServerURL
+	}
+	return t.httpPrefix + t.ServerURL
+}
+
+// getModelURL get http url of model, version segment is omitted if modelVersion is empty
+func (t *TritonClientService) getModelURL(modelName, modelVersion string) string {
+	if modelVersion == "" {
+		return t.getServerURL() + TritonAPIForModelPrefix + modelName
+	}
+	return t.getServerURL() + TritonAPIForModelPrefix + modelName + TritonAPIForModelVersionPrefix + modelVersion
+}
+
+// setGRPCConnection Create GRPC Client with connection
+func (t *TritonClientService) setGRPCConnection(grpcConn *grpc.ClientConn) {
+	t.grpcConn = grpcConn
+	t.grpcClient = NewGRPCInferenceServiceClient(&tritonGRPCConn{conn: grpcConn, client: t})
 }
 
 // disconnectToTritonWithGRPC Disconnect GRPC Connection
@@ -151,74 +283,184 @@ func (t *TritonClientService) releaseHttpResponse(responseObj *fasthttp.Response
 	fasthttp.ReleaseResponse(responseObj)
 }
 
-// makeHttpPostRequestWithDoTimeout
-func (t *TritonClientService) makeHttpPostRequestWithDoTimeout(uri string, reqBody []byte, timeout time.Duration) ([]byte, int, error) {
-	requestObj := t.acquireHttpRequest(HttpPostMethod)
+// doHttpRequestWithContext make http request with retry policy, abort when ctx is done.
+// reqInferHeaderLength > 0 means request body use binary tensor data extension.
+// Return response body, status code and response Inference-Header-Content-Length.
+func (t *TritonClientService) doHttpRequestWithContext(
+	ctx context.Context, method, uri string, reqBody []byte, reqInferHeaderLength int,
+) (respBody []byte, statusCode, respInferHeaderLength int, httpErr error) {
+	t.doWithRetry(ctx, httpRetryCallKind(uri), func(ctx context.Context) bool {
+		respBody, statusCode, respInferHeaderLength, httpErr = t.doHttpRequestOnce(ctx, method, uri, reqBody, reqInferHeaderLength)
+		return ctx.Err() == nil && t.retryPolicy.isRetryableHTTP(statusCode, httpErr)
+	})
+	return respBody, statusCode, respInferHeaderLength, httpErr
+}
+
+// doHttpRequestOnce make http request once, abort when ctx is done.
+func (t *TritonClientService) doHttpRequestOnce(
+	ctx context.Context, method, uri string, reqBody []byte, reqInferHeaderLength int,
+) ([]byte, int, int, error) {
+	requestObj := t.acquireHttpRequest(method)
 	responseObj := t.acquireHttpResponse()
-	defer func() {
+	release := func() {
 		t.releaseHttpRequest(requestObj)
 		t.releaseHttpResponse(responseObj)
-	}()
+	}
 	requestObj.SetRequestURI(uri)
 	if reqBody != nil {
 		requestObj.SetBody(reqBody)
 	}
-	if httpErr := t.httpClient.DoTimeout(requestObj, responseObj, timeout); httpErr != nil {
-		return nil, responseObj.StatusCode(), httpErr
+	if reqInferHeaderLength > 0 {
+		requestObj.Header.SetContentType(OctetStreamContentType)
+		requestObj.Header.Set(InferHeaderContentLengthKey, strconv.Itoa(reqInferHeaderLength))
+	}
+	headers, headerErr := t.getRequestHeaders(ctx)
+	if headerErr != nil {
+		release()
+		return nil, 0, 0, headerErr
+	}
+	for k, v := range headers {
+		requestObj.Header.Set(k, v)
+	}
+	// read response before release response object
+	readResponse := func(httpErr error) ([]byte, int, int, error) {
+		defer release()
+		if httpErr != nil {
+			// response is not received, status code of response object is meaningless
+			return nil, 0, 0, httpErr
+		}
+		respInferHeaderLength, _ := strconv.Atoi(string(responseObj.Header.Peek(InferHeaderContentLengthKey)))
+		return append([]byte(nil), responseObj.Body()...), responseObj.StatusCode(), respInferHeaderLength, nil
+	}
+	// always use DoDeadline, deadline of DoDeadline stays on the pooled connection and breaks a later plain Do call
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(httpNoDeadlineTimeout)
+	}
+	// request without cancel signal, do it directly
+	if ctx.Done() == nil {
+		return readResponse(t.httpClient.DoDeadline(requestObj, responseObj, deadline))
+	}
+	doneChan := make(chan error, 1)
+	go func() { doneChan <- t.httpClient.DoDeadline(requestObj, responseObj, deadline) }()
+	select {
+	case httpErr := <-doneChan:
+		return readResponse(httpErr)
+	case <-ctx.Done():
+		// request/response objects are still in use, release them after request finished
+		go func() {
+			<-doneChan
+			release()
+		}()
+		return nil, 0, 0, ctx.Err()
 	}
-	return responseObj.Body(), responseObj.StatusCode(), nil
 }
 
-// makeHttpGetRequestWithDoTimeout
-func (t *TritonClientService) makeHttpGetRequestWithDoTimeout(uri string, timeout time.Duration) ([]byte, int, error) {
-	requestObj := t.acquireHttpRequest(HttpGetMethod)
-	responseObj := t.acquireHttpResponse()
-	defer func() {
-		t.releaseHttpRequest(requestObj)
-		t.releaseHttpResponse(responseObj)
-	}()
-	requestObj.SetRequestURI(uri)
-	if httpErr := t.httpClient.DoTimeout(requestObj, responseObj, timeout); httpErr != nil {
-		return nil, responseObj.StatusCode(), httpErr
-	}
-	return responseObj.Body(), responseObj.StatusCode(), nil
+// makeHttpRequestWithContext make http request and return response body, abort when ctx is done
+func (t *TritonClientService) makeHttpRequestWithContext(ctx context.Context, method, uri string, reqBody []byte) ([]byte, int, error) {
+	respBody, statusCode, _, httpErr := t.doHttpRequestWithContext(ctx, method, uri, reqBody, 0)
+	return respBody, statusCode, httpErr
+}
+
+// makeHttpPostRequestWithContext
+func (t *TritonClientService) makeHttpPostRequestWithContext(ctx context.Context, uri string, reqBody []byte) ([]byte, int, error) {
+	return t.makeHttpRequestWithContext(ctx, HttpPostMethod, uri, reqBody)
+}
+
+// makeHttpGetRequestWithContext
+func (t *TritonClientService) makeHttpGetRequestWithContext(ctx context.Context, uri string) ([]byte, int, error) {
+	return t.makeHttpRequestWithContext(ctx, HttpGetMethod, uri, nil)
 }
 
 // modelGRPCInfer Call Triton with GRPC（core function）
 func (t *TritonClientService) modelGRPCInfer(
-	inferInputs []*ModelInferRequest_InferInputTensor,
-	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
-	rawInputs [][]byte,
-	modelName, modelVersion string,
-	timeout time.Duration,
-) (*ModelInferResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	// Create infer request for specific model/version
-	modelInferRequest := ModelInferRequest{
-		ModelName:        modelName,
-		ModelVersion:     modelVersion,
-		Inputs:           inferInputs,
-		Outputs:          inferOutputs,
-		RawInputContents: rawInputs,
-	}
+	ctx context.Context, modelInferRequest *ModelInferRequest, decoderFunc DecoderFunc, params ...interface{},
+) ([]interface{}, error) {
 	// Get infer response
-	modelInferResponse, inferErr := t.grpcClient.ModelInfer(ctx, &modelInferRequest)
+	modelInferResponse, inferErr := t.grpcClient.ModelInfer(ctx, modelInferRequest)
 	if inferErr != nil {
-		return nil, errors.New("inferErr: " + inferErr.Error())
+		return nil, t.grpcErrorHandler(
+			inferErr, "ModelInfer", modelInferRequest.ModelName, modelInferRequest.ModelVersion)
+	}
+	echoInferResponseID(modelInferRequest, modelInferResponse)
+	// decode Result
+	response, decodeErr := decoderFunc(modelInferResponse, params...)
+	if decodeErr != nil {
+		return nil, t.decodeFuncErrorHandler(decodeErr)
+	}
+	return response, nil
+}
+
+// modelHTTPBinaryInfer Call Triton with HTTP binary tensor data extension（core function）
+func (t *TritonClientService) modelHTTPBinaryInfer(
+	ctx context.Context, modelInferRequest *ModelInferRequest, decoderFunc DecoderFunc, params ...interface{},
+) ([]interface{}, error) {
+	requestBody, inferHeaderLength, encodeErr := EncodeHTTPBinaryInferRequest(modelInferRequest)
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	return t.sendHTTPBinaryInferRequest(ctx, modelInferRequest, requestBody, inferHeaderLength, decoderFunc, params...)
+}
+
+// sendHTTPBinaryInferRequest send request body encoded by EncodeHTTPBinaryInferRequest and decode response
+func (t *TritonClientService) sendHTTPBinaryInferRequest(
+	ctx context.Context,
+	modelInferRequest *ModelInferRequest,
+	requestBody []byte,
+	inferHeaderLength int,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	modelName, modelVersion := modelInferRequest.ModelName, modelInferRequest.ModelVersion
+	// get infer response
+	respBody, statusCode, respInferHeaderLength, inferErr := t.doHttpRequestWithContext(
+		ctx, HttpPostMethod,
+		t.getModelURL(modelName, modelVersion)+"/infer",
+		requestBody, inferHeaderLength)
+	if inferErr != nil || statusCode != fasthttp.StatusOK {
+		return nil, t.httpErrorHandler(statusCode, inferErr, respBody, "ModelInfer", modelName, modelVersion)
+	}
+	modelInferResponse, decodeErr := DecodeHTTPBinaryInferResponse(respBody, respInferHeaderLength)
+	if decodeErr != nil {
+		return nil, decodeErr
 	}
-	return modelInferResponse, nil
+	echoInferResponseID(modelInferRequest, modelInferResponse)
+	// decode Result
+	response, decodeErr := decoderFunc(modelInferResponse, params...)
+	if decodeErr != nil {
+		return nil, errors.New("[HTTP]decodeFunc error: " + decodeErr.Error())
+	}
+	return response, nil
 }
 
-// httpErrorHandler HTTP Error Handler
-func (t *TritonClientService) httpErrorHandler(statusCode int, httpErr error) error {
-	return errors.New("[HTTP]code: " + strconv.Itoa(statusCode) + "; error: " + httpErr.Error())
+// httpErrorHandler HTTP Error Handler, build TritonError with triton error message in respBody
+func (t *TritonClientService) httpErrorHandler(
+	statusCode int, httpErr error, respBody []byte, operation, modelName, modelVersion string,
+) error {
+	return &TritonError{
+		Transport:    TransportHTTP,
+		StatusCode:   statusCode,
+		Message:      parseHTTPErrorMessage(respBody),
+		Operation:    operation,
+		ModelName:    modelName,
+		ModelVersion: modelVersion,
+		Err:          httpErr,
+	}
 }
 
-// grpcErrorHandler GRPC Error Handler
-func (t *TritonClientService) grpcErrorHandler(grpcErr error) error {
+// grpcErrorHandler GRPC Error Handler, build TritonError with grpc status
+func (t *TritonClientService) grpcErrorHandler(grpcErr error, operation, modelName, modelVersion string) error {
 	if grpcErr != nil {
-		return errors.New("[GRPC]error: " + grpcErr.Error())
+		grpcStatus, _ := status.FromError(grpcErr)
+		return &TritonError{
+			Transport:    TransportGRPC,
+			GRPCCode:     grpcStatus.Code(),
+			Message:      grpcStatus.Message(),
+			Operation:    operation,
+			ModelName:    modelName,
+			ModelVersion: modelVersion,
+			Err:          grpcErr,
+		}
 	}
 	return nil
 }
@@ -240,14 +482,28 @@ func (t *TritonClientService) ModelHTTPInfer(
 	timeout time.Duration,
 	decoderFunc DecoderFunc,
 	params ...interface{},
+) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ModelHTTPInferCtx(ctx, requestBody, modelName, modelVersion, decoderFunc, params...)
+}
+
+// ModelHTTPInferCtx Call Triton Infer with HTTP and context
+func (t *TritonClientService) ModelHTTPInferCtx(
+	ctx context.Context,
+	requestBody []byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
 ) ([]interface{}, error) {
 	// get infer response
-	modelInferResponse, modelInferStatusCode, inferErr := t.makeHttpPostRequestWithDoTimeout(
-		HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+TritonAPIForModelVersionPrefix+modelVersion+"/infer",
-		requestBody,
-		timeout)
+	modelInferResponse, modelInferStatusCode, inferErr := t.makeHttpPostRequestWithContext(
+		ctx,
+		t.getModelURL(modelName, modelVersion)+"/infer",
+		requestBody)
 	if inferErr != nil || modelInferStatusCode != fasthttp.StatusOK {
-		return nil, t.httpErrorHandler(modelInferStatusCode, inferErr)
+		return nil, t.httpErrorHandler(modelInferStatusCode, inferErr, modelInferResponse, "ModelInfer", modelName, modelVersion)
 	}
 	// decode Result
 	response, decodeErr := decoderFunc(modelInferResponse, params...)
@@ -257,6 +513,43 @@ func (t *TritonClientService) ModelHTTPInfer(
 	return response, nil
 }
 
+// ModelHTTPBinaryInfer Call Triton Infer with HTTP binary tensor data extension
+func (t *TritonClientService) ModelHTTPBinaryInfer(
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	timeout time.Duration,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ModelHTTPBinaryInferCtx(ctx, inferInputs, inferOutputs, rawInputs, modelName, modelVersion, decoderFunc, params...)
+}
+
+// ModelHTTPBinaryInferCtx Call Triton Infer with HTTP binary tensor data extension and context.
+// Request inputs / outputs are the same as ModelGRPCInferCtx, and decoderFunc will receive *ModelInferResponse
+// decoded by DecodeHTTPBinaryInferResponse, so decoderFunc can be shared with GRPC.
+func (t *TritonClientService) ModelHTTPBinaryInferCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	return t.modelHTTPBinaryInfer(ctx, &ModelInferRequest{
+		ModelName:        modelName,
+		ModelVersion:     modelVersion,
+		Inputs:           inferInputs,
+		Outputs:          inferOutputs,
+		RawInputContents: rawInputs,
+	}, decoderFunc, params...)
+}
+
 // ModelGRPCInfer Call Triton Infer with GRPC
 func (t *TritonClientService) ModelGRPCInfer(
 	inferInputs []*ModelInferRequest_InferInputTensor,
@@ -267,35 +560,52 @@ func (t *TritonClientService) ModelGRPCInfer(
 	decoderFunc DecoderFunc,
 	params ...interface{},
 ) ([]interface{}, error) {
-	// Get infer response
-	modelInferResponse, inferErr := t.modelGRPCInfer(inferInputs, inferOutputs, rawInputs, modelName, modelVersion, timeout)
-	if inferErr != nil {
-		return nil, t.grpcErrorHandler(inferErr)
-	}
-	// decode Result
-	response, decodeErr := decoderFunc(modelInferResponse, params...)
-	if decodeErr != nil {
-		return nil, t.decodeFuncErrorHandler(decodeErr)
-	}
-	return response, nil
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ModelGRPCInferCtx(ctx, inferInputs, inferOutputs, rawInputs, modelName, modelVersion, decoderFunc, params...)
+}
+
+// ModelGRPCInferCtx Call Triton Infer with GRPC and context
+func (t *TritonClientService) ModelGRPCInferCtx(
+	ctx context.Context,
+	inferInputs []*ModelInferRequest_InferInputTensor,
+	inferOutputs []*ModelInferRequest_InferRequestedOutputTensor,
+	rawInputs [][]byte,
+	modelName, modelVersion string,
+	decoderFunc DecoderFunc,
+	params ...interface{},
+) ([]interface{}, error) {
+	return t.modelGRPCInfer(ctx, &ModelInferRequest{
+		ModelName:        modelName,
+		ModelVersion:     modelVersion,
+		Inputs:           inferInputs,
+		Outputs:          inferOutputs,
+		RawInputContents: rawInputs,
+	}, decoderFunc, params...)
 }
 
 // CheckServerAlive check server is alive
 func (t *TritonClientService) CheckServerAlive(timeout time.Duration) (bool, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.CheckServerAliveCtx(ctx)
+}
+
+// CheckServerAliveCtx check server is alive with context
+func (t *TritonClientService) CheckServerAliveCtx(ctx context.Context) (bool, error) {
+	if t.grpcClient != nil {
 		// server alive
 		serverLiveResponse, serverAliveErr := t.grpcClient.ServerLive(ctx, &ServerLiveRequest{})
 		if serverAliveErr != nil {
-			return false, t.grpcErrorHandler(serverAliveErr)
+			return false, t.grpcErrorHandler(serverAliveErr, "ServerLive", "", "")
 		}
 		return serverLiveResponse.Live, nil
 	} else {
-		_, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForServerIsLive, nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForServerIsLive, nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return false, t.httpErrorHandler(statusCode, httpErr)
+			return false, t.httpErrorHandler(statusCode, httpErr, respBody, "ServerLive", "", "")
 		}
 		return true, nil
 	}
@@ -303,20 +613,25 @@ func (t *TritonClientService) CheckServerAlive(timeout time.Duration) (bool, err
 
 // CheckServerReady check server is ready
 func (t *TritonClientService) CheckServerReady(timeout time.Duration) (bool, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.CheckServerReadyCtx(ctx)
+}
+
+// CheckServerReadyCtx check server is ready with context
+func (t *TritonClientService) CheckServerReadyCtx(ctx context.Context) (bool, error) {
+	if t.grpcClient != nil {
 		// server ready
 		serverReadyResponse, serverReadyErr := t.grpcClient.ServerReady(ctx, &ServerReadyRequest{})
 		if serverReadyErr != nil {
-			return false, t.grpcErrorHandler(serverReadyErr)
+			return false, t.grpcErrorHandler(serverReadyErr, "ServerReady", "", "")
 		}
 		return serverReadyResponse.Ready, nil
 	} else {
-		_, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForServerIsReady, nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForServerIsReady, nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return false, t.httpErrorHandler(statusCode, httpErr)
+			return false, t.httpErrorHandler(statusCode, httpErr, respBody, "ServerReady", "", "")
 		}
 		return true, nil
 	}
@@ -324,20 +639,25 @@ func (t *TritonClientService) CheckServerReady(timeout time.Duration) (bool, err
 
 // CheckModelReady check model is ready
 func (t *TritonClientService) CheckModelReady(modelName, modelVersion string, timeout time.Duration) (bool, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.CheckModelReadyCtx(ctx, modelName, modelVersion)
+}
+
+// CheckModelReadyCtx check model is ready with context
+func (t *TritonClientService) CheckModelReadyCtx(ctx context.Context, modelName, modelVersion string) (bool, error) {
+	if t.grpcClient != nil {
 		// model ready
 		modelReadyResponse, modelReadyErr := t.grpcClient.ModelReady(ctx, &ModelReadyRequest{Name: modelName, Version: modelVersion})
 		if modelReadyErr != nil {
-			return false, t.grpcErrorHandler(modelReadyErr)
+			return false, t.grpcErrorHandler(modelReadyErr, "ModelReady", modelName, modelVersion)
 		}
 		return modelReadyResponse.Ready, nil
 	} else {
-		_, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+TritonAPIForModelVersionPrefix+modelVersion+"/ready", nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getModelURL(modelName, modelVersion)+"/ready", nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return false, t.httpErrorHandler(statusCode, httpErr)
+			return false, t.httpErrorHandler(statusCode, httpErr, respBody, "ModelReady", modelName, modelVersion)
 		}
 		return true, nil
 	}
@@ -345,17 +665,22 @@ func (t *TritonClientService) CheckModelReady(modelName, modelVersion string, ti
 
 // ServerMetadata Get server metadata
 func (t *TritonClientService) ServerMetadata(timeout time.Duration) (*ServerMetadataResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.ServerMetadataCtx(ctx)
+}
+
+// ServerMetadataCtx Get server metadata with context
+func (t *TritonClientService) ServerMetadataCtx(ctx context.Context) (*ServerMetadataResponse, error) {
+	if t.grpcClient != nil {
 		// server metadata
 		serverMetadataResponse, serverMetaErr := t.grpcClient.ServerMetadata(ctx, &ServerMetadataRequest{})
-		return serverMetadataResponse, t.grpcErrorHandler(serverMetaErr)
+		return serverMetadataResponse, t.grpcErrorHandler(serverMetaErr, "ServerMetadata", "", "")
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIPrefix, nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIPrefix, nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "ServerMetadata", "", "")
 		}
 		serverMetadataResponse := new(ServerMetadataResponse)
 		if jsonDecodeErr := json.Unmarshal(respBody, &serverMetadataResponse); jsonDecodeErr != nil {
@@ -367,17 +692,22 @@ func (t *TritonClientService) ServerMetadata(timeout time.Duration) (*ServerMeta
 
 // ModelMetadataRequest Get model metadata
 func (t *TritonClientService) ModelMetadataRequest(modelName, modelVersion string, timeout time.Duration) (*ModelMetadataResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.ModelMetadataRequestCtx(ctx, modelName, modelVersion)
+}
+
+// ModelMetadataRequestCtx Get model metadata with context
+func (t *TritonClientService) ModelMetadataRequestCtx(ctx context.Context, modelName, modelVersion string) (*ModelMetadataResponse, error) {
+	if t.grpcClient != nil {
 		// model metadata
 		modelMetadataResponse, modelMetaErr := t.grpcClient.ModelMetadata(ctx, &ModelMetadataRequest{Name: modelName, Version: modelVersion})
-		return modelMetadataResponse, t.grpcErrorHandler(modelMetaErr)
+		return modelMetadataResponse, t.grpcErrorHandler(modelMetaErr, "ModelMetadata", modelName, modelVersion)
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+TritonAPIForModelVersionPrefix+modelVersion, nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getModelURL(modelName, modelVersion), nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "ModelMetadata", modelName, modelVersion)
 		}
 		modelMetadataResponse := new(ModelMetadataResponse)
 		if jsonDecodeErr := json.Unmarshal(respBody, &modelMetadataResponse); jsonDecodeErr != nil {
@@ -389,21 +719,26 @@ func (t *TritonClientService) ModelMetadataRequest(modelName, modelVersion strin
 
 // ModelIndex Get model repo index
 func (t *TritonClientService) ModelIndex(repoName string, isReady bool, timeout time.Duration) (*RepositoryIndexResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.ModelIndexCtx(ctx, repoName, isReady)
+}
+
+// ModelIndexCtx Get model repo index with context
+func (t *TritonClientService) ModelIndexCtx(ctx context.Context, repoName string, isReady bool) (*RepositoryIndexResponse, error) {
+	if t.grpcClient != nil {
 		// The name of the repository. If empty the index is returned for all repositories.
 		repositoryIndexResponse, modelIndexErr := t.grpcClient.RepositoryIndex(ctx, &RepositoryIndexRequest{RepositoryName: repoName, Ready: isReady})
-		return repositoryIndexResponse, t.grpcErrorHandler(modelIndexErr)
+		return repositoryIndexResponse, t.grpcErrorHandler(modelIndexErr, "RepositoryIndex", "", "")
 	} else {
 		reqBody, jsonEncodeErr := json.Marshal(&ModelIndexRequestHTTPObj{repoName, isReady})
 		if jsonEncodeErr != nil {
 			return nil, jsonEncodeErr
 		}
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForRepoIndex, reqBody, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForRepoIndex, reqBody)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "RepositoryIndex", "", "")
 		}
 		repositoryIndexResponse := new(RepositoryIndexResponse)
 		if jsonDecodeErr := json.Unmarshal(respBody, &repositoryIndexResponse.Models); jsonDecodeErr != nil {
@@ -415,19 +750,25 @@ func (t *TritonClientService) ModelIndex(repoName string, isReady bool, timeout
 
 // ModelConfiguration Get model configuration
 func (t *TritonClientService) ModelConfiguration(modelName, modelVersion string, timeout time.Duration) (*ModelConfigResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ModelConfigurationCtx(ctx, modelName, modelVersion)
+}
 
+// ModelConfigurationCtx Get model configuration with context
+func (t *TritonClientService) ModelConfigurationCtx(ctx context.Context, modelName, modelVersion string) (*ModelConfigResponse, error) {
+	if t.grpcClient != nil {
 		modelConfigResponse, getModelConfigErr := t.grpcClient.ModelConfig(ctx, &ModelConfigRequest{Name: modelName, Version: modelVersion})
-		return modelConfigResponse, t.grpcErrorHandler(getModelConfigErr)
+		return modelConfigResponse, t.grpcErrorHandler(getModelConfigErr, "ModelConfig", modelName, modelVersion)
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpGetRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+TritonAPIForModelVersionPrefix+modelVersion+"/config", timeout)
+		respBody, statusCode, httpErr := t.makeHttpGetRequestWithContext(ctx, t.getModelURL(modelName, modelVersion)+"/config")
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "ModelConfig", modelName, modelVersion)
 		}
-		modelConfigResponse := new(ModelConfigResponse)
-		if jsonDecodeErr := json.Unmarshal(respBody, &modelConfigResponse); jsonDecodeErr != nil {
+		// triton returns model config json directly, enums are encoded as string, like "TYPE_INT32"
+		modelConfigResponse := &ModelConfigResponse{Config: new(ModelConfig)}
+		if jsonDecodeErr := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(respBody, modelConfigResponse.Config); jsonDecodeErr != nil {
 			return nil, jsonDecodeErr
 		}
 		return modelConfigResponse, nil
@@ -436,16 +777,21 @@ func (t *TritonClientService) ModelConfiguration(modelName, modelVersion string,
 
 // ModelInferStats Get Model infer stats
 func (t *TritonClientService) ModelInferStats(modelName, modelVersion string, timeout time.Duration) (*ModelStatisticsResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.ModelInferStatsCtx(ctx, modelName, modelVersion)
+}
+
+// ModelInferStatsCtx Get Model infer stats with context
+func (t *TritonClientService) ModelInferStatsCtx(ctx context.Context, modelName, modelVersion string) (*ModelStatisticsResponse, error) {
+	if t.grpcClient != nil {
 		modelStatisticsResponse, getInferStatsErr := t.grpcClient.ModelStatistics(ctx, &ModelStatisticsRequest{Name: modelName, Version: modelVersion})
-		return modelStatisticsResponse, t.grpcErrorHandler(getInferStatsErr)
+		return modelStatisticsResponse, t.grpcErrorHandler(getInferStatsErr, "ModelStatistics", modelName, modelVersion)
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpGetRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+TritonAPIForModelVersionPrefix+modelVersion+"/stats", timeout)
+		respBody, statusCode, httpErr := t.makeHttpGetRequestWithContext(ctx, t.getModelURL(modelName, modelVersion)+"/stats")
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "ModelStatistics", modelName, modelVersion)
 		}
 		modelStatisticsResponse := new(ModelStatisticsResponse)
 		jsonDecodeErr := json.Unmarshal(respBody, &modelStatisticsResponse)
@@ -459,11 +805,23 @@ func (t *TritonClientService) ModelInferStats(modelName, modelVersion string, ti
 // ModelLoadWithHTTP Load Model with http
 // modelConfigBody ==> https://github.com/triton-inference-server/server/blob/main/docs/protocol/extension_model_repository.md#examples
 func (t *TritonClientService) ModelLoadWithHTTP(modelName string, modelConfigBody []byte, timeout time.Duration) (*RepositoryModelLoadResponse, error) {
-	loadRespBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForRepoModelPrefix+modelName+"/load", modelConfigBody, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ModelLoadWithHTTPCtx(ctx, modelName, modelConfigBody)
+}
+
+// ModelLoadWithHTTPCtx Load Model with http and context
+func (t *TritonClientService) ModelLoadWithHTTPCtx(ctx context.Context, modelName string, modelConfigBody []byte) (*RepositoryModelLoadResponse, error) {
+	loadRespBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForRepoModelPrefix+modelName+"/load", modelConfigBody)
 	if httpErr != nil || statusCode != fasthttp.StatusOK {
-		return nil, t.httpErrorHandler(statusCode, httpErr)
+		return nil, t.httpErrorHandler(statusCode, httpErr, loadRespBody, "RepositoryModelLoad", modelName, "")
 	}
 	repositoryModelLoadResponse := new(RepositoryModelLoadResponse)
+	// triton returns empty body when model is loaded
+	if len(loadRespBody) == 0 {
+		return repositoryModelLoadResponse, nil
+	}
 	if jsonDecodeErr := json.Unmarshal(loadRespBody, &repositoryModelLoadResponse); jsonDecodeErr != nil {
 		return nil, jsonDecodeErr
 	}
@@ -474,23 +832,42 @@ func (t *TritonClientService) ModelLoadWithHTTP(modelName string, modelConfigBod
 func (t *TritonClientService) ModelLoadWithGRPC(repoName, modelName string, modelConfigBody map[string]*ModelRepositoryParameter, timeout time.Duration) (*RepositoryModelLoadResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+
+	return t.ModelLoadWithGRPCCtx(ctx, repoName, modelName, modelConfigBody)
+}
+
+// ModelLoadWithGRPCCtx Load Model with grpc and context
+func (t *TritonClientService) ModelLoadWithGRPCCtx(ctx context.Context, repoName, modelName string, modelConfigBody map[string]*ModelRepositoryParameter) (*RepositoryModelLoadResponse, error) {
 	// The name of the repository to load from. If empty the model is loaded from any repository.
 	loadResponse, loadErr := t.grpcClient.RepositoryModelLoad(ctx, &RepositoryModelLoadRequest{
 		RepositoryName: repoName,
 		ModelName:      modelName,
 		Parameters:     modelConfigBody,
 	})
-	return loadResponse, t.grpcErrorHandler(loadErr)
+	return loadResponse, t.grpcErrorHandler(loadErr, "RepositoryModelLoad", modelName, "")
 }
 
 // ModelUnloadWithHTTP Unload model with http
 // modelConfigBody if not is nil
 func (t *TritonClientService) ModelUnloadWithHTTP(modelName string, modelConfigBody []byte, timeout time.Duration) (*RepositoryModelUnloadResponse, error) {
-	respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForRepoModelPrefix+modelName+"/unload", modelConfigBody, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ModelUnloadWithHTTPCtx(ctx, modelName, modelConfigBody)
+}
+
+// ModelUnloadWithHTTPCtx Unload model with http and context
+// modelConfigBody if not is nil
+func (t *TritonClientService) ModelUnloadWithHTTPCtx(ctx context.Context, modelName string, modelConfigBody []byte) (*RepositoryModelUnloadResponse, error) {
+	respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForRepoModelPrefix+modelName+"/unload", modelConfigBody)
 	if httpErr != nil || statusCode != fasthttp.StatusOK {
-		return nil, t.httpErrorHandler(statusCode, httpErr)
+		return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "RepositoryModelUnload", modelName, "")
 	}
 	repositoryModelUnloadResponse := new(RepositoryModelUnloadResponse)
+	// triton returns empty body when model is unloaded
+	if len(respBody) == 0 {
+		return repositoryModelUnloadResponse, nil
+	}
 	jsonDecodeErr := json.Unmarshal(respBody, &repositoryModelUnloadResponse)
 	if jsonDecodeErr != nil {
 		return nil, jsonDecodeErr
@@ -504,29 +881,41 @@ func (t *TritonClientService) ModelUnloadWithGRPC(repoName, modelName string, mo
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	return t.ModelUnloadWithGRPCCtx(ctx, repoName, modelName, modelConfigBody)
+}
+
+// ModelUnloadWithGRPCCtx Unload model with grpc and context
+// modelConfigBody if not is nil
+func (t *TritonClientService) ModelUnloadWithGRPCCtx(ctx context.Context, repoName, modelName string, modelConfigBody map[string]*ModelRepositoryParameter) (*RepositoryModelUnloadResponse, error) {
 	unloadResponse, unloadErr := t.grpcClient.RepositoryModelUnload(ctx, &RepositoryModelUnloadRequest{
 		RepositoryName: repoName,
 		ModelName:      modelName,
 		Parameters:     modelConfigBody,
 	})
-	return unloadResponse, t.grpcErrorHandler(unloadErr)
+	return unloadResponse, t.grpcErrorHandler(unloadErr, "RepositoryModelUnload", modelName, "")
 }
 
 // ShareMemoryStatus Get share memory / cuda memory status. Response: CudaSharedMemoryStatusResponse / SystemSharedMemoryStatusResponse
 func (t *TritonClientService) ShareMemoryStatus(isCUDA bool, regionName string, timeout time.Duration) (interface{}, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ShareMemoryStatusCtx(ctx, isCUDA, regionName)
+}
 
+// ShareMemoryStatusCtx Get share memory / cuda memory status with context.
+// Response: CudaSharedMemoryStatusResponse / SystemSharedMemoryStatusResponse
+func (t *TritonClientService) ShareMemoryStatusCtx(ctx context.Context, isCUDA bool, regionName string) (interface{}, error) {
+	if t.grpcClient != nil {
 		if isCUDA {
 			// CUDA Memory
 			cudaSharedMemoryStatusResponse, cudaStatusErr := t.grpcClient.CudaSharedMemoryStatus(ctx, &CudaSharedMemoryStatusRequest{Name: regionName})
-			return cudaSharedMemoryStatusResponse, t.grpcErrorHandler(cudaStatusErr)
+			return cudaSharedMemoryStatusResponse, t.grpcErrorHandler(cudaStatusErr, "SharedMemoryStatus", "", "")
 		} else {
 			// System Memory
 			systemSharedMemoryStatusResponse, systemStatusErr := t.grpcClient.SystemSharedMemoryStatus(ctx, &SystemSharedMemoryStatusRequest{Name: regionName})
 			if systemStatusErr != nil {
-				return nil, t.grpcErrorHandler(systemStatusErr)
+				return nil, t.grpcErrorHandler(systemStatusErr, "SharedMemoryStatus", "", "")
 			}
 			return systemSharedMemoryStatusResponse, nil
 		}
@@ -534,13 +923,13 @@ func (t *TritonClientService) ShareMemoryStatus(isCUDA bool, regionName string,
 		// SetRequestURI
 		var uri string
 		if isCUDA {
-			uri = HTTPPrefix + t.ServerURL + TritonAPIForCudaMemoryRegionPrefix + regionName + "/status"
+			uri = t.getServerURL() + TritonAPIForCudaMemoryRegionPrefix + regionName + "/status"
 		} else {
-			uri = HTTPPrefix + t.ServerURL + TritonAPIForSystemMemoryRegionPrefix + regionName + "/status"
+			uri = t.getServerURL() + TritonAPIForSystemMemoryRegionPrefix + regionName + "/status"
 		}
-		respBody, statusCode, httpErr := t.makeHttpGetRequestWithDoTimeout(uri, timeout)
+		respBody, statusCode, httpErr := t.makeHttpGetRequestWithContext(ctx, uri)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "SharedMemoryStatus", "", "")
 		}
 		// Parse Response
 		if isCUDA {
@@ -561,10 +950,15 @@ func (t *TritonClientService) ShareMemoryStatus(isCUDA bool, regionName string,
 
 // ShareCUDAMemoryRegister cuda share memory register
 func (t *TritonClientService) ShareCUDAMemoryRegister(regionName string, cudaRawHandle []byte, cudaDeviceId int64, byteSize uint64, timeout time.Duration) (*CudaSharedMemoryRegisterResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.ShareCUDAMemoryRegisterCtx(ctx, regionName, cudaRawHandle, cudaDeviceId, byteSize)
+}
+
+// ShareCUDAMemoryRegisterCtx cuda share memory register with context
+func (t *TritonClientService) ShareCUDAMemoryRegisterCtx(ctx context.Context, regionName string, cudaRawHandle []byte, cudaDeviceId int64, byteSize uint64) (*CudaSharedMemoryRegisterResponse, error) {
+	if t.grpcClient != nil {
 		// CUDA Memory
 		cudaSharedMemoryRegisterResponse, registerErr := t.grpcClient.CudaSharedMemoryRegister(ctx, &CudaSharedMemoryRegisterRequest{
 			Name:      regionName,
@@ -572,17 +966,20 @@ func (t *TritonClientService) ShareCUDAMemoryRegister(regionName string, cudaRaw
 			DeviceId:  cudaDeviceId,
 			ByteSize:  byteSize,
 		})
-		return cudaSharedMemoryRegisterResponse, t.grpcErrorHandler(registerErr)
+		return cudaSharedMemoryRegisterResponse, t.grpcErrorHandler(registerErr, "CudaSharedMemoryRegister", "", "")
 	} else {
 		reqBody, jsonEncodeErr := json.Marshal(&CudaMemoryRegisterBodyHTTPObj{cudaRawHandle, cudaDeviceId, byteSize})
 		if jsonEncodeErr != nil {
 			return nil, jsonEncodeErr
 		}
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForCudaMemoryRegionPrefix+regionName+"/register", reqBody, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForCudaMemoryRegionPrefix+regionName+"/register", reqBody)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "CudaSharedMemoryRegister", "", "")
 		}
 		cudaSharedMemoryRegisterResponse := new(CudaSharedMemoryRegisterResponse)
+		if len(respBody) == 0 {
+			return cudaSharedMemoryRegisterResponse, nil
+		}
 		if jsonDecodeErr := json.Unmarshal(respBody, &cudaSharedMemoryRegisterResponse); jsonDecodeErr != nil {
 			return nil, jsonDecodeErr
 		}
@@ -592,19 +989,27 @@ func (t *TritonClientService) ShareCUDAMemoryRegister(regionName string, cudaRaw
 
 // ShareCUDAMemoryUnRegister cuda share memory unregister
 func (t *TritonClientService) ShareCUDAMemoryUnRegister(regionName string, timeout time.Duration) (*CudaSharedMemoryUnregisterResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ShareCUDAMemoryUnRegisterCtx(ctx, regionName)
+}
 
+// ShareCUDAMemoryUnRegisterCtx cuda share memory unregister with context
+func (t *TritonClientService) ShareCUDAMemoryUnRegisterCtx(ctx context.Context, regionName string) (*CudaSharedMemoryUnregisterResponse, error) {
+	if t.grpcClient != nil {
 		// CUDA Memory
 		cudaSharedMemoryUnRegisterResponse, unRegisterErr := t.grpcClient.CudaSharedMemoryUnregister(ctx, &CudaSharedMemoryUnregisterRequest{Name: regionName})
-		return cudaSharedMemoryUnRegisterResponse, t.grpcErrorHandler(unRegisterErr)
+		return cudaSharedMemoryUnRegisterResponse, t.grpcErrorHandler(unRegisterErr, "CudaSharedMemoryUnregister", "", "")
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForCudaMemoryRegionPrefix+regionName+"/unregister", nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForCudaMemoryRegionPrefix+regionName+"/unregister", nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "CudaSharedMemoryUnregister", "", "")
 		}
 		cudaSharedMemoryUnregisterResponse := new(CudaSharedMemoryUnregisterResponse)
+		if len(respBody) == 0 {
+			return cudaSharedMemoryUnregisterResponse, nil
+		}
 		if jsonDecodeErr := json.Unmarshal(respBody, &cudaSharedMemoryUnregisterResponse); jsonDecodeErr != nil {
 			return nil, jsonDecodeErr
 		}
@@ -614,10 +1019,15 @@ func (t *TritonClientService) ShareCUDAMemoryUnRegister(regionName string, timeo
 
 // ShareSystemMemoryRegister system share memory register
 func (t *TritonClientService) ShareSystemMemoryRegister(regionName, cpuMemRegionKey string, byteSize, cpuMemOffset uint64, timeout time.Duration) (*SystemSharedMemoryRegisterResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.ShareSystemMemoryRegisterCtx(ctx, regionName, cpuMemRegionKey, byteSize, cpuMemOffset)
+}
 
+// ShareSystemMemoryRegisterCtx system share memory register with context
+func (t *TritonClientService) ShareSystemMemoryRegisterCtx(ctx context.Context, regionName, cpuMemRegionKey string, byteSize, cpuMemOffset uint64) (*SystemSharedMemoryRegisterResponse, error) {
+	if t.grpcClient != nil {
 		// System Memory
 		systemSharedMemoryRegisterResponse, registerErr := t.grpcClient.SystemSharedMemoryRegister(ctx, &SystemSharedMemoryRegisterRequest{
 			Name:     regionName,
@@ -625,17 +1035,20 @@ func (t *TritonClientService) ShareSystemMemoryRegister(regionName, cpuMemRegion
 			Offset:   cpuMemOffset,
 			ByteSize: byteSize,
 		})
-		return systemSharedMemoryRegisterResponse, t.grpcErrorHandler(registerErr)
+		return systemSharedMemoryRegisterResponse, t.grpcErrorHandler(registerErr, "SystemSharedMemoryRegister", "", "")
 	} else {
 		reqBody, jsonEncodeErr := json.Marshal(&SystemMemoryRegisterBodyHTTPObj{cpuMemRegionKey, cpuMemOffset, byteSize})
 		if jsonEncodeErr != nil {
 			return nil, jsonEncodeErr
 		}
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForSystemMemoryRegionPrefix+regionName+"/register", reqBody, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForSystemMemoryRegionPrefix+regionName+"/register", reqBody)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "SystemSharedMemoryRegister", "", "")
 		}
 		systemSharedMemoryRegisterResponse := new(SystemSharedMemoryRegisterResponse)
+		if len(respBody) == 0 {
+			return systemSharedMemoryRegisterResponse, nil
+		}
 		if jsonDecodeErr := json.Unmarshal(respBody, &systemSharedMemoryRegisterResponse); jsonDecodeErr != nil {
 			return nil, jsonDecodeErr
 		}
@@ -645,19 +1058,27 @@ func (t *TritonClientService) ShareSystemMemoryRegister(regionName, cpuMemRegion
 
 // ShareSystemMemoryUnRegister system share memory unregister
 func (t *TritonClientService) ShareSystemMemoryUnRegister(regionName string, timeout time.Duration) (*SystemSharedMemoryUnregisterResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
+	return t.ShareSystemMemoryUnRegisterCtx(ctx, regionName)
+}
+
+// ShareSystemMemoryUnRegisterCtx system share memory unregister with context
+func (t *TritonClientService) ShareSystemMemoryUnRegisterCtx(ctx context.Context, regionName string) (*SystemSharedMemoryUnregisterResponse, error) {
+	if t.grpcClient != nil {
 		// System Memory
 		systemSharedMemoryUnRegisterResponse, unRegisterErr := t.grpcClient.SystemSharedMemoryUnregister(ctx, &SystemSharedMemoryUnregisterRequest{Name: regionName})
-		return systemSharedMemoryUnRegisterResponse, t.grpcErrorHandler(unRegisterErr)
+		return systemSharedMemoryUnRegisterResponse, t.grpcErrorHandler(unRegisterErr, "SystemSharedMemoryUnregister", "", "")
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForSystemMemoryRegionPrefix+regionName+"/unregister", nil, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForSystemMemoryRegionPrefix+regionName+"/unregister", nil)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "SystemSharedMemoryUnregister", "", "")
 		}
 		systemSharedMemoryUnregisterResponse := new(SystemSharedMemoryUnregisterResponse)
+		if len(respBody) == 0 {
+			return systemSharedMemoryUnregisterResponse, nil
+		}
 		if jsonDecodeErr := json.Unmarshal(respBody, &systemSharedMemoryUnregisterResponse); jsonDecodeErr != nil {
 			return nil, jsonDecodeErr
 		}
@@ -667,17 +1088,22 @@ func (t *TritonClientService) ShareSystemMemoryUnRegister(regionName string, tim
 
 // GetModelTracingSetting get model tracing setting
 func (t *TritonClientService) GetModelTracingSetting(modelName string, timeout time.Duration) (*TraceSettingResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.GetModelTracingSettingCtx(ctx, modelName)
+}
 
+// GetModelTracingSettingCtx get model tracing setting with context
+func (t *TritonClientService) GetModelTracingSettingCtx(ctx context.Context, modelName string) (*TraceSettingResponse, error) {
+	if t.grpcClient != nil {
 		// Tracing
 		traceSettingResponse, getTraceSettingErr := t.grpcClient.TraceSetting(ctx, &TraceSettingRequest{ModelName: modelName})
-		return traceSettingResponse, t.grpcErrorHandler(getTraceSettingErr)
+		return traceSettingResponse, t.grpcErrorHandler(getTraceSettingErr, "TraceSetting", modelName, "")
 	} else {
-		respBody, statusCode, httpErr := t.makeHttpGetRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+"/trace/setting", timeout)
+		respBody, statusCode, httpErr := t.makeHttpGetRequestWithContext(ctx, t.getServerURL()+TritonAPIForModelPrefix+modelName+"/trace/setting")
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "TraceSetting", modelName, "")
 		}
 		traceSettingResponse := new(TraceSettingResponse)
 		if jsonDecodeErr := json.Unmarshal(respBody, traceSettingResponse); jsonDecodeErr != nil {
@@ -690,21 +1116,27 @@ func (t *TritonClientService) GetModelTracingSetting(modelName string, timeout t
 // SetModelTracingSetting set model tracing setting
 // Param: settingMap ==> https://github.com/triton-inference-server/server/blob/main/docs/protocol/extension_trace.md#trace-setting-response-json-object
 func (t *TritonClientService) SetModelTracingSetting(modelName string, settingMap map[string]*TraceSettingRequest_SettingValue, timeout time.Duration) (*TraceSettingResponse, error) {
-	if t.grpcClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return t.SetModelTracingSettingCtx(ctx, modelName, settingMap)
+}
 
+// SetModelTracingSettingCtx set model tracing setting with context
+// Param: settingMap ==> https://github.com/triton-inference-server/server/blob/main/docs/protocol/extension_trace.md#trace-setting-response-json-object
+func (t *TritonClientService) SetModelTracingSettingCtx(ctx context.Context, modelName string, settingMap map[string]*TraceSettingRequest_SettingValue) (*TraceSettingResponse, error) {
+	if t.grpcClient != nil {
 		traceSettingResponse, setTraceSettingErr := t.grpcClient.TraceSetting(ctx, &TraceSettingRequest{ModelName: modelName, Settings: settingMap})
-		return traceSettingResponse, t.grpcErrorHandler(setTraceSettingErr)
+		return traceSettingResponse, t.grpcErrorHandler(setTraceSettingErr, "TraceSetting", modelName, "")
 	} else {
 		// Experimental
 		reqBody, jsonEncodeErr := json.Marshal(&TraceSettingRequestHTTPObj{settingMap})
 		if jsonEncodeErr != nil {
 			return nil, jsonEncodeErr
 		}
-		respBody, statusCode, httpErr := t.makeHttpPostRequestWithDoTimeout(HTTPPrefix+t.ServerURL+TritonAPIForModelPrefix+modelName+"/trace/setting", reqBody, timeout)
+		respBody, statusCode, httpErr := t.makeHttpPostRequestWithContext(ctx, t.getServerURL()+TritonAPIForModelPrefix+modelName+"/trace/setting", reqBody)
 		if httpErr != nil || statusCode != fasthttp.StatusOK {
-			return nil, t.httpErrorHandler(statusCode, httpErr)
+			return nil, t.httpErrorHandler(statusCode, httpErr, respBody, "TraceSetting", modelName, "")
 		}
 		traceSettingResponse := new(TraceSettingResponse)
 		if jsonDecodeErr := json.Unmarshal(respBody, traceSettingResponse); jsonDecodeErr != nil {
@@ -729,33 +1161,33 @@ func (t *TritonClientService) ShutdownTritonConnection() (disconnectionErr error
 }
 
 // NewTritonClientWithOnlyHttp init triton client
-func NewTritonClientWithOnlyHttp(uri string, httpClient *fasthttp.Client) *TritonClientService {
+func NewTritonClientWithOnlyHttp(uri string, httpClient *fasthttp.Client, opts ...TritonClientOption) *TritonClientService {
 	client := &TritonClientService{ServerURL: uri}
 	if httpCreateErr := client.setHTTPConnection(httpClient); httpCreateErr != nil {
 		return nil
 	}
+	client.applyOptions(opts...)
 	return client
 }
 
 // NewTritonClientWithOnlyGRPC init triton client
-func NewTritonClientWithOnlyGRPC(grpcConn *grpc.ClientConn) *TritonClientService {
-	client := &TritonClientService{
-		grpcConn:   grpcConn,
-		grpcClient: NewGRPCInferenceServiceClient(grpcConn),
-	}
+func NewTritonClientWithOnlyGRPC(grpcConn *grpc.ClientConn, opts ...TritonClientOption) *TritonClientService {
+	client := &TritonClientService{}
+	client.setGRPCConnection(grpcConn)
+	client.applyOptions(opts...)
 	return client
 }
 
 // NewTritonClientForAll init triton client with http and grpc
-func NewTritonClientForAll(httpServerUrl string, httpClient *fasthttp.Client, grpcConn *grpc.ClientConn) *TritonClientService {
-	client := &TritonClientService{
-		ServerURL:  httpServerUrl,
-		grpcConn:   grpcConn,
-		grpcClient: NewGRPCInferenceServiceClient(grpcConn),
+func NewTritonClientForAll(httpServerUrl string, httpClient *fasthttp.Client, grpcConn *grpc.ClientConn, opts ...TritonClientOption) *TritonClientService {
+	client := &TritonClientService{ServerURL: httpServerUrl}
+	if grpcConn != nil {
+		client.setGRPCConnection(grpcConn)
 	}
 	if httpCreateErr := client.setHTTPConnection(httpClient); httpCreateErr != nil {
 		return nil
 	}
+	client.applyOptions(opts...)
 
 	return client
 }
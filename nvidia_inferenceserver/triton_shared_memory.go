@@ -0,0 +1,52 @@
+package nvidia_inferenceserver
+
+const (
+	SharedMemoryRegionParamKey   string = "shared_memory_region"
+	SharedMemoryByteSizeParamKey string = "shared_memory_byte_size"
+	SharedMemoryOffsetParamKey   string = "shared_memory_offset"
+)
+
+// sharedMemoryParameters build parameters to reference data in shared memory region
+func sharedMemoryParameters(
+	params map[string]*InferParameter, regionName string, byteSize, offset uint64,
+) map[string]*InferParameter {
+	if params == nil {
+		params = make(map[string]*InferParameter, 3)
+	}
+	params[SharedMemoryRegionParamKey] = &InferParameter{
+		ParameterChoice: &InferParameter_StringParam{StringParam: regionName},
+	}
+	params[SharedMemoryByteSizeParamKey] = &InferParameter{
+		ParameterChoice: &InferParameter_Int64Param{Int64Param: int64(byteSize)},
+	}
+	if offset > 0 {
+		params[SharedMemoryOffsetParamKey] = &InferParameter{
+			ParameterChoice: &InferParameter_Int64Param{Int64Param: int64(offset)},
+		}
+	}
+	return params
+}
+
+// isSharedMemoryParameters check parameters reference shared memory region
+func isSharedMemoryParameters(params map[string]*InferParameter) bool {
+	_, ok := params[SharedMemoryRegionParamKey]
+	return ok
+}
+
+// SetSharedMemoryInput make input tensor read data from registered shared memory region (system or CUDA),
+// the raw input contents of this input must be nil.
+func SetSharedMemoryInput(
+	input *ModelInferRequest_InferInputTensor, regionName string, byteSize, offset uint64,
+) *ModelInferRequest_InferInputTensor {
+	input.Parameters = sharedMemoryParameters(input.Parameters, regionName, byteSize, offset)
+	return input
+}
+
+// SetSharedMemoryOutput make triton write output tensor into registered shared memory region (system or CUDA)
+// instead of returning it in response.
+func SetSharedMemoryOutput(
+	output *ModelInferRequest_InferRequestedOutputTensor, regionName string, byteSize, offset uint64,
+) *ModelInferRequest_InferRequestedOutputTensor {
+	output.Parameters = sharedMemoryParameters(output.Parameters, regionName, byteSize, offset)
+	return output
+}
@@ -0,0 +1,229 @@
+//go:build linux
+
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/net/context"
+)
+
+const SystemSharedMemoryDir string = "/dev/shm/"
+
+// SystemSharedMemoryRegion POSIX shared memory region created in /dev/shm and mapped into process memory
+type SystemSharedMemoryRegion struct {
+	name     string
+	key      string
+	byteSize uint64
+
+	lock sync.RWMutex
+	data []byte
+}
+
+// Name triton region name
+func (r *SystemSharedMemoryRegion) Name() string { return r.name }
+
+// Key shared memory key, like "/region_name"
+func (r *SystemSharedMemoryRegion) Key() string { return r.key }
+
+// ByteSize size of region
+func (r *SystemSharedMemoryRegion) ByteSize() uint64 { return r.byteSize }
+
+// checkRange check [offset, offset+byteSize) is in region and region is not closed
+func (r *SystemSharedMemoryRegion) checkRange(offset, byteSize uint64) error {
+	if r.data == nil {
+		return errors.New("[SHM]region " + r.name + " is closed")
+	}
+	if offset+byteSize < offset || offset+byteSize > r.byteSize {
+		return errors.New("[SHM]out of range of region " + r.name)
+	}
+	return nil
+}
+
+// Write write data into region at offset
+func (r *SystemSharedMemoryRegion) Write(offset uint64, data []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if rangeErr := r.checkRange(offset, uint64(len(data))); rangeErr != nil {
+		return rangeErr
+	}
+	copy(r.data[offset:], data)
+	return nil
+}
+
+// Read copy byteSize bytes of region at offset
+func (r *SystemSharedMemoryRegion) Read(offset, byteSize uint64) ([]byte, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if rangeErr := r.checkRange(offset, byteSize); rangeErr != nil {
+		return nil, rangeErr
+	}
+	data := make([]byte, byteSize)
+	copy(data, r.data[offset:offset+byteSize])
+	return data, nil
+}
+
+// WriteInput write tensor into region at offset and return input tensor which references the region
+func (r *SystemSharedMemoryRegion) WriteInput(
+	tensor *InferTensor, offset uint64,
+) (*ModelInferRequest_InferInputTensor, error) {
+	if validateErr := tensor.Validate(); validateErr != nil {
+		return nil, validateErr
+	}
+	input, raw := tensor.GRPCInput()
+	if writeErr := r.Write(offset, raw); writeErr != nil {
+		return nil, writeErr
+	}
+	return SetSharedMemoryInput(input, r.name, uint64(len(raw)), offset), nil
+}
+
+// Output create requested output tensor which is written into region at offset
+func (r *SystemSharedMemoryRegion) Output(
+	name string, byteSize, offset uint64,
+) *ModelInferRequest_InferRequestedOutputTensor {
+	return SetSharedMemoryOutput(&ModelInferRequest_InferRequestedOutputTensor{Name: name}, r.name, byteSize, offset)
+}
+
+// ReadOutput read output data from region at offset, output is got from InferResult and its typed accessors
+// (AsFloat32 / AsInt64 ...) can be used after read.
+func (r *SystemSharedMemoryRegion) ReadOutput(output *InferOutput, offset, byteSize uint64) error {
+	raw, readErr := r.Read(offset, byteSize)
+	if readErr != nil {
+		return readErr
+	}
+	output.raw, output.contents = raw, nil
+	return nil
+}
+
+// Close unmap and unlink the region, the region must be unregistered from triton before close
+func (r *SystemSharedMemoryRegion) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.data == nil {
+		return nil
+	}
+	if unmapErr := syscall.Munmap(r.data); unmapErr != nil {
+		return errors.New("[SHM]munmap error: " + unmapErr.Error())
+	}
+	r.data = nil
+	if removeErr := os.Remove(SystemSharedMemoryDir + strings.TrimPrefix(r.key, "/")); removeErr != nil &&
+		!os.IsNotExist(removeErr) {
+		return errors.New("[SHM]unlink error: " + removeErr.Error())
+	}
+	return nil
+}
+
+// NewSystemSharedMemoryRegion create POSIX shared memory region with key (like "/region_name") and map it,
+// the region is not registered to triton, use SharedMemoryManager to create and register region.
+func NewSystemSharedMemoryRegion(name, key string, byteSize uint64) (*SystemSharedMemoryRegion, error) {
+	if byteSize == 0 {
+		return nil, errors.New("[SHM]byte size of region must be positive")
+	}
+	shmName := strings.TrimPrefix(key, "/")
+	if shmName == "" || strings.Contains(shmName, "/") {
+		return nil, errors.New("[SHM]invalid shared memory key: " + key)
+	}
+	shmFile, openErr := os.OpenFile(SystemSharedMemoryDir+shmName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if openErr != nil {
+		return nil, errors.New("[SHM]create shared memory error: " + openErr.Error())
+	}
+	defer shmFile.Close()
+
+	if truncateErr := shmFile.Truncate(int64(byteSize)); truncateErr != nil {
+		_ = os.Remove(shmFile.Name())
+		return nil, errors.New("[SHM]truncate shared memory error: " + truncateErr.Error())
+	}
+	data, mmapErr := syscall.Mmap(
+		int(shmFile.Fd()), 0, int(byteSize), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if mmapErr != nil {
+		_ = os.Remove(shmFile.Name())
+		return nil, errors.New("[SHM]mmap error: " + mmapErr.Error())
+	}
+	return &SystemSharedMemoryRegion{name: name, key: "/" + shmName, byteSize: byteSize, data: data}, nil
+}
+
+// SharedMemoryManager create system shared memory regions and register them to triton
+type SharedMemoryManager struct {
+	client *TritonClientService
+
+	lock    sync.Mutex
+	regions map[string]*SystemSharedMemoryRegion
+}
+
+// CreateRegion create region with key "/"+name and register it to triton
+func (m *SharedMemoryManager) CreateRegion(
+	ctx context.Context, name string, byteSize uint64,
+) (*SystemSharedMemoryRegion, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.regions[name]; ok {
+		return nil, errors.New("[SHM]region " + name + " already exists")
+	}
+	region, createErr := NewSystemSharedMemoryRegion(name, "/"+name, byteSize)
+	if createErr != nil {
+		return nil, createErr
+	}
+	if _, registerErr := m.client.ShareSystemMemoryRegisterCtx(ctx, name, region.Key(), byteSize, 0); registerErr != nil {
+		_ = region.Close()
+		return nil, registerErr
+	}
+	m.regions[name] = region
+	return region, nil
+}
+
+// Region get region created by manager
+func (m *SharedMemoryManager) Region(name string) (*SystemSharedMemoryRegion, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	region, ok := m.regions[name]
+	return region, ok
+}
+
+// DestroyRegion unregister region from triton and close it
+func (m *SharedMemoryManager) DestroyRegion(ctx context.Context, name string) error {
+	m.lock.Lock()
+	region, ok := m.regions[name]
+	delete(m.regions, name)
+	m.lock.Unlock()
+
+	if !ok {
+		return errors.New("[SHM]region " + name + " not found")
+	}
+	_, unregisterErr := m.client.ShareSystemMemoryUnRegisterCtx(ctx, name)
+	if closeErr := region.Close(); closeErr != nil && unregisterErr == nil {
+		return closeErr
+	}
+	return unregisterErr
+}
+
+// Close destroy all regions created by manager, return the first error
+func (m *SharedMemoryManager) Close(ctx context.Context) error {
+	m.lock.Lock()
+	names := make([]string, 0, len(m.regions))
+	for name := range m.regions {
+		names = append(names, name)
+	}
+	m.lock.Unlock()
+
+	var firstErr error
+	for _, name := range names {
+		if destroyErr := m.DestroyRegion(ctx, name); destroyErr != nil && firstErr == nil {
+			firstErr = destroyErr
+		}
+	}
+	return firstErr
+}
+
+// NewSharedMemoryManager create system shared memory manager of client
+func NewSharedMemoryManager(client *TritonClientService) *SharedMemoryManager {
+	return &SharedMemoryManager{client: client, regions: make(map[string]*SystemSharedMemoryRegion)}
+}
@@ -0,0 +1,304 @@
+package nvidia_inferenceserver
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultStreamResponseBufferSize        int    = 64
+	StreamFinalResponseParamKey            string = "triton_final_response"
+	StreamEnableEmptyFinalResponseParamKey string = "triton_enable_empty_final_response"
+)
+
+// StreamInferCallback Stream Infer Callback Function.
+// response may be nil when err is not nil, callback of request is not called again after an error.
+type StreamInferCallback func(response *ModelInferResponse, err error)
+
+// StreamInferResult Stream Infer Result Struct
+type StreamInferResult struct {
+	RequestID string
+	Response  *ModelInferResponse
+	Err       error
+}
+
+// ModelStreamInferSession bidirectional stream infer session base on ModelStreamInfer
+type ModelStreamInferSession struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stream GRPCInferenceService_ModelStreamInferClient
+
+	sendLock         sync.Mutex
+	callbackLock     sync.RWMutex
+	defaultCallback  StreamInferCallback
+	requestCallbacks map[string]StreamInferCallback
+	// requestTrackers observers of request results which do not change delivery, used by SequenceSession
+	requestTrackers map[string]func(err error)
+	// inflightRequests request ids of callbacks / trackers without final response or error in send order
+	inflightRequests []string
+	responseChan     chan *StreamInferResult
+
+	closeOnce sync.Once
+	doneChan  chan struct{}
+	err       error
+}
+
+// removeInflightRequest remove request id from in-flight requests, callbackLock must be held
+func (s *ModelStreamInferSession) removeInflightRequest(requestID string) {
+	for i, inflightID := range s.inflightRequests {
+		if inflightID == requestID {
+			s.inflightRequests = append(s.inflightRequests[:i], s.inflightRequests[i+1:]...)
+			return
+		}
+	}
+}
+
+// getRequestCallback get callback and tracker registered for request id of result.
+// Error without request id (no infer_response) is correlated to the oldest in-flight request, which may have
+// got partial responses of decoupled model already, because triton reports errors of stream requests in receive order.
+func (s *ModelStreamInferSession) getRequestCallback(result *StreamInferResult) (StreamInferCallback, func(err error)) {
+	s.callbackLock.RLock()
+	defer s.callbackLock.RUnlock()
+	if result.RequestID == "" && result.Err != nil && len(s.inflightRequests) > 0 {
+		result.RequestID = s.inflightRequests[0]
+	}
+	return s.requestCallbacks[result.RequestID], s.requestTrackers[result.RequestID]
+}
+
+// isFinalResponse check response is the final response of the request (decoupled model)
+func (s *ModelStreamInferSession) isFinalResponse(response *ModelInferResponse) bool {
+	if response == nil {
+		return false
+	}
+	finalParam, ok := response.Parameters[StreamFinalResponseParamKey]
+	return ok && finalParam.GetBoolParam()
+}
+
+// dispatch deliver stream response to callback or response channel
+func (s *ModelStreamInferSession) dispatch(result *StreamInferResult) {
+	callback, tracker := s.getRequestCallback(result)
+	// triton sends no more response of request after an error
+	isLast := result.Err != nil || s.isFinalResponse(result.Response)
+	if tracker != nil {
+		tracker(result.Err)
+		if isLast {
+			s.untrackRequest(result.RequestID)
+		}
+	}
+	if callback != nil {
+		callback(result.Response, result.Err)
+		if isLast {
+			s.UnregisterCallback(result.RequestID)
+		}
+		return
+	}
+	if s.defaultCallback != nil {
+		s.defaultCallback(result.Response, result.Err)
+		return
+	}
+	select {
+	case s.responseChan <- result:
+	case <-s.ctx.Done():
+	}
+}
+
+// failPendingCallbacks deliver stream error to callbacks and trackers without final response and remove them
+func (s *ModelStreamInferSession) failPendingCallbacks() {
+	s.callbackLock.Lock()
+	callbacks, trackers := s.requestCallbacks, s.requestTrackers
+	s.requestCallbacks = make(map[string]StreamInferCallback)
+	s.requestTrackers = make(map[string]func(err error))
+	s.inflightRequests = nil
+	s.callbackLock.Unlock()
+
+	streamErr := s.err
+	if streamErr == nil {
+		streamErr = errors.New("[GRPC]stream is closed before final response")
+	}
+	for _, tracker := range trackers {
+		tracker(streamErr)
+	}
+	for _, callback := range callbacks {
+		callback(nil, streamErr)
+	}
+}
+
+// receiveLoop receive stream response until stream is closed
+func (s *ModelStreamInferSession) receiveLoop() {
+	defer func() {
+		s.failPendingCallbacks()
+		close(s.responseChan)
+		close(s.doneChan)
+	}()
+	for {
+		streamResponse, recvErr := s.stream.Recv()
+		if recvErr != nil {
+			if recvErr != io.EOF {
+				if s.ctx.Err() != nil && status.Code(recvErr) == codes.Canceled {
+					s.err = s.ctx.Err()
+				} else {
+					s.err = errors.New("[GRPC]stream receive error: " + recvErr.Error())
+				}
+			}
+			return
+		}
+		result := &StreamInferResult{Response: streamResponse.GetInferResponse()}
+		if result.Response != nil {
+			result.RequestID = result.Response.Id
+		}
+		if streamResponse.GetErrorMessage() != "" {
+			result.Err = errors.New("[GRPC]stream infer error: " + streamResponse.GetErrorMessage())
+		}
+		s.dispatch(result)
+	}
+}
+
+// Send send an infer request on the stream
+func (s *ModelStreamInferSession) Send(request *ModelInferRequest) error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	if sendErr := s.stream.Send(request); sendErr != nil {
+		return errors.New("[GRPC]stream send error: " + sendErr.Error())
+	}
+	return nil
+}
+
+// SendWithCallback send an infer request and deliver the responses of this request to callback.
+// request.Id must not be empty, triton_enable_empty_final_response is set on request so that triton marks
+// the last response (or sends an empty one for decoupled model) with triton_final_response.
+// The callback is removed when final response or an error arrives, the stream is finished
+// (callback gets the stream error) or UnregisterCallback is called.
+func (s *ModelStreamInferSession) SendWithCallback(request *ModelInferRequest, callback StreamInferCallback) error {
+	if request.Id == "" {
+		return errors.New("request id is empty, can not correlate responses")
+	}
+	if callback == nil {
+		return errors.New("callback function is nil")
+	}
+	if request.Parameters == nil {
+		request.Parameters = make(map[string]*InferParameter)
+	}
+	request.Parameters[StreamEnableEmptyFinalResponseParamKey] = &InferParameter{
+		ParameterChoice: &InferParameter_BoolParam{BoolParam: true},
+	}
+	s.callbackLock.Lock()
+	s.requestCallbacks[request.Id] = callback
+	if _, ok := s.requestTrackers[request.Id]; !ok {
+		s.inflightRequests = append(s.inflightRequests, request.Id)
+	}
+	s.callbackLock.Unlock()
+
+	if sendErr := s.Send(request); sendErr != nil {
+		s.UnregisterCallback(request.Id)
+		return sendErr
+	}
+	return nil
+}
+
+// UnregisterCallback remove callback of request id
+func (s *ModelStreamInferSession) UnregisterCallback(requestID string) {
+	s.callbackLock.Lock()
+	delete(s.requestCallbacks, requestID)
+	if _, ok := s.requestTrackers[requestID]; !ok {
+		s.removeInflightRequest(requestID)
+	}
+	s.callbackLock.Unlock()
+}
+
+// trackRequest observe errors of request id before it is sent, results are still delivered as usual.
+// Tracker is removed when final response or an error arrives, the stream is finished or untrackRequest is called.
+func (s *ModelStreamInferSession) trackRequest(requestID string, tracker func(err error)) {
+	s.callbackLock.Lock()
+	s.requestTrackers[requestID] = tracker
+	if _, ok := s.requestCallbacks[requestID]; !ok {
+		s.inflightRequests = append(s.inflightRequests, requestID)
+	}
+	s.callbackLock.Unlock()
+}
+
+// untrackRequest remove tracker of request id
+func (s *ModelStreamInferSession) untrackRequest(requestID string) {
+	s.callbackLock.Lock()
+	delete(s.requestTrackers, requestID)
+	if _, ok := s.requestCallbacks[requestID]; !ok {
+		s.removeInflightRequest(requestID)
+	}
+	s.callbackLock.Unlock()
+}
+
+// Responses responses channel, only used when session is created without callback.
+// The channel is closed when the stream is finished.
+func (s *ModelStreamInferSession) Responses() <-chan *StreamInferResult {
+	return s.responseChan
+}
+
+// Done return a channel which is closed when the stream is finished.
+func (s *ModelStreamInferSession) Done() <-chan struct{} {
+	return s.doneChan
+}
+
+// Err return the error which makes stream finished, nil if stream is closed normally.
+// Only valid after Done is closed.
+func (s *ModelStreamInferSession) Err() error {
+	select {
+	case <-s.doneChan:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// CloseSend stop sending requests, but keep receiving responses of sent requests.
+func (s *ModelStreamInferSession) CloseSend() error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+
+	return s.stream.CloseSend()
+}
+
+// Close close the stream session and wait for receive loop exit.
+// Stream is canceled before taking send lock, so that Send blocked by flow control returns and releases the lock.
+func (s *ModelStreamInferSession) Close() error {
+	var closeErr error
+	s.closeOnce.Do(func() {
+		s.cancel()
+		closeErr = s.CloseSend()
+		<-s.doneChan
+	})
+	return closeErr
+}
+
+// NewModelStreamInferSession create a bidirectional stream infer session with GRPC.
+// If callback is nil, responses will be delivered through Responses channel.
+// The session is closed when ctx is canceled.
+func (t *TritonClientService) NewModelStreamInferSession(
+	ctx context.Context, callback StreamInferCallback,
+) (*ModelStreamInferSession, error) {
+	if t.grpcClient == nil {
+		return nil, errors.New("[GRPC]grpc client is nil, can not create stream session")
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream, streamErr := t.grpcClient.ModelStreamInfer(streamCtx)
+	if streamErr != nil {
+		cancel()
+		return nil, t.grpcErrorHandler(streamErr, "ModelStreamInfer", "", "")
+	}
+	session := &ModelStreamInferSession{
+		ctx:              streamCtx,
+		cancel:           cancel,
+		stream:           stream,
+		defaultCallback:  callback,
+		requestCallbacks: make(map[string]StreamInferCallback),
+		requestTrackers:  make(map[string]func(err error)),
+		responseChan:     make(chan *StreamInferResult, DefaultStreamResponseBufferSize),
+		doneChan:         make(chan struct{}),
+	}
+	go session.receiveLoop()
+	return session, nil
+}
@@ -0,0 +1,474 @@
+package nvidia_inferenceserver
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/goccy/go-json"
+)
+
+// TensorNumeric go numeric types which can be converted to triton tensor
+type TensorNumeric interface {
+	uint8 | uint16 | uint32 | uint64 | int8 | int16 | int32 | int64 | float32 | float64
+}
+
+// InferTensor typed tensor with little-endian raw contents, can be used as GRPC / HTTP input.
+type InferTensor struct {
+	Name       string
+	Datatype   string
+	Shape      []int64
+	Raw        []byte
+	Parameters map[string]*InferParameter
+}
+
+// DataTypeByteSize byte size of a single element of datatype, return 0 for BYTES and unknown datatype.
+func DataTypeByteSize(datatype string) int {
+	switch datatype {
+	case TritonDataTypeBool, TritonDataTypeUint8, TritonDataTypeInt8:
+		return 1
+	case TritonDataTypeUint16, TritonDataTypeInt16, TritonDataTypeFP16, TritonDataTypeBF16:
+		return 2
+	case TritonDataTypeUint32, TritonDataTypeInt32, TritonDataTypeFP32:
+		return 4
+	case TritonDataTypeUint64, TritonDataTypeInt64, TritonDataTypeFP64:
+		return 8
+	}
+	return 0
+}
+
+// NumericDataType triton datatype of go numeric type T
+func NumericDataType[T TensorNumeric]() string {
+	var zero T
+	switch any(zero).(type) {
+	case uint8:
+		return TritonDataTypeUint8
+	case uint16:
+		return TritonDataTypeUint16
+	case uint32:
+		return TritonDataTypeUint32
+	case uint64:
+		return TritonDataTypeUint64
+	case int8:
+		return TritonDataTypeInt8
+	case int16:
+		return TritonDataTypeInt16
+	case int32:
+		return TritonDataTypeInt32
+	case int64:
+		return TritonDataTypeInt64
+	case float32:
+		return TritonDataTypeFP32
+	}
+	return TritonDataTypeFP64
+}
+
+// ShapeElementCount element count of shape, dims must not be negative
+func ShapeElementCount(shape []int64) (int64, error) {
+	count := int64(1)
+	for _, dim := range shape {
+		if dim < 0 {
+			return 0, errors.New("invalid shape dim: " + strconv.FormatInt(dim, 10))
+		}
+		count *= dim
+	}
+	return count, nil
+}
+
+// validateShape validate shape against element count
+func validateShape(shape []int64, elementCount int) error {
+	shapeElementCount, shapeErr := ShapeElementCount(shape)
+	if shapeErr != nil {
+		return shapeErr
+	}
+	if shapeElementCount != int64(elementCount) {
+		return errors.New("shape element count " + strconv.FormatInt(shapeElementCount, 10) +
+			" not equal to data element count " + strconv.Itoa(elementCount))
+	}
+	return nil
+}
+
+// CastNumericToRawContents cast go numeric slice to target datatype and encode it with little-endian.
+// Support all numeric datatype and BOOL, FP16 / BF16 are rounded to nearest even.
+func CastNumericToRawContents[T TensorNumeric](datatype string, data []T) ([]byte, error) {
+	elementSize := DataTypeByteSize(datatype)
+	if elementSize == 0 {
+		return nil, errors.New("unsupported datatype: " + datatype)
+	}
+	raw := make([]byte, len(data)*elementSize)
+	for i, value := range data {
+		offset := i * elementSize
+		switch datatype {
+		case TritonDataTypeBool:
+			if value != 0 {
+				raw[offset] = 1
+			}
+		case TritonDataTypeUint8, TritonDataTypeInt8:
+			raw[offset] = uint8(value)
+		case TritonDataTypeUint16, TritonDataTypeInt16:
+			binary.LittleEndian.PutUint16(raw[offset:], uint16(value))
+		case TritonDataTypeUint32, TritonDataTypeInt32:
+			binary.LittleEndian.PutUint32(raw[offset:], uint32(value))
+		case TritonDataTypeUint64, TritonDataTypeInt64:
+			binary.LittleEndian.PutUint64(raw[offset:], uint64(value))
+		case TritonDataTypeFP16:
+			binary.LittleEndian.PutUint16(raw[offset:], float32ToFloat16Bits(float32(value)))
+		case TritonDataTypeBF16:
+			binary.LittleEndian.PutUint16(raw[offset:], float32ToBFloat16Bits(float32(value)))
+		case TritonDataTypeFP32:
+			binary.LittleEndian.PutUint32(raw[offset:], math.Float32bits(float32(value)))
+		case TritonDataTypeFP64:
+			binary.LittleEndian.PutUint64(raw[offset:], math.Float64bits(float64(value)))
+		}
+	}
+	return raw, nil
+}
+
+// DecodeRawContentsToNumeric decode little-endian raw contents of datatype to go numeric slice, values are cast to T.
+func DecodeRawContentsToNumeric[T TensorNumeric](datatype string, raw []byte) ([]T, error) {
+	elementSize := DataTypeByteSize(datatype)
+	if elementSize == 0 {
+		return nil, errors.New("unsupported datatype: " + datatype)
+	}
+	if len(raw)%elementSize != 0 {
+		return nil, errors.New("raw contents size " + strconv.Itoa(len(raw)) + " is not a multiple of " + datatype + " size")
+	}
+	data := make([]T, len(raw)/elementSize)
+	for i := range data {
+		offset := i * elementSize
+		switch datatype {
+		case TritonDataTypeBool, TritonDataTypeUint8:
+			data[i] = T(raw[offset])
+		case TritonDataTypeInt8:
+			data[i] = T(int8(raw[offset]))
+		case TritonDataTypeUint16:
+			data[i] = T(binary.LittleEndian.Uint16(raw[offset:]))
+		case TritonDataTypeInt16:
+			data[i] = T(int16(binary.LittleEndian.Uint16(raw[offset:])))
+		case TritonDataTypeUint32:
+			data[i] = T(binary.LittleEndian.Uint32(raw[offset:]))
+		case TritonDataTypeInt32:
+			data[i] = T(int32(binary.LittleEndian.Uint32(raw[offset:])))
+		case TritonDataTypeUint64:
+			data[i] = T(binary.LittleEndian.Uint64(raw[offset:]))
+		case TritonDataTypeInt64:
+			data[i] = T(int64(binary.LittleEndian.Uint64(raw[offset:])))
+		case TritonDataTypeFP16:
+			data[i] = T(float16BitsToFloat32(binary.LittleEndian.Uint16(raw[offset:])))
+		case TritonDataTypeBF16:
+			data[i] = T(math.Float32frombits(uint32(binary.LittleEndian.Uint16(raw[offset:])) << 16))
+		case TritonDataTypeFP32:
+			data[i] = T(math.Float32frombits(binary.LittleEndian.Uint32(raw[offset:])))
+		case TritonDataTypeFP64:
+			data[i] = T(math.Float64frombits(binary.LittleEndian.Uint64(raw[offset:])))
+		}
+	}
+	return data, nil
+}
+
+// EncodeBoolRawContents encode bool slice to raw contents
+func EncodeBoolRawContents(data []bool) []byte {
+	raw := make([]byte, len(data))
+	for i, value := range data {
+		if value {
+			raw[i] = 1
+		}
+	}
+	return raw
+}
+
+// DecodeBoolRawContents decode raw contents to bool slice
+func DecodeBoolRawContents(raw []byte) []bool {
+	data := make([]bool, len(raw))
+	for i, b := range raw {
+		data[i] = b != 0
+	}
+	return data
+}
+
+// EncodeBytesRawContents encode BYTES elements with 4-bytes little-endian length prefix
+func EncodeBytesRawContents(data [][]byte) []byte {
+	rawSize := 0
+	for _, element := range data {
+		rawSize += bytesElementLengthPrefixSize + len(element)
+	}
+	raw := make([]byte, 0, rawSize)
+	lengthPrefix := make([]byte, bytesElementLengthPrefixSize)
+	for _, element := range data {
+		binary.LittleEndian.PutUint32(lengthPrefix, uint32(len(element)))
+		raw = append(raw, lengthPrefix...)
+		raw = append(raw, element...)
+	}
+	return raw
+}
+
+// DecodeBytesRawContents decode 4-bytes length prefixed raw contents to BYTES elements
+func DecodeBytesRawContents(raw []byte) ([][]byte, error) {
+	var data [][]byte
+	for offset := 0; offset < len(raw); {
+		if offset+bytesElementLengthPrefixSize > len(raw) {
+			return nil, errors.New("invalid BYTES raw contents")
+		}
+		elementSize := int(binary.LittleEndian.Uint32(raw[offset:]))
+		offset += bytesElementLengthPrefixSize
+		if offset+elementSize > len(raw) {
+			return nil, errors.New("invalid BYTES raw contents")
+		}
+		data = append(data, raw[offset:offset+elementSize])
+		offset += elementSize
+	}
+	return data, nil
+}
+
+// NewNumericTensor create tensor from go numeric slice, datatype is derived from T.
+func NewNumericTensor[T TensorNumeric](name string, shape []int64, data []T) (*InferTensor, error) {
+	return NewNumericTensorWithDataType(name, NumericDataType[T](), shape, data)
+}
+
+// NewNumericTensorWithDataType create tensor from go numeric slice and cast values to datatype. Like []float32 to FP16.
+func NewNumericTensorWithDataType[T TensorNumeric](name, datatype string, shape []int64, data []T) (*InferTensor, error) {
+	if shapeErr := validateShape(shape, len(data)); shapeErr != nil {
+		return nil, errors.New("tensor " + name + ": " + shapeErr.Error())
+	}
+	raw, encodeErr := CastNumericToRawContents(datatype, data)
+	if encodeErr != nil {
+		return nil, errors.New("tensor " + name + ": " + encodeErr.Error())
+	}
+	return &InferTensor{Name: name, Datatype: datatype, Shape: shape, Raw: raw}, nil
+}
+
+// NewBoolTensor create BOOL tensor
+func NewBoolTensor(name string, shape []int64, data []bool) (*InferTensor, error) {
+	if shapeErr := validateShape(shape, len(data)); shapeErr != nil {
+		return nil, errors.New("tensor " + name + ": " + shapeErr.Error())
+	}
+	return &InferTensor{Name: name, Datatype: TritonDataTypeBool, Shape: shape, Raw: EncodeBoolRawContents(data)}, nil
+}
+
+// NewBytesTensor create BYTES tensor
+func NewBytesTensor(name string, shape []int64, data [][]byte) (*InferTensor, error) {
+	if shapeErr := validateShape(shape, len(data)); shapeErr != nil {
+		return nil, errors.New("tensor " + name + ": " + shapeErr.Error())
+	}
+	return &InferTensor{Name: name, Datatype: TritonDataTypeBytes, Shape: shape, Raw: EncodeBytesRawContents(data)}, nil
+}
+
+// NewStringTensor create BYTES tensor from string slice
+func NewStringTensor(name string, shape []int64, data []string) (*InferTensor, error) {
+	bytesData := make([][]byte, len(data))
+	for i, str := range data {
+		bytesData[i] = []byte(str)
+	}
+	return NewBytesTensor(name, shape, bytesData)
+}
+
+// ElementCount element count of tensor
+func (t *InferTensor) ElementCount() int64 {
+	count, _ := ShapeElementCount(t.Shape)
+	return count
+}
+
+// Validate validate raw contents size against shape and datatype
+func (t *InferTensor) Validate() error {
+	elementCount, shapeErr := ShapeElementCount(t.Shape)
+	if shapeErr != nil {
+		return errors.New("tensor " + t.Name + ": " + shapeErr.Error())
+	}
+	if t.Datatype == TritonDataTypeBytes {
+		data, decodeErr := DecodeBytesRawContents(t.Raw)
+		if decodeErr != nil {
+			return errors.New("tensor " + t.Name + ": " + decodeErr.Error())
+		}
+		return validateShape(t.Shape, len(data))
+	}
+	elementSize := DataTypeByteSize(t.Datatype)
+	if elementSize == 0 {
+		return errors.New("tensor " + t.Name + ": unsupported datatype: " + t.Datatype)
+	}
+	if int64(len(t.Raw)) != elementCount*int64(elementSize) {
+		return errors.New("tensor " + t.Name + ": raw contents size " + strconv.Itoa(len(t.Raw)) +
+			" not match shape and datatype")
+	}
+	return nil
+}
+
+// GRPCInput GRPC input tensor and raw contents
+func (t *InferTensor) GRPCInput() (*ModelInferRequest_InferInputTensor, []byte) {
+	return &ModelInferRequest_InferInputTensor{
+		Name:       t.Name,
+		Datatype:   t.Datatype,
+		Shape:      t.Shape,
+		Parameters: t.Parameters,
+	}, t.Raw
+}
+
+// JSONData decode raw contents to json serializable data (flat slice)
+func (t *InferTensor) JSONData() (interface{}, error) {
+	switch t.Datatype {
+	case TritonDataTypeBool:
+		return DecodeBoolRawContents(t.Raw), nil
+	case TritonDataTypeBytes:
+		data, decodeErr := DecodeBytesRawContents(t.Raw)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+		strData := make([]string, len(data))
+		for i, element := range data {
+			strData[i] = string(element)
+		}
+		return strData, nil
+	case TritonDataTypeUint8, TritonDataTypeUint16, TritonDataTypeUint32, TritonDataTypeUint64:
+		return DecodeRawContentsToNumeric[uint64](t.Datatype, t.Raw)
+	case TritonDataTypeInt8, TritonDataTypeInt16, TritonDataTypeInt32, TritonDataTypeInt64:
+		return DecodeRawContentsToNumeric[int64](t.Datatype, t.Raw)
+	case TritonDataTypeFP16, TritonDataTypeBF16, TritonDataTypeFP32:
+		return DecodeRawContentsToNumeric[float32](t.Datatype, t.Raw)
+	case TritonDataTypeFP64:
+		return DecodeRawContentsToNumeric[float64](t.Datatype, t.Raw)
+	}
+	return nil, errors.New("unsupported datatype: " + t.Datatype)
+}
+
+// HTTPInput HTTP input tensor json object.
+// If binaryData is true, return raw contents which should be appended after json header,
+// otherwise data is set to json object.
+func (t *InferTensor) HTTPInput(binaryData bool) (InferInputTensorHTTPObj, []byte, error) {
+	inputObj := InferInputTensorHTTPObj{
+		Name:       t.Name,
+		Shape:      t.Shape,
+		Datatype:   t.Datatype,
+		Parameters: inferParametersToHTTPObj(t.Parameters),
+	}
+	if binaryData {
+		if inputObj.Parameters == nil {
+			inputObj.Parameters = make(map[string]interface{}, 1)
+		}
+		inputObj.Parameters[BinaryDataSizeParamKey] = len(t.Raw)
+		return inputObj, t.Raw, nil
+	}
+	data, decodeErr := t.JSONData()
+	if decodeErr != nil {
+		return inputObj, nil, errors.New("tensor " + t.Name + ": " + decodeErr.Error())
+	}
+	inputObj.Data = data
+	return inputObj, nil, nil
+}
+
+// BuildGRPCInferInputs build GRPC input tensors and raw input contents from tensors
+func BuildGRPCInferInputs(tensors ...*InferTensor) ([]*ModelInferRequest_InferInputTensor, [][]byte) {
+	inferInputs := make([]*ModelInferRequest_InferInputTensor, len(tensors))
+	rawInputs := make([][]byte, len(tensors))
+	for i, tensor := range tensors {
+		inferInputs[i], rawInputs[i] = tensor.GRPCInput()
+	}
+	return inferInputs, rawInputs
+}
+
+// BuildHTTPInferRequestBody build HTTP infer request body from tensors.
+// If binaryData is true, use binary tensor data extension and return json header length (Inference-Header-Content-Length),
+// otherwise return json body and 0. The json body can be used by ModelHTTPInfer.
+func BuildHTTPInferRequestBody(
+	tensors []*InferTensor, inferOutputs []*ModelInferRequest_InferRequestedOutputTensor, binaryData bool,
+) ([]byte, int, error) {
+	if binaryData {
+		inferInputs, rawInputs := BuildGRPCInferInputs(tensors...)
+		return EncodeHTTPBinaryInferRequest(&ModelInferRequest{
+			Inputs: inferInputs, Outputs: inferOutputs, RawInputContents: rawInputs,
+		})
+	}
+	requestObj := InferRequestHTTPObj{
+		Inputs:  make([]InferInputTensorHTTPObj, len(tensors)),
+		Outputs: make([]InferOutputTensorHTTPObj, len(inferOutputs)),
+	}
+	for i, tensor := range tensors {
+		inputObj, _, inputErr := tensor.HTTPInput(false)
+		if inputErr != nil {
+			return nil, 0, inputErr
+		}
+		requestObj.Inputs[i] = inputObj
+	}
+	for i, output := range inferOutputs {
+		requestObj.Outputs[i] = InferOutputTensorHTTPObj{Name: output.Name, Parameters: inferParametersToHTTPObj(output.Parameters)}
+	}
+	jsonBody, jsonEncodeErr := json.Marshal(&requestObj)
+	if jsonEncodeErr != nil {
+		return nil, 0, jsonEncodeErr
+	}
+	return jsonBody, 0, nil
+}
+
+// float16BitsToFloat32 convert IEEE 754 half precision bits to float32
+func float16BitsToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exponent := uint32(h>>10) & 0x1f
+	mantissa := uint32(h) & 0x3ff
+	switch exponent {
+	case 0:
+		if mantissa == 0 {
+			return math.Float32frombits(sign)
+		}
+		// subnormal, normalize it
+		exponent = 127 - 15 + 1
+		for mantissa&0x400 == 0 {
+			mantissa <<= 1
+			exponent--
+		}
+		mantissa &= 0x3ff
+		return math.Float32frombits(sign | exponent<<23 | mantissa<<13)
+	case 0x1f:
+		// Inf / NaN
+		return math.Float32frombits(sign | 0xff<<23 | mantissa<<13)
+	}
+	return math.Float32frombits(sign | (exponent+127-15)<<23 | mantissa<<13)
+}
+
+// float32ToFloat16Bits convert float32 to IEEE 754 half precision bits, round to nearest even
+func float32ToFloat16Bits(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int32(bits>>23) & 0xff
+	mantissa := bits & 0x7fffff
+	if exponent == 0xff {
+		// Inf / NaN
+		if mantissa != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	}
+	exponent = exponent - 127 + 15
+	if exponent >= 0x1f {
+		// overflow to Inf
+		return sign | 0x7c00
+	}
+	if exponent <= 0 {
+		// subnormal or zero
+		if exponent < -10 {
+			return sign
+		}
+		mantissa |= 0x800000
+		shift := uint32(14 - exponent)
+		halfMantissa := mantissa >> shift
+		roundBit := uint32(1) << (shift - 1)
+		if mantissa&roundBit != 0 && (mantissa&(roundBit-1) != 0 || halfMantissa&1 != 0) {
+			halfMantissa++
+		}
+		return sign | uint16(halfMantissa)
+	}
+	half := sign | uint16(exponent)<<10 | uint16(mantissa>>13)
+	if mantissa&0x1000 != 0 && (mantissa&0xfff != 0 || half&1 != 0) {
+		// carry may overflow into exponent, which is still correct
+		half++
+	}
+	return half
+}
+
+// float32ToBFloat16Bits convert float32 to bfloat16 bits, round to nearest even
+func float32ToBFloat16Bits(f float32) uint16 {
+	bits := math.Float32bits(f)
+	if math.IsNaN(float64(f)) {
+		return uint16(bits>>16) | 0x40
+	}
+	rounding := uint32(0x7fff) + (bits>>16)&1
+	return uint16((bits + rounding) >> 16)
+}
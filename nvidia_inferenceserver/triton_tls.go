@@ -0,0 +1,55 @@
+package nvidia_inferenceserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// NewTLSConfig create tls config for http / grpc client.
+// caCertPath: custom CA certificate (PEM), use system root CA if empty.
+// clientCertPath / clientKeyPath: client certificate and key (PEM) for mutual-TLS, skip if empty.
+// serverName: used to verify the hostname of server certificate, use target host if empty.
+func NewTLSConfig(caCertPath, clientCertPath, clientKeyPath, serverName string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if caCertPath != "" {
+		caCert, readErr := os.ReadFile(caCertPath)
+		if readErr != nil {
+			return nil, errors.New("[TLS]read ca cert error: " + readErr.Error())
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("[TLS]failed to append ca cert: " + caCertPath)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	if clientCertPath != "" || clientKeyPath != "" {
+		clientCert, loadErr := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if loadErr != nil {
+			return nil, errors.New("[TLS]load client cert error: " + loadErr.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+	return tlsConfig, nil
+}
+
+// NewGRPCSecureConnection create grpc connection with TLS / mutual-TLS credentials.
+// tlsConfig can be created by NewTLSConfig, dialOpts will be appended after transport credentials.
+func NewGRPCSecureConnection(target string, tlsConfig *tls.Config, dialOpts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("[TLS]tls config is nil")
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}, dialOpts...)
+	grpcConn, dialErr := grpc.Dial(target, opts...)
+	if dialErr != nil {
+		return nil, errors.New("[GRPC]secure connection error: " + dialErr.Error())
+	}
+	return grpcConn, nil
+}
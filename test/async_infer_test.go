@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+func TestAsyncInferWithInFlightLimit(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := startFakeTriton(t, &tritontest.Model{
+		Name: tModelName,
+		Handler: func(_ context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				last := atomic.LoadInt32(&maxInFlight)
+				if current <= last || atomic.CompareAndSwapInt32(&maxInFlight, last, current) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			return &nvidia_inferenceserver.ModelInferResponse{}, nil
+		},
+	})
+	client, err := server.NewGRPCClient(nvidia_inferenceserver.WithMaxInFlight(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.ShutdownTritonConnection()
+
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) {
+		return []interface{}{params[0]}, nil
+	}
+	futures := make([]*nvidia_inferenceserver.InferFuture, 6)
+	for i := range futures {
+		futures[i] = client.ModelGRPCInferAsync(context.Background(), nil, nil, nil, tModelName, tModelVersion, decoder, i)
+	}
+	for i, future := range futures {
+		result, inferErr := future.Wait()
+		if inferErr != nil {
+			t.Fatal(inferErr)
+		}
+		if result[0] != i {
+			t.Fatalf("unexpected result of future %d: %v", i, result)
+		}
+	}
+	if atomic.LoadInt32(&maxInFlight) != 2 {
+		t.Fatalf("expect 2 in-flight requests at most, got %d", maxInFlight)
+	}
+
+	// future is not finished before ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	future := client.ModelGRPCInferAsync(context.Background(), nil, nil, nil, tModelName, tModelVersion, decoder, 0)
+	if _, err = future.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect context deadline exceeded, got %v", err)
+	}
+	<-future.Done()
+}
+
+func TestInferGroupCancelOnFirstError(t *testing.T) {
+	server := startFakeTriton(t,
+		&tritontest.Model{Name: "slow", Latency: 5 * time.Second},
+		&tritontest.Model{Name: "fast"},
+	)
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+
+	group := nvidia_inferenceserver.NewInferGroup(context.Background())
+	group.Add(
+		grpcClient.ModelGRPCInferAsync(group.Context(), nil, nil, nil, "fast", "", decoder),
+		grpcClient.ModelGRPCInferAsync(group.Context(), nil, nil, nil, "slow", "", decoder),
+		httpClient.ModelHTTPInferAsync(group.Context(), []byte(`{"inputs":[]}`), "unknown", "", decoder),
+	)
+	start := time.Now()
+	results, err := group.Wait()
+	if !errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) {
+		t.Fatalf("expect ErrModelNotFound, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("slow infer is not canceled by the first error")
+	}
+	if len(results) != 3 {
+		t.Fatalf("expect 3 results, got %d", len(results))
+	}
+
+	group = nvidia_inferenceserver.NewInferGroup(context.Background())
+	for i := 0; i < 3; i++ {
+		group.Go(grpcClient, func(ctx context.Context) ([]interface{}, error) {
+			return grpcClient.ModelGRPCInferCtx(ctx, nil, nil, nil, "fast", "", decoder)
+		})
+	}
+	if _, err = group.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunhailin-Leo/triton-service-go/models/bert"
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+// tokenCountModel fake bert model, output probability of every batch item is [token count, 0]
+type tokenCountModel struct {
+	lock       sync.Mutex
+	batchSizes []int64
+}
+
+func (m *tokenCountModel) handler(
+	_ context.Context, request *nvidia_inferenceserver.ModelInferRequest,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	for i, input := range request.Inputs {
+		if input.Name != tBertModelInputMaskKey {
+			continue
+		}
+		batchSize, seqLen := input.Shape[0], input.Shape[1]
+		m.lock.Lock()
+		m.batchSizes = append(m.batchSizes, batchSize)
+		m.lock.Unlock()
+
+		probability := make([]float32, batchSize*2)
+		for j := int64(0); j < batchSize*seqLen; j++ {
+			probability[j/seqLen*2] += float32(int32(binary.LittleEndian.Uint32(request.RawInputContents[i][j*4:])))
+		}
+		output, err := nvidia_inferenceserver.NewNumericTensor(tBertModelOutputProbabilitiesKey, []int64{batchSize, 2}, probability)
+		if err != nil {
+			return nil, err
+		}
+		return tritontest.StaticOutputHandler(output)(context.Background(), request)
+	}
+	return nil, status.Error(codes.InvalidArgument, "input_mask is missing")
+}
+
+func newTestBertBatcher(t *testing.T, model *tokenCountModel) (*tritontest.Server, *bert.DynamicBatcher) {
+	config := testModelConfig()
+	config.MaxBatchSize = 4
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: config, Handler: model.handler})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = grpcConn.Close() })
+	bertService, err := bert.NewModelService(
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testGenerateModelInferOutputRequest,
+		nvidia_inferenceserver.NewInferResultDecoder(testModerInferCallback))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bertService = bertService.SetChineseTokenize().SetMaxSeqLength(16).SetModelInferWithGRPC()
+	batcher, err := bertService.NewDynamicBatcher(tModelName, tModelVersion, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(batcher.Close)
+	return server, batcher.SetMaxQueueDelay(50 * time.Millisecond)
+}
+
+func TestBertDynamicBatcher(t *testing.T) {
+	model := new(tokenCountModel)
+	_, batcher := newTestBertBatcher(t, model)
+	if batcher.GetMaxBatchSize() != 4 {
+		t.Fatalf("expect max batch size 4 from model config, got %d", batcher.GetMaxBatchSize())
+	}
+	if batcher.GetRequestTimeout() != time.Second {
+		t.Fatalf("expect request timeout 1s of NewDynamicBatcher, got %v", batcher.GetRequestTimeout())
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(textLen int) {
+			defer wg.Done()
+			result, err := batcher.Infer(context.Background(), strings.Repeat("好", textLen))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			// [CLS] + text + [SEP]
+			if probability := result.([]float32); probability[0] != float32(textLen+2) {
+				t.Errorf("result of text length %d is scattered to wrong caller: %v", textLen, probability)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	model.lock.Lock()
+	defer model.lock.Unlock()
+	total := int64(0)
+	for _, batchSize := range model.batchSizes {
+		if batchSize > 4 {
+			t.Fatalf("batch size %d exceeds max batch size", batchSize)
+		}
+		total += batchSize
+	}
+	if total != 8 || len(model.batchSizes) >= 8 {
+		t.Fatalf("requests are not batched: %v", model.batchSizes)
+	}
+}
+
+func TestBertDynamicBatcherError(t *testing.T) {
+	server, batcher := newTestBertBatcher(t, new(tokenCountModel))
+	server.InjectError("ModelInfer", status.Error(codes.Unavailable, "server is busy"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := batcher.Infer(context.Background(), "你好"); err == nil {
+				t.Error("expect error of batched infer")
+			}
+		}()
+	}
+	wg.Wait()
+
+	batcher.Close()
+	if _, err := batcher.Infer(context.Background(), "你好"); !errors.Is(err, bert.ErrBatcherClosed) {
+		t.Fatalf("expect ErrBatcherClosed, got %v", err)
+	}
+}
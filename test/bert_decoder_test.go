@@ -0,0 +1,264 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/sunhailin-Leo/triton-service-go/models/bert"
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+// testBertHeadOutputFunc build output tensors of fake bert head with batch size and sequence length of request
+type testBertHeadOutputFunc func(batchSize, seqLength int64) []*nvidia_inferenceserver.InferTensor
+
+// testBertHeadHandler fake bert head model returns outputs of outputFunc
+func testBertHeadHandler(outputFunc testBertHeadOutputFunc) tritontest.ModelHandler {
+	return func(_ context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		shape := request.Inputs[0].Shape
+		response := new(nvidia_inferenceserver.ModelInferResponse)
+		for _, output := range outputFunc(shape[0], shape[1]) {
+			outputTensor, raw := output.GRPCInput()
+			response.Outputs = append(response.Outputs, &nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+				Name: outputTensor.Name, Datatype: outputTensor.Datatype, Shape: outputTensor.Shape,
+			})
+			response.RawOutputContents = append(response.RawOutputContents, raw)
+		}
+		return response, nil
+	}
+}
+
+// testOutputRequest request outputs by name
+func testOutputRequest(outputNames ...string) bert.GenerateModelInferOutputRequest {
+	return func(params ...interface{}) []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor {
+		outputs := make([]*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor, len(outputNames))
+		for i, outputName := range outputNames {
+			outputs[i] = &nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor{Name: outputName}
+		}
+		return outputs
+	}
+}
+
+// testBertHeadInfer infer with decoder over http / http binary / grpc, check result of every mode
+func testBertHeadInfer(
+	t *testing.T, outputFunc testBertHeadOutputFunc, outputNames []string, decoder nvidia_inferenceserver.DecoderFunc,
+	setup func(*bert.ModelService), infer func(*bert.ModelService) ([]interface{}, error), check func([]interface{}) error,
+) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Handler: testBertHeadHandler(outputFunc)})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcConn.Close()
+
+	bertService, initErr := bert.NewModelService(
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testOutputRequest(outputNames...), decoder)
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	bertService.SetChineseTokenize()
+	setup(bertService)
+	for _, mode := range []string{"http", "http-binary", "grpc"} {
+		bertService.UnsetModelInferWithGRPC().UnsetModelInferWithHTTPBinary()
+		switch mode {
+		case "http-binary":
+			bertService.SetModelInferWithHTTPBinary()
+		case "grpc":
+			bertService.SetModelInferWithGRPC()
+		}
+		inferResult, inferErr := infer(bertService)
+		if inferErr != nil {
+			t.Fatalf("%s infer error: %v", mode, inferErr)
+		}
+		if checkErr := check(inferResult); checkErr != nil {
+			t.Fatalf("%s %v", mode, checkErr)
+		}
+	}
+}
+
+func TestBertClassificationDecoder(t *testing.T) {
+	outputFunc := func(batchSize, _ int64) []*nvidia_inferenceserver.InferTensor {
+		logits := make([]float32, 0, batchSize*3)
+		for i := int64(0); i < batchSize; i++ {
+			logits = append(logits, 0, 2, 1)
+		}
+		output, _ := nvidia_inferenceserver.NewNumericTensor("logits", []int64{batchSize, 3}, logits)
+		return []*nvidia_inferenceserver.InferTensor{output}
+	}
+	decoder := bert.NewClassificationDecoder("logits", map[int]string{0: "negative", 1: "positive"}, 2)
+	testBertHeadInfer(t, outputFunc, []string{"logits"}, decoder,
+		func(s *bert.ModelService) { s.SetMaxSeqLength(8) },
+		func(s *bert.ModelService) ([]interface{}, error) {
+			return s.ModelInfer([]string{"今天天气很好", "明天"}, tModelName, tModelVersion, 1*time.Second)
+		},
+		func(result []interface{}) error {
+			labelScores := result[1].([]bert.LabelScore)
+			if len(labelScores) != 2 || labelScores[0].Label != "positive" || labelScores[1].Label != "2" ||
+				math.Abs(float64(labelScores[0].Score)-0.6652) > 1e-4 {
+				return fmt.Errorf("unexpected label scores: %v", labelScores)
+			}
+			return nil
+		})
+}
+
+func TestBertTokenClassificationDecoder(t *testing.T) {
+	// tokens: [CLS] 我 在 北 京 上 海 [SEP], 北 is B-LOC, 京 is I-LOC, 上 is S-LOC, 海 is I-LOC
+	tags := map[int64]int64{3: 1, 4: 2, 5: 3, 6: 2}
+	outputFunc := func(batchSize, seqLength int64) []*nvidia_inferenceserver.InferTensor {
+		logits := make([]float32, batchSize*seqLength*4)
+		for i := int64(0); i < batchSize; i++ {
+			for j := int64(0); j < seqLength; j++ {
+				logits[(i*seqLength+j)*4+tags[j]] = 5
+			}
+		}
+		output, _ := nvidia_inferenceserver.NewNumericTensor("logits", []int64{batchSize, seqLength, 4}, logits)
+		return []*nvidia_inferenceserver.InferTensor{output}
+	}
+	decoder := bert.NewTokenClassificationDecoder("logits", map[int]string{0: "O", 1: "B-LOC", 2: "I-LOC", 3: "S-LOC"})
+	testBertHeadInfer(t, outputFunc, []string{"logits"}, decoder,
+		func(s *bert.ModelService) { s.SetMaxSeqLength(10).SetPaddingLongest().SetTokenizerReturnPosInfo() },
+		func(s *bert.ModelService) ([]interface{}, error) {
+			return s.ModelInfer([]string{"我在北京上海"}, tModelName, tModelVersion, 1*time.Second)
+		},
+		func(result []interface{}) error {
+			entities := result[0].([]bert.Entity)
+			// I-LOC after S-LOC starts a new entity
+			if len(entities) != 3 || entities[0].Text != "北京" || entities[0].Offsets != (bert.OffsetsType{Start: 2, End: 4}) ||
+				entities[1].Text != "上" || entities[2].Text != "海" || entities[0].Label != "LOC" {
+				return fmt.Errorf("unexpected entities: %+v", entities)
+			}
+			return nil
+		})
+}
+
+func TestBertTokenClassificationDecoderOverflow(t *testing.T) {
+	// windows: [CLS] 我 在 北 京 [SEP] and [CLS] 京 上 海 [SEP], the first token of every window is B-LOC
+	outputFunc := func(batchSize, seqLength int64) []*nvidia_inferenceserver.InferTensor {
+		logits := make([]float32, batchSize*seqLength*2)
+		for i := int64(0); i < batchSize; i++ {
+			logits[(i*seqLength+1)*2+1] = 5
+		}
+		output, _ := nvidia_inferenceserver.NewNumericTensor("logits", []int64{batchSize, seqLength, 2}, logits)
+		return []*nvidia_inferenceserver.InferTensor{output}
+	}
+	decoder := bert.NewTokenClassificationDecoder("logits", map[int]string{0: "O", 1: "B-LOC"})
+	testBertHeadInfer(t, outputFunc, []string{"logits"}, decoder,
+		func(s *bert.ModelService) { s.SetMaxSeqLength(6).SetOverflowStride(1).SetTokenizerReturnPosInfo() },
+		func(s *bert.ModelService) ([]interface{}, error) {
+			return s.ModelInfer([]string{"我在北京上海"}, tModelName, tModelVersion, 1*time.Second)
+		},
+		func(result []interface{}) error {
+			windowResults := result[0].(bert.WindowResults)
+			if len(windowResults) != 2 {
+				return fmt.Errorf("unexpected window results: %+v", windowResults)
+			}
+			// token range of entity indexes tokens of the whole Input
+			for i, expected := range []bert.Entity{
+				{Label: "LOC", Text: "我", TokenStart: 0, TokenEnd: 1, Offsets: bert.OffsetsType{Start: 0, End: 1}},
+				{Label: "LOC", Text: "京", TokenStart: 3, TokenEnd: 4, Offsets: bert.OffsetsType{Start: 3, End: 4}},
+			} {
+				entities := windowResults[i].Result.([]bert.Entity)
+				if len(entities) != 1 || entities[0].Text != expected.Text || entities[0].Label != expected.Label ||
+					entities[0].TokenStart != expected.TokenStart || entities[0].TokenEnd != expected.TokenEnd ||
+					entities[0].Offsets != expected.Offsets {
+					return fmt.Errorf("unexpected entities of window %d: %+v", i, entities)
+				}
+			}
+			return nil
+		})
+}
+
+func TestBertQuestionAnsweringDecoder(t *testing.T) {
+	// tokens: [CLS] 哪 里 [SEP] 我 在 北 京 上 班 [SEP], answer is 北京
+	outputFunc := func(batchSize, seqLength int64) []*nvidia_inferenceserver.InferTensor {
+		startLogits := make([]float32, batchSize*seqLength)
+		endLogits := make([]float32, batchSize*seqLength)
+		for i := int64(0); i < batchSize; i++ {
+			startLogits[i*seqLength+6], endLogits[i*seqLength+7] = 3, 4
+			// [CLS] and question are not candidates
+			startLogits[i*seqLength], endLogits[i*seqLength+1] = 10, 10
+		}
+		start, _ := nvidia_inferenceserver.NewNumericTensor("start_logits", []int64{batchSize, seqLength}, startLogits)
+		end, _ := nvidia_inferenceserver.NewNumericTensor("end_logits", []int64{batchSize, seqLength}, endLogits)
+		return []*nvidia_inferenceserver.InferTensor{start, end}
+	}
+	decoder := bert.NewQuestionAnsweringDecoder("start_logits", "end_logits", 4)
+	testBertHeadInfer(t, outputFunc, []string{"start_logits", "end_logits"}, decoder,
+		func(s *bert.ModelService) { s.SetMaxSeqLength(16).SetTokenizerReturnPosInfo() },
+		func(s *bert.ModelService) ([]interface{}, error) {
+			return s.ModelInferPair([]bert.TextPair{{TextA: "哪里", TextB: "我在北京上班"}}, tModelName, tModelVersion, 1*time.Second)
+		},
+		func(result []interface{}) error {
+			span := result[0].(*bert.AnswerSpan)
+			if span.Text != "北京" || span.Offsets != (bert.OffsetsType{Start: 2, End: 4}) || span.Score != 7 {
+				return fmt.Errorf("unexpected answer span: %+v", span)
+			}
+			return nil
+		})
+}
+
+func TestBertEmbeddingDecoder(t *testing.T) {
+	// embedding of token j is [1, j]
+	outputFunc := func(batchSize, seqLength int64) []*nvidia_inferenceserver.InferTensor {
+		hidden := make([]float32, 0, batchSize*seqLength*2)
+		for i := int64(0); i < batchSize; i++ {
+			for j := int64(0); j < seqLength; j++ {
+				hidden = append(hidden, 1, float32(j))
+			}
+		}
+		output, _ := nvidia_inferenceserver.NewNumericTensor("last_hidden_state", []int64{batchSize, seqLength, 2}, hidden)
+		return []*nvidia_inferenceserver.InferTensor{output}
+	}
+	infer := func(s *bert.ModelService) ([]interface{}, error) {
+		return s.ModelInfer([]string{"今天"}, tModelName, tModelVersion, 1*time.Second)
+	}
+	setup := func(s *bert.ModelService) { s.SetMaxSeqLength(8) }
+
+	// mean of [CLS] 今 天 [SEP] with mask: [1, 1.5]
+	testBertHeadInfer(t, outputFunc, []string{"last_hidden_state"},
+		bert.NewEmbeddingDecoder("last_hidden_state", bert.PoolingMean, true), setup, infer,
+		func(result []interface{}) error {
+			embedding := result[0].([]float32)
+			norm := math.Sqrt(3.25)
+			if math.Abs(float64(embedding[0])-1/norm) > 1e-6 || math.Abs(float64(embedding[1])-1.5/norm) > 1e-6 {
+				return fmt.Errorf("unexpected mean embedding: %v", embedding)
+			}
+			return nil
+		})
+	testBertHeadInfer(t, outputFunc, []string{"last_hidden_state"},
+		bert.NewEmbeddingDecoder("last_hidden_state", bert.PoolingCLS, false), setup, infer,
+		func(result []interface{}) error {
+			if embedding := result[0].([]float32); fmt.Sprint(embedding) != "[1 0]" {
+				return fmt.Errorf("unexpected cls embedding: %v", embedding)
+			}
+			return nil
+		})
+}
+
+func TestBertEmbeddingDecoderEmptyMask(t *testing.T) {
+	output, _ := nvidia_inferenceserver.NewNumericTensor("last_hidden_state", []int64{1, 2, 2}, []float32{1, 2, 3, 4})
+	outputTensor, raw := output.GRPCInput()
+	response := &nvidia_inferenceserver.ModelInferResponse{
+		Outputs: []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+			{Name: outputTensor.Name, Datatype: outputTensor.Datatype, Shape: outputTensor.Shape},
+		},
+		RawOutputContents: [][]byte{raw},
+	}
+	// input without tokens has an empty mask, mean pooling keeps zero embedding instead of NaN
+	for _, normalize := range []bool{false, true} {
+		decoder := bert.NewEmbeddingDecoder("last_hidden_state", bert.PoolingMean, normalize)
+		result, err := decoder(response, nil, []*bert.InputObjects{{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if embedding := result[0].([]float32); fmt.Sprint(embedding) != "[0 0]" {
+			t.Fatalf("unexpected embedding of empty mask (normalize: %v): %v", normalize, embedding)
+		}
+	}
+}
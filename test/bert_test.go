@@ -1,16 +1,17 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/valyala/fasthttp"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/sunhailin-Leo/triton-service-go/models/bert"
 	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
 )
 
 const (
@@ -48,9 +49,6 @@ func testGenerateModelInferRequest(batchSize, maxSeqLength int) []*nvidia_infere
 
 // testGenerateModelInferOutputRequest Triton Output
 func testGenerateModelInferOutputRequest(params ...interface{}) []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor {
-	for _, param := range params {
-		println("Param: ", param)
-	}
 	return []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor{
 		{
 			Name: tBertModelOutputProbabilitiesKey,
@@ -66,43 +64,451 @@ func testGenerateModelInferOutputRequest(params ...interface{}) []*nvidia_infere
 	}
 }
 
-// testModerInferCallback infer call back (process model infer data)
-func testModerInferCallback(inferResponse interface{}, params ...interface{}) ([]interface{}, error) {
-	fmt.Println(inferResponse)
-	fmt.Println(params...)
-	return nil, nil
+// testModerInferCallback infer call back (process model infer data), return probability of every batch
+func testModerInferCallback(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+	output, err := inferResult.Output(tBertModelOutputProbabilitiesKey)
+	if err != nil {
+		return nil, err
+	}
+	probability, err := output.AsFloat32()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, output.Shape[0])
+	for i := range result {
+		result[i] = probability[i*2 : i*2+2]
+	}
+	return result, nil
+}
+
+// testBertModelHandler fake bert model, check input tensors and return probability with shape [batch, 2]
+func testBertModelHandler(
+	_ context.Context, request *nvidia_inferenceserver.ModelInferRequest,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	if len(request.Inputs) != 3 {
+		return nil, fmt.Errorf("expect 3 inputs, got %d", len(request.Inputs))
+	}
+	batchSize := request.Inputs[0].Shape[0]
+	probability := make([]float32, 0, batchSize*2)
+	for i := int64(0); i < batchSize; i++ {
+		probability = append(probability, 0.1, 0.9)
+	}
+	output, err := nvidia_inferenceserver.NewNumericTensor(tBertModelOutputProbabilitiesKey, []int64{batchSize, 2}, probability)
+	if err != nil {
+		return nil, err
+	}
+	response := new(nvidia_inferenceserver.ModelInferResponse)
+	outputTensor, raw := output.GRPCInput()
+	response.Outputs = []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+		{Name: outputTensor.Name, Datatype: outputTensor.Datatype, Shape: outputTensor.Shape},
+	}
+	response.RawOutputContents = [][]byte{raw}
+	return response, nil
 }
 
 func TestBertService(t *testing.T) {
-	vocabPath := "<Your Bert Vocab Path>"
-	maxSeqLen := 48
-	httpAddr := "<HTTP URL>"
-	grpcAddr := "<GRPC URL>"
-	defaultHttpClient := &fasthttp.Client{}
-	defaultGRPCClient, grpcErr := grpc.Dial(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if grpcErr != nil {
-		panic(grpcErr)
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Handler: testBertModelHandler})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
 	}
+	defer grpcConn.Close()
 
 	// Service
 	bertService, initErr := bert.NewModelService(
-		vocabPath, httpAddr, defaultHttpClient, defaultGRPCClient,
-		testGenerateModelInferRequest, testGenerateModelInferOutputRequest, testModerInferCallback)
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testGenerateModelInferOutputRequest,
+		nvidia_inferenceserver.NewInferResultDecoder(testModerInferCallback))
 	if initErr != nil {
-		panic(initErr)
-	}
-	bertService = bertService.SetChineseTokenize().SetMaxSeqLength(maxSeqLen)
-	// infer
-	inferResultV1, inferErr := bertService.ModelInfer(
-		[]string{"<Data>"},
-		"<Model Name>",
-		"<Model Version>",
-		1*time.Second,
-		"params_1",
-		"params_2",
-	)
+		t.Fatal(initErr)
+	}
+	bertService = bertService.SetChineseTokenize().SetMaxSeqLength(16)
+
+	for _, mode := range []string{"http", "http-binary", "grpc"} {
+		bertService.UnsetModelInferWithGRPC().UnsetModelInferWithHTTPBinary()
+		switch mode {
+		case "http-binary":
+			bertService.SetModelInferWithHTTPBinary()
+		case "grpc":
+			bertService.SetModelInferWithGRPC()
+		}
+		inferResult, inferErr := bertService.ModelInfer(
+			[]string{"今天天气很好", "明天会下雨吗"}, tModelName, tModelVersion, 1*time.Second)
+		if inferErr != nil {
+			t.Fatalf("%s infer error: %v", mode, inferErr)
+		}
+		if len(inferResult) != 2 {
+			t.Fatalf("%s expect 2 results, got %v", mode, inferResult)
+		}
+		if probability := inferResult[1].([]float32); probability[0] != 0.1 || probability[1] != 0.9 {
+			t.Fatalf("%s unexpected probability: %v", mode, probability)
+		}
+	}
+}
+
+// testBertInputData data of infer request input, raw contents (GRPC / HTTP binary) or json contents (HTTP)
+func testBertInputData(request *nvidia_inferenceserver.ModelInferRequest, inputName string) ([]int64, error) {
+	for i, input := range request.Inputs {
+		if input.Name != inputName {
+			continue
+		}
+		if len(request.RawInputContents) > i {
+			return nvidia_inferenceserver.DecodeRawContentsToNumeric[int64](input.Datatype, request.RawInputContents[i])
+		}
+		data := input.GetContents().GetInt64Contents()
+		for _, value := range input.GetContents().GetIntContents() {
+			data = append(data, int64(value))
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("input %s not found", inputName)
+}
+
+func TestBertServiceTextPair(t *testing.T) {
+	var segmentIDs []int64
+	handler := func(ctx context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		var err error
+		if segmentIDs, err = testBertInputData(request, tBertModelSegmentIdsKey); err != nil {
+			return nil, err
+		}
+		return testBertModelHandler(ctx, request)
+	}
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Handler: handler})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcConn.Close()
+
+	// callback returns input objects of every batch
+	inputObjectsCallback := func(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+		inputObjects := params[1].([]*bert.InputObjects)
+		result := make([]interface{}, len(inputObjects))
+		for i, inputObject := range inputObjects {
+			result[i] = inputObject
+		}
+		return result, nil
+	}
+	bertService, initErr := bert.NewModelService(
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testGenerateModelInferOutputRequest,
+		nvidia_inferenceserver.NewInferResultDecoder(inputObjectsCallback))
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	// 6 + 6 tokens are truncated longest first (second text on tie) to 4 + 3 tokens: [CLS] A A A A [SEP] B B B [SEP]
+	bertService = bertService.SetChineseTokenize().SetTokenizerReturnPosInfo().SetMaxSeqLength(10)
+	expectedSegmentIDs := []int64{0, 0, 0, 0, 0, 0, 1, 1, 1, 1}
+
+	for _, mode := range []string{"http", "http-binary", "grpc"} {
+		bertService.UnsetModelInferWithGRPC().UnsetModelInferWithHTTPBinary()
+		switch mode {
+		case "http-binary":
+			bertService.SetModelInferWithHTTPBinary()
+		case "grpc":
+			bertService.SetModelInferWithGRPC()
+		}
+		for _, infer := range []func() ([]interface{}, error){
+			func() ([]interface{}, error) {
+				return bertService.ModelInferPair(
+					[]bert.TextPair{{TextA: "今天天气很好", TextB: "明天会下雨吗"}}, tModelName, tModelVersion, 1*time.Second)
+			},
+			func() ([]interface{}, error) {
+				return bertService.ModelInfer(
+					[]string{"今天天气很好" + bert.DataSplitString + "明天会下雨吗"}, tModelName, tModelVersion, 1*time.Second)
+			},
+		} {
+			inferResult, inferErr := infer()
+			if inferErr != nil {
+				t.Fatalf("%s infer error: %v", mode, inferErr)
+			}
+			if fmt.Sprint(segmentIDs) != fmt.Sprint(expectedSegmentIDs) {
+				t.Fatalf("%s expect segment_ids %v, got %v", mode, expectedSegmentIDs, segmentIDs)
+			}
+			inputObject := inferResult[0].(*bert.InputObjects)
+			if inputObject.Input != "今天天气很好" || inputObject.PairInput != "明天会下雨吗" ||
+				inputObject.Tokens[5] != bert.DefaultSEP || inputObject.Tokens[9] != bert.DefaultSEP {
+				t.Fatalf("%s unexpected input object: %+v", mode, inputObject)
+			}
+			if len(inputObject.PosArray) != 4 || len(inputObject.PairPosArray) != 3 || inputObject.PairPosArray[2].Start != 2 {
+				t.Fatalf("%s unexpected offsets: %v / %v", mode, inputObject.PosArray, inputObject.PairPosArray)
+			}
+		}
+	}
+
+	// single text keeps segment_ids 0
+	if _, err = bertService.ModelInfer([]string{"今天天气很好"}, tModelName, tModelVersion, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(segmentIDs) != fmt.Sprint(make([]int64, 10)) {
+		t.Fatalf("expect zero segment_ids, got %v", segmentIDs)
+	}
+}
+
+func TestBertServiceInputTensorNames(t *testing.T) {
+	// huggingface export without token_type_ids
+	config := testModelConfig()
+	config.Input = []*nvidia_inferenceserver.ModelInput{
+		{Name: "attention_mask", DataType: nvidia_inferenceserver.DataType_TYPE_INT64, Dims: []int64{-1}},
+		{Name: "input_ids", DataType: nvidia_inferenceserver.DataType_TYPE_INT64, Dims: []int64{-1}},
+	}
+	var attentionMask []int64
+	maskName := "attention_mask"
+	handler := func(_ context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		if len(request.Inputs) != 2 || request.Inputs[0].Datatype != "INT64" {
+			return nil, fmt.Errorf("unexpected inputs: %v", request.Inputs)
+		}
+		var err error
+		if attentionMask, err = testBertInputData(request, maskName); err != nil {
+			return nil, err
+		}
+		probability, _ := nvidia_inferenceserver.NewNumericTensor(tBertModelOutputProbabilitiesKey, []int64{1, 2}, []float32{0.1, 0.9})
+		outputTensor, raw := probability.GRPCInput()
+		return &nvidia_inferenceserver.ModelInferResponse{
+			Outputs: []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+				{Name: outputTensor.Name, Datatype: outputTensor.Datatype, Shape: outputTensor.Shape},
+			},
+			RawOutputContents: [][]byte{raw},
+		}, nil
+	}
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: config, Handler: handler})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcConn.Close()
+
+	bertService, initErr := bert.NewModelService(
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testGenerateModelInferOutputRequest,
+		nvidia_inferenceserver.NewInferResultDecoder(testModerInferCallback))
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	bertService = bertService.SetChineseTokenize().SetMaxSeqLength(8)
+	if err = bertService.SetInputTensorsFromModelMeta(tModelName, tModelVersion, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []string{"http", "http-binary", "grpc"} {
+		bertService.UnsetModelInferWithGRPC().UnsetModelInferWithHTTPBinary()
+		switch mode {
+		case "http-binary":
+			bertService.SetModelInferWithHTTPBinary()
+		case "grpc":
+			bertService.SetModelInferWithGRPC()
+		}
+		if _, err = bertService.ModelInfer([]string{"今天天气"}, tModelName, tModelVersion, 1*time.Second); err != nil {
+			t.Fatalf("%s infer error: %v", mode, err)
+		}
+		if fmt.Sprint(attentionMask) != fmt.Sprint([]int64{1, 1, 1, 1, 1, 1, 0, 0}) {
+			t.Fatalf("%s unexpected attention_mask: %v", mode, attentionMask)
+		}
+	}
+
+	// unmapped tensor name
+	maskName = "mask"
+	config = proto.Clone(config).(*nvidia_inferenceserver.ModelConfig)
+	config.Input[0].Name = maskName
+	server.AddModel(&tritontest.Model{Name: tModelName, Config: config, Handler: handler})
+	if err = bertService.SetInputTensorsFromModelMeta(tModelName, tModelVersion, 1*time.Second); err == nil {
+		t.Fatal("expect error of unmapped input tensor")
+	}
+	bertService.SetInputTensorField("mask", bert.FeatureMask)
+	if err = bertService.SetInputTensorsFromModelMeta(tModelName, tModelVersion, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bertService.ModelInfer([]string{"今天"}, tModelName, tModelVersion, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBertServicePadding(t *testing.T) {
+	var shapes [][]int64
+	handler := func(ctx context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		inputIDs, err := testBertInputData(request, tBertModelInputIdsKey)
+		if err != nil {
+			return nil, err
+		}
+		shape := request.Inputs[0].Shape
+		if int64(len(inputIDs)) != shape[0]*shape[1] {
+			return nil, fmt.Errorf("input_ids length %d does not match shape %v", len(inputIDs), shape)
+		}
+		shapes = append(shapes, shape)
+		return testBertModelHandler(ctx, request)
+	}
+	config := testModelConfig()
+	config.Input = []*nvidia_inferenceserver.ModelInput{
+		{Name: tBertModelSegmentIdsKey, DataType: nvidia_inferenceserver.DataType_TYPE_INT32, Dims: []int64{12}},
+		{Name: tBertModelInputIdsKey, DataType: nvidia_inferenceserver.DataType_TYPE_INT32, Dims: []int64{12}},
+		{Name: tBertModelInputMaskKey, DataType: nvidia_inferenceserver.DataType_TYPE_INT32, Dims: []int64{12}},
+	}
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: config, Handler: handler})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcConn.Close()
+
+	// callback returns input of every batch item
+	inputCallback := func(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+		inputObjects := params[1].([]*bert.InputObjects)
+		result := make([]interface{}, len(inputObjects))
+		for i, inputObject := range inputObjects {
+			result[i] = inputObject.Input
+		}
+		return result, nil
+	}
+	bertService, initErr := bert.NewModelService(
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testGenerateModelInferOutputRequest,
+		nvidia_inferenceserver.NewInferResultDecoder(inputCallback))
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	bertService = bertService.SetChineseTokenize().SetMaxSeqLength(16)
+	// sequence length with CLS/SEP: 12, 4, 8, 4
+	inferData := []string{"明天会下雨吗还是晴天", "今天", "今天天气很好", "明天"}
+
+	for _, testCase := range []struct {
+		name     string
+		setup    func(*bert.ModelService)
+		expected string
+	}{
+		{name: "max-length", setup: func(s *bert.ModelService) { s.UnsetPadding() }, expected: "[[4 16]]"},
+		{name: "longest", setup: func(s *bert.ModelService) { s.SetPaddingLongest() }, expected: "[[4 12]]"},
+		{name: "multiple", setup: func(s *bert.ModelService) { s.SetPaddingMultiple(5) }, expected: "[[4 15]]"},
+		{name: "bucket", setup: func(s *bert.ModelService) { s.SetPaddingBuckets(10, 6) }, expected: "[[2 6] [1 10] [1 16]]"},
+	} {
+		for _, mode := range []string{"http", "http-binary", "grpc"} {
+			bertService.UnsetModelInferWithGRPC().UnsetModelInferWithHTTPBinary()
+			switch mode {
+			case "http-binary":
+				bertService.SetModelInferWithHTTPBinary()
+			case "grpc":
+				bertService.SetModelInferWithGRPC()
+			}
+			testCase.setup(bertService)
+			shapes = nil
+			inferResult, inferErr := bertService.ModelInfer(inferData, tModelName, tModelVersion, 1*time.Second)
+			if inferErr != nil {
+				t.Fatalf("%s %s infer error: %v", testCase.name, mode, inferErr)
+			}
+			if fmt.Sprint(shapes) != testCase.expected {
+				t.Fatalf("%s %s expect shapes %s, got %v", testCase.name, mode, testCase.expected, shapes)
+			}
+			if fmt.Sprint(inferResult) != fmt.Sprint(inferData) {
+				t.Fatalf("%s %s results are not in input order: %v", testCase.name, mode, inferResult)
+			}
+		}
+	}
+
+	// fixed dims of model config disable dynamic padding
+	if err = bertService.SetSeqLengthFromModelConfig(tModelName, tModelVersion, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	shapes = nil
+	if _, err = bertService.SetPaddingLongest().ModelInfer(inferData[1:], tModelName, tModelVersion, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(shapes) != "[[3 12]]" {
+		t.Fatalf("expect shapes of model config dims, got %v", shapes)
+	}
+
+	// max sequence length set after model config overrides fixed dims
+	shapes = nil
+	inferResult, inferErr := bertService.SetMaxSeqLength(8).ModelInfer(inferData, tModelName, tModelVersion, 1*time.Second)
+	if inferErr != nil {
+		t.Fatal(inferErr)
+	}
+	if fmt.Sprint(shapes) != "[[4 8]]" {
+		t.Fatalf("expect shapes of max sequence length, got %v", shapes)
+	}
+	if inferResult[0] != inferData[0] {
+		t.Fatalf("unexpected infer result: %v", inferResult)
+	}
+}
+
+func TestBertServiceOverflow(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Handler: testBertModelHandler})
+	grpcConn, err := server.DialGRPC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcConn.Close()
+
+	// callback returns logits [window index, 1] and span logits peaking at "八" (start) / "九" (end)
+	isSpan := false
+	windowCallback := func(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+		inputObjects := params[1].([]*bert.InputObjects)
+		result := make([]interface{}, len(inputObjects))
+		for i, inputObject := range inputObjects {
+			if !isSpan {
+				result[i] = []float32{float32(inputObject.Window.WindowIndex), 1}
+				continue
+			}
+			logits := bert.SpanLogits{Start: make([]float32, len(inputObject.Tokens)), End: make([]float32, len(inputObject.Tokens))}
+			for pos, token := range inputObject.Tokens {
+				switch token {
+				case "八":
+					logits.Start[pos] = 5
+				case "九":
+					logits.End[pos] = 5
+				}
+			}
+			result[i] = logits
+		}
+		return result, nil
+	}
+	bertService, initErr := bert.NewModelService(
+		"bert-chinese-vocab.txt", server.HTTPAddr(), &fasthttp.Client{}, grpcConn,
+		testGenerateModelInferRequest, testGenerateModelInferOutputRequest,
+		nvidia_inferenceserver.NewInferResultDecoder(windowCallback))
+	if initErr != nil {
+		t.Fatal(initErr)
+	}
+	// 10 tokens, 6 tokens per window with 2 tokens overlap: [0, 6) [4, 10)
+	bertService = bertService.SetChineseTokenize().SetMaxSeqLength(8).SetOverflowStride(2).SetModelInferWithGRPC()
+	document := "一二三四五六七八九十"
+
+	inferResult, inferErr := bertService.ModelInfer([]string{document, "短句"}, tModelName, tModelVersion, 1*time.Second)
 	if inferErr != nil {
-		panic(inferErr)
+		t.Fatal(inferErr)
+	}
+	windows := inferResult[0].(bert.WindowResults)
+	if len(windows) != 2 || len(inferResult[1].(bert.WindowResults)) != 1 {
+		t.Fatalf("unexpected windows: %v", inferResult)
+	}
+	if window := windows[1].Input.Window; window.InputIndex != 0 || window.TokenStart != 4 || window.TokenEnd != 10 ||
+		window.Offsets != (bert.OffsetsType{Start: 4, End: 10}) || windows[1].Input.Tokens[1] != "五" {
+		t.Fatalf("unexpected window: %+v", window)
+	}
+	if meanLogits, _ := windows.MeanLogits(); fmt.Sprint(meanLogits) != "[0.5 1]" {
+		t.Fatalf("unexpected mean logits: %v", meanLogits)
+	}
+	if maxLogits, _ := windows.MaxLogits(); fmt.Sprint(maxLogits) != "[1 1]" {
+		t.Fatalf("unexpected max logits: %v", maxLogits)
+	}
+
+	// question / context pair, context is windowed: [0, 5) [4, 9) [8, 10)
+	isSpan = true
+	bertService.SetMaxSeqLength(10).SetOverflowStride(1)
+	inferResult, inferErr = bertService.ModelInferPair(
+		[]bert.TextPair{{TextA: "问题", TextB: document}}, tModelName, tModelVersion, 1*time.Second)
+	if inferErr != nil {
+		t.Fatal(inferErr)
+	}
+	windows = inferResult[0].(bert.WindowResults)
+	if len(windows) != 3 || windows[1].Input.Window.FeatureStart != 4 || windows[1].Input.Window.Offsets.Start != 4 {
+		t.Fatalf("unexpected pair windows: %+v", windows[1].Input.Window)
+	}
+	span, spanErr := windows.BestSpan(4)
+	if spanErr != nil {
+		t.Fatal(spanErr)
+	}
+	if span.Text != "八九" || span.WindowIndex != 1 || span.TokenStart != 7 || span.TokenEnd != 9 || span.Score != 10 {
+		t.Fatalf("unexpected best span: %+v", span)
+	}
+	if _, err = windows.MeanLogits(); err == nil {
+		t.Fatal("expect error of span logits mean")
 	}
-	println(inferResultV1)
 }
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// startReadyServer start http server which answer ready probe by ready flag and count infer requests
+func startReadyServer(t *testing.T, ready *int32, inferCount *int64) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fasthttp.Server{Handler: func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) == "/v2/health/ready" && atomic.LoadInt32(ready) == 0 {
+			ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+			return
+		}
+		if string(ctx.Path()) != "/v2/health/ready" {
+			atomic.AddInt64(inferCount, 1)
+		}
+		ctx.SetBodyString(`{}`)
+	}}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return listener.Addr().String()
+}
+
+func TestTritonClientPoolFailover(t *testing.T) {
+	readyA, readyB := int32(1), int32(1)
+	var countA, countB int64
+	clientA := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(startReadyServer(t, &readyA, &countA), &fasthttp.Client{})
+	clientB := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(startReadyServer(t, &readyB, &countB), &fasthttp.Client{})
+	pool, err := nvidia_inferenceserver.NewTritonClientPool(
+		[]*nvidia_inferenceserver.TritonClientService{clientA, clientB},
+		nvidia_inferenceserver.WithPoolHealthCheck(0, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+	infer := func() error {
+		_, inferErr := pool.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "bert", "1", decoder)
+		return inferErr
+	}
+	for i := 0; i < 4; i++ {
+		if err = infer(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if countA != 2 || countB != 2 {
+		t.Fatalf("round-robin mismatch: %d %d", countA, countB)
+	}
+
+	atomic.StoreInt32(&readyA, 0)
+	pool.CheckHealth(context.Background())
+	if pool.HealthyCount() != 1 {
+		t.Fatalf("unhealthy endpoint is not ejected: %v", pool.Endpoints())
+	}
+	for i := 0; i < 2; i++ {
+		if err = infer(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if countA != 2 || countB != 4 {
+		t.Fatalf("failover mismatch: %d %d", countA, countB)
+	}
+
+	atomic.StoreInt32(&readyB, 0)
+	pool.CheckHealth(context.Background())
+	if err = infer(); err != nvidia_inferenceserver.ErrNoHealthyEndpoint {
+		t.Fatalf("expect ErrNoHealthyEndpoint, got %v", err)
+	}
+
+	atomic.StoreInt32(&readyA, 1)
+	pool.CheckHealth(context.Background())
+	if pool.HealthyCount() != 1 || !pool.Endpoints()[0].Healthy {
+		t.Fatalf("recovered endpoint is not re-admitted: %v", pool.Endpoints())
+	}
+}
+
+// closedAddr address without listener, connection is refused
+func closedAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	return addr
+}
+
+func TestTritonClientPoolTransportFailover(t *testing.T) {
+	ready := int32(1)
+	var count int64
+	liveAddr := startReadyServer(t, &ready, &count)
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+	newPool := func(opts ...nvidia_inferenceserver.TritonClientPoolOption) *nvidia_inferenceserver.TritonClientPool {
+		// the dead endpoint is picked first by round-robin
+		pool, err := nvidia_inferenceserver.NewTritonClientPool([]*nvidia_inferenceserver.TritonClientService{
+			nvidia_inferenceserver.NewTritonClientWithOnlyHttp(closedAddr(t), &fasthttp.Client{}),
+			nvidia_inferenceserver.NewTritonClientWithOnlyHttp(liveAddr, &fasthttp.Client{}),
+		}, append(opts, nvidia_inferenceserver.WithPoolHealthCheck(0, time.Second))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(pool.Close)
+		return pool
+	}
+	infer := func(pool *nvidia_inferenceserver.TritonClientPool) error {
+		_, inferErr := pool.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "bert", "1", decoder)
+		return inferErr
+	}
+
+	// without retry, transport error is returned and dead endpoint is ejected
+	pool := newPool()
+	var tritonErr *nvidia_inferenceserver.TritonError
+	if err := infer(pool); !errors.As(err, &tritonErr) || tritonErr.StatusCode != 0 {
+		t.Fatalf("expect transport error, got %v", err)
+	}
+	if pool.HealthyCount() != 1 || pool.Endpoints()[0].Healthy {
+		t.Fatalf("dead endpoint is not ejected: %v", pool.Endpoints())
+	}
+	if err := infer(pool); err != nil || atomic.LoadInt64(&count) != 1 {
+		t.Fatalf("infer is not sent to live endpoint: %v %d", err, count)
+	}
+	// probe keeps dead endpoint ejected
+	pool.CheckHealth(context.Background())
+	if pool.HealthyCount() != 1 {
+		t.Fatalf("dead endpoint is re-admitted: %v", pool.Endpoints())
+	}
+
+	// infer is retried on the next healthy endpoint only if RetryInference is true
+	retryPolicy := nvidia_inferenceserver.DefaultRetryPolicy()
+	if err := infer(newPool(nvidia_inferenceserver.WithPoolRetryPolicy(retryPolicy))); err == nil {
+		t.Fatal("infer is retried without RetryInference")
+	}
+	retryPolicy.RetryInference = true
+	pool = newPool(nvidia_inferenceserver.WithPoolRetryPolicy(retryPolicy))
+	if err := infer(pool); err != nil || atomic.LoadInt64(&count) != 2 || pool.HealthyCount() != 1 {
+		t.Fatalf("infer is not retried on live endpoint: %v %d %v", err, count, pool.Endpoints())
+	}
+
+	// idempotent call is retried, Do is not
+	pool = newPool(nvidia_inferenceserver.WithPoolRetryPolicy(nvidia_inferenceserver.DefaultRetryPolicy()))
+	checkReady := func(client *nvidia_inferenceserver.TritonClientService) error {
+		_, readyErr := client.CheckServerReadyCtx(context.Background())
+		return readyErr
+	}
+	if err := pool.DoIdempotent(context.Background(), checkReady); err != nil || pool.HealthyCount() != 1 {
+		t.Fatalf("idempotent call is not retried on live endpoint: %v %v", err, pool.Endpoints())
+	}
+	pool = newPool(nvidia_inferenceserver.WithPoolRetryPolicy(nvidia_inferenceserver.DefaultRetryPolicy()))
+	if err := pool.Do(checkReady); err == nil || pool.HealthyCount() != 1 {
+		t.Fatalf("expect Do error and ejection, got %v %v", err, pool.Endpoints())
+	}
+
+	// GRPC UNAVAILABLE ejects endpoint
+	conn, err := grpc.Dial(closedAddr(t), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	grpcPool, err := nvidia_inferenceserver.NewTritonClientPool(
+		[]*nvidia_inferenceserver.TritonClientService{nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(conn)},
+		nvidia_inferenceserver.WithPoolHealthCheck(0, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcPool.Close()
+	if err = grpcPool.Do(checkReady); err == nil || grpcPool.HealthyCount() != 0 {
+		t.Fatalf("expect GRPC error and ejection, got %v %v", err, grpcPool.Endpoints())
+	}
+	if err = grpcPool.Do(checkReady); err != nvidia_inferenceserver.ErrNoHealthyEndpoint {
+		t.Fatalf("expect ErrNoHealthyEndpoint, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+type errorGRPCServer struct {
+	nvidia_inferenceserver.UnimplementedGRPCInferenceServiceServer
+}
+
+func (s *errorGRPCServer) ModelReady(
+	_ context.Context, req *nvidia_inferenceserver.ModelReadyRequest,
+) (*nvidia_inferenceserver.ModelReadyResponse, error) {
+	return nil, status.Error(codes.NotFound, "Request for unknown model: '"+req.Name+"' is not found")
+}
+
+func TestHTTPTritonError(t *testing.T) {
+	serverURL := startFastHTTPServer(t, func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Path()) {
+		case "/v2/models/unknown/versions/1/infer":
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			ctx.SetBodyString(`{"error":"Request for unknown model: 'unknown' is not found"}`)
+		case "/v2/models/bert/versions/1/infer":
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			ctx.SetBodyString(`{"error":"unexpected shape for input 'input_ids'"}`)
+		case "/v2/models/gpt/versions/1/infer":
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			ctx.SetBodyString(`{"error":"input 'attention_mask' not found for model 'gpt'"}`)
+		case "/v2/models/bert/versions/1/ready":
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+		case "/v2/models/slow/versions/1/infer":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			ctx.SetStatusCode(fasthttp.StatusNotFound)
+		}
+	})
+	client := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(serverURL, &fasthttp.Client{})
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+
+	_, err := client.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "unknown", "1", decoder)
+	var tritonErr *nvidia_inferenceserver.TritonError
+	if !errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) || !errors.As(err, &tritonErr) {
+		t.Fatalf("expect ErrModelNotFound, got %v", err)
+	}
+	if tritonErr.StatusCode != 400 || tritonErr.ModelName != "unknown" || tritonErr.Operation != "ModelInfer" {
+		t.Fatalf("unexpected triton error: %+v", tritonErr)
+	}
+	if err.Error() != "[HTTP]code: 400; error: Request for unknown model: 'unknown' is not found" {
+		t.Fatalf("unexpected error string: %s", err.Error())
+	}
+
+	_, err = client.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "bert", "1", decoder)
+	if !errors.Is(err, nvidia_inferenceserver.ErrInvalidInput) || errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) {
+		t.Fatalf("expect ErrInvalidInput, got %v", err)
+	}
+	_, err = client.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "gpt", "1", decoder)
+	if !errors.Is(err, nvidia_inferenceserver.ErrInvalidInput) || errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) {
+		t.Fatalf("expect ErrInvalidInput of missing input, got %v", err)
+	}
+	// 404 of wrong URL path is not an unknown model
+	_, err = client.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "bert", "2", decoder)
+	if err == nil || errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) {
+		t.Fatalf("expect error which is not ErrModelNotFound, got %v", err)
+	}
+
+	if _, err = client.CheckModelReady("bert", "1", time.Second); !errors.Is(err, nvidia_inferenceserver.ErrModelNotReady) {
+		t.Fatalf("expect ErrModelNotReady, got %v", err)
+	}
+
+	_, err = client.ModelHTTPInfer([]byte(`{}`), "slow", "1", 50*time.Millisecond, decoder)
+	if !errors.Is(err, nvidia_inferenceserver.ErrTimeout) {
+		t.Fatalf("expect ErrTimeout, got %v", err)
+	}
+}
+
+func TestGRPCTritonError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	nvidia_inferenceserver.RegisterGRPCInferenceServiceServer(server, &errorGRPCServer{})
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(conn)
+	defer client.ShutdownTritonConnection()
+
+	_, err = client.CheckModelReady("unknown", "1", time.Second)
+	var tritonErr *nvidia_inferenceserver.TritonError
+	if !errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) || !errors.As(err, &tritonErr) {
+		t.Fatalf("expect ErrModelNotFound, got %v", err)
+	}
+	if tritonErr.GRPCCode != codes.NotFound || tritonErr.ModelName != "unknown" {
+		t.Fatalf("unexpected triton error: %+v", tritonErr)
+	}
+	if _, err = client.ServerMetadata(time.Second); status.Code(errors.Unwrap(err)) != codes.Unimplemented {
+		t.Fatalf("expect UNIMPLEMENTED, got %v", err)
+	}
+}
+
+func TestTritonErrorModelMessages(t *testing.T) {
+	notFound, notReady := nvidia_inferenceserver.ErrModelNotFound, nvidia_inferenceserver.ErrModelNotReady
+	for message, expectErr := range map[string]error{
+		"Request for unknown model: 'bert' is not found":                    notFound,
+		"Request for unknown model: 'bert' version 2 is not found":          notFound,
+		"Request for unknown model: 'bert' version 1 is not at ready state": notReady,
+		"Request for unknown model: 'bert' has no available versions":       notReady,
+		"Request for unknown model: 'bert' is not ready":                    notReady,
+	} {
+		for _, tritonErr := range []*nvidia_inferenceserver.TritonError{
+			{Transport: nvidia_inferenceserver.TransportHTTP, StatusCode: fasthttp.StatusBadRequest, Message: message},
+			{Transport: nvidia_inferenceserver.TransportGRPC, GRPCCode: codes.Unavailable, Message: message},
+		} {
+			isNotFound, isNotReady := errors.Is(tritonErr, notFound), errors.Is(tritonErr, notReady)
+			if isNotFound == isNotReady || !errors.Is(tritonErr, expectErr) {
+				t.Fatalf("%s %q expect only %v, got not found %v, not ready %v",
+					tritonErr.Transport, message, expectErr, isNotFound, isNotReady)
+			}
+			if errors.Is(tritonErr, nvidia_inferenceserver.ErrInvalidInput) {
+				t.Fatalf("%s %q expect not ErrInvalidInput", tritonErr.Transport, message)
+			}
+		}
+	}
+}
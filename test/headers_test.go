@@ -0,0 +1,152 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+// headerRecorder record headers (incoming metadata) of every infer request received by fake model
+type headerRecorder struct {
+	lock    sync.Mutex
+	headers []metadata.MD
+}
+
+func (r *headerRecorder) handler(
+	ctx context.Context, _ *nvidia_inferenceserver.ModelInferRequest,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.lock.Lock()
+	r.headers = append(r.headers, md)
+	r.lock.Unlock()
+	return &nvidia_inferenceserver.ModelInferResponse{}, nil
+}
+
+// last header value of key in the last request
+func (r *headerRecorder) last(key string) string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.headers) == 0 {
+		return ""
+	}
+	values := r.headers[len(r.headers)-1].Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// headerInferFuncs infer with http binary, grpc and grpc stream
+func headerInferFuncs(
+	httpClient, grpcClient *nvidia_inferenceserver.TritonClientService,
+) map[string]func(ctx context.Context) error {
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+	return map[string]func(ctx context.Context) error{
+		"http": func(ctx context.Context) error {
+			_, inferErr := httpClient.ModelHTTPBinaryInferCtx(ctx, nil, nil, nil, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+		"grpc": func(ctx context.Context) error {
+			_, inferErr := grpcClient.ModelGRPCInferCtx(ctx, nil, nil, nil, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+		"grpc-stream": func(ctx context.Context) error {
+			session, sessionErr := grpcClient.NewModelStreamInferSession(ctx, nil)
+			if sessionErr != nil {
+				return sessionErr
+			}
+			defer session.Close()
+			if sendErr := session.Send(&nvidia_inferenceserver.ModelInferRequest{
+				ModelName: tModelName, ModelVersion: tModelVersion,
+			}); sendErr != nil {
+				return sendErr
+			}
+			select {
+			case result := <-session.Responses():
+				return result.Err
+			case <-time.After(time.Second):
+				return errors.New("wait stream response timeout")
+			}
+		},
+	}
+}
+
+func TestHeaderProvider(t *testing.T) {
+	recorder := new(headerRecorder)
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Handler: recorder.handler})
+
+	var refreshCount int
+	tokenProvider := nvidia_inferenceserver.NewBearerTokenProvider(
+		func(_ context.Context) (string, time.Time, error) {
+			refreshCount++
+			// token enters refresh window (1 hour before expiry) 100ms later
+			return fmt.Sprintf("token-%d", refreshCount), time.Now().Add(time.Hour + 100*time.Millisecond), nil
+		}, time.Hour)
+	opt := nvidia_inferenceserver.WithHeaderProvider(
+		nvidia_inferenceserver.StaticHeaderProvider{"X-Tenant": "tenant-1"}, tokenProvider)
+	grpcClient, err := server.NewGRPCClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcClient.ShutdownTritonConnection()
+	httpClient := server.NewHTTPClient(opt)
+
+	ctx := context.Background()
+	for name, infer := range headerInferFuncs(httpClient, grpcClient) {
+		if err = infer(ctx); err != nil {
+			t.Fatalf("%s infer error: %v", name, err)
+		}
+		if recorder.last("x-tenant") != "tenant-1" || recorder.last("authorization") != "Bearer token-1" {
+			t.Fatalf("%s headers are not injected: %v", name, recorder.headers[len(recorder.headers)-1])
+		}
+		// per-call headers override provider headers
+		if err = infer(nvidia_inferenceserver.WithRequestHeaders(ctx, map[string]string{"X-Tenant": "tenant-2"})); err != nil {
+			t.Fatalf("%s infer error: %v", name, err)
+		}
+		if recorder.last("x-tenant") != "tenant-2" || recorder.last("authorization") != "Bearer token-1" {
+			t.Fatalf("%s per-call headers do not override: %v", name, recorder.headers[len(recorder.headers)-1])
+		}
+	}
+	if refreshCount != 1 {
+		t.Fatalf("expect token refreshed once, got %d", refreshCount)
+	}
+
+	// token is refreshed before it expires
+	time.Sleep(150 * time.Millisecond)
+	if err = headerInferFuncs(httpClient, grpcClient)["grpc"](ctx); err != nil {
+		t.Fatal(err)
+	}
+	if refreshCount != 2 || recorder.last("authorization") != "Bearer token-2" {
+		t.Fatalf("expect refreshed token-2, got %d %s", refreshCount, recorder.last("authorization"))
+	}
+}
+
+func TestHeaderProviderError(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	providerErr := errors.New("token service unavailable")
+	tokenProvider := nvidia_inferenceserver.NewBearerTokenProvider(
+		func(_ context.Context) (string, time.Time, error) { return "", time.Time{}, providerErr }, time.Minute)
+	opt := nvidia_inferenceserver.WithHeaderProvider(tokenProvider)
+	grpcClient, err := server.NewGRPCClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer grpcClient.ShutdownTritonConnection()
+
+	for name, infer := range headerInferFuncs(server.NewHTTPClient(opt), grpcClient) {
+		if err = infer(context.Background()); err == nil {
+			t.Fatalf("%s expect provider error", name)
+		}
+	}
+	if server.InferCount(tModelName) != 0 {
+		t.Fatalf("expect no request reaches server, got %d", server.InferCount(tModelName))
+	}
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+func TestEncodeHTTPBinaryInferRequest(t *testing.T) {
+	rawInput := make([]byte, 8)
+	binary.LittleEndian.PutUint32(rawInput, 101)
+	binary.LittleEndian.PutUint32(rawInput[4:], 102)
+	body, headerLength, err := nvidia_inferenceserver.EncodeHTTPBinaryInferRequest(&nvidia_inferenceserver.ModelInferRequest{
+		Id: "req-1",
+		Inputs: []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor{
+			{Name: "input_ids", Datatype: "INT32", Shape: []int64{1, 2}},
+			{Name: "input_mask", Datatype: "INT32", Shape: []int64{1, 2},
+				Contents: &nvidia_inferenceserver.InferTensorContents{IntContents: []int32{1, 1}}},
+		},
+		Outputs:          []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor{{Name: "logits"}},
+		RawInputContents: [][]byte{rawInput},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body[headerLength:], rawInput) {
+		t.Fatalf("binary part mismatch: %v", body[headerLength:])
+	}
+	header := new(nvidia_inferenceserver.InferRequestHTTPObj)
+	if err = json.Unmarshal(body[:headerLength], header); err != nil {
+		t.Fatal(err)
+	}
+	if header.ID != "req-1" || header.Inputs[0].Parameters["binary_data_size"].(float64) != 8 {
+		t.Fatalf("unexpected json header: %s", body[:headerLength])
+	}
+	if header.Inputs[1].Data == nil || header.Outputs[0].Parameters["binary_data"] != true {
+		t.Fatalf("unexpected json header: %s", body[:headerLength])
+	}
+}
+
+func TestDecodeHTTPBinaryInferResponse(t *testing.T) {
+	rawOutput := make([]byte, 8)
+	binary.LittleEndian.PutUint32(rawOutput, math.Float32bits(0.25))
+	binary.LittleEndian.PutUint32(rawOutput[4:], math.Float32bits(0.75))
+	header := []byte(`{"model_name":"bert","model_version":"1","id":"req-1","outputs":[` +
+		`{"name":"labels","datatype":"INT64","shape":[1],"data":[3]},` +
+		`{"name":"probability","datatype":"FP32","shape":[1,2],"parameters":{"binary_data_size":8}}]}`)
+	response, err := nvidia_inferenceserver.DecodeHTTPBinaryInferResponse(append(header, rawOutput...), len(header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Id != "req-1" || len(response.Outputs) != 2 || len(response.RawOutputContents) != 2 {
+		t.Fatalf("unexpected response: %v", response)
+	}
+	if response.Outputs[0].Contents.Int64Contents[0] != 3 {
+		t.Fatalf("unexpected json output: %v", response.Outputs[0])
+	}
+	contents, err := nvidia_inferenceserver.RawContentsToInferTensorContents("FP32", response.RawOutputContents[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contents.Fp32Contents[0] != 0.25 || contents.Fp32Contents[1] != 0.75 {
+		t.Fatalf("unexpected binary output: %v", contents.Fp32Contents)
+	}
+}
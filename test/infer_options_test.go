@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+func TestInferOptions(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		requests []*nvidia_inferenceserver.ModelInferRequest
+	)
+	server := startFakeTriton(t, &tritontest.Model{
+		Name: tModelName,
+		Handler: func(_ context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+			lock.Lock()
+			requests = append(requests, request)
+			lock.Unlock()
+			return &nvidia_inferenceserver.ModelInferResponse{}, nil
+		},
+	})
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+
+	options := &nvidia_inferenceserver.InferOptions{
+		RequestID: "request-1",
+		Priority:  2,
+		Timeout:   50 * time.Millisecond,
+		Parameters: map[string]*nvidia_inferenceserver.InferParameter{
+			"custom": {ParameterChoice: &nvidia_inferenceserver.InferParameter_StringParam{StringParam: "value"}},
+		},
+	}
+	var responseID string
+	decoder := nvidia_inferenceserver.NewInferResultDecoder(
+		func(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			responseID = inferResult.ID
+			return nil, nil
+		})
+	inputIds, _ := nvidia_inferenceserver.NewNumericTensor("input_ids", []int64{1, 2}, []int32{101, 102})
+	inputs, rawInputs := nvidia_inferenceserver.BuildGRPCInferInputs(inputIds)
+	requestBody, _, err := nvidia_inferenceserver.BuildHTTPInferRequestBody(
+		[]*nvidia_inferenceserver.InferTensor{inputIds}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for name, infer := range map[string]func() error{
+		"grpc": func() error {
+			_, inferErr := grpcClient.ModelGRPCInferWithOptionsCtx(
+				ctx, inputs, nil, rawInputs, tModelName, tModelVersion, options, decoder)
+			return inferErr
+		},
+		"http": func() error {
+			_, inferErr := httpClient.ModelHTTPInferWithOptionsCtx(
+				ctx, requestBody, tModelName, tModelVersion, options, decoder)
+			return inferErr
+		},
+		"http-binary": func() error {
+			_, inferErr := httpClient.ModelHTTPBinaryInferWithOptionsCtx(
+				ctx, inputs, nil, rawInputs, tModelName, tModelVersion, options, decoder)
+			return inferErr
+		},
+	} {
+		responseID, requests = "", nil
+		if err = infer(); err != nil {
+			t.Fatalf("%s infer error: %v", name, err)
+		}
+		if responseID != options.RequestID {
+			t.Fatalf("%s expect response id %s, got %s", name, options.RequestID, responseID)
+		}
+		request := requests[0]
+		if request.Id != options.RequestID ||
+			request.Parameters[nvidia_inferenceserver.InferPriorityParamKey].GetInt64Param() != 2 ||
+			request.Parameters[nvidia_inferenceserver.InferTimeoutParamKey].GetInt64Param() != 50000 ||
+			request.Parameters["custom"].GetStringParam() != "value" {
+			t.Fatalf("%s unexpected request: %v", name, request)
+		}
+		if len(request.Inputs) != 1 || request.Inputs[0].Name != "input_ids" {
+			t.Fatalf("%s unexpected request inputs: %v", name, request.Inputs)
+		}
+	}
+
+	// nil options is the same as infer without options
+	if _, err = grpcClient.ModelGRPCInferWithOptionsCtx(
+		ctx, inputs, nil, rawInputs, tModelName, tModelVersion, nil, decoder); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests[1].Parameters) != 0 || requests[1].Id != "" {
+		t.Fatalf("unexpected request of nil options: %v", requests[1])
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+func TestInferResultFromGRPC(t *testing.T) {
+	tensor, _ := nvidia_inferenceserver.NewNumericTensorWithDataType("logits", "FP16", []int64{1, 2}, []float32{0.5, -1})
+	result, err := nvidia_inferenceserver.NewInferResult(&nvidia_inferenceserver.ModelInferResponse{
+		Id: "req-1",
+		Outputs: []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+			{Name: "logits", Datatype: "FP16", Shape: []int64{1, 2}},
+			{Name: "label", Datatype: "BYTES", Shape: []int64{1},
+				Contents: &nvidia_inferenceserver.InferTensorContents{BytesContents: [][]byte{[]byte("POSITIVE")}}},
+		},
+		RawOutputContents: [][]byte{tensor.Raw, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logits, _ := result.Output("logits")
+	if data, err := logits.AsFloat32(); err != nil || data[0] != 0.5 || data[1] != -1 {
+		t.Fatalf("unexpected logits: %v %v", data, err)
+	}
+	label, _ := result.Output("label")
+	if data, err := label.AsStrings(); err != nil || data[0] != "POSITIVE" {
+		t.Fatalf("unexpected label: %v %v", data, err)
+	}
+	if _, err = label.AsInt64(); err == nil {
+		t.Fatal("expect BYTES to numeric error")
+	}
+	if result.ID != "req-1" {
+		t.Fatalf("unexpected id: %s", result.ID)
+	}
+}
+
+func TestInferResultFromHTTPBody(t *testing.T) {
+	rawOutput := make([]byte, 8)
+	binary.LittleEndian.PutUint64(rawOutput, math.Float64bits(0.125))
+	body := append([]byte(`{"model_name":"bert","outputs":[`+
+		`{"name":"score","datatype":"FP64","shape":[1],"parameters":{"binary_data_size":8}},`+
+		`{"name":"ids","datatype":"INT32","shape":[2,1],"data":[[1],[2]]}]}`), rawOutput...)
+	var ids []int64
+	var score []float64
+	decoder := nvidia_inferenceserver.NewInferResultDecoder(
+		func(result *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			idsOutput, _ := result.Output("ids")
+			scoreOutput, _ := result.Output("score")
+			ids, _ = idsOutput.AsInt64()
+			score, _ = scoreOutput.AsFloat64()
+			return nil, nil
+		})
+	if _, err := decoder(body); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[1] != 2 || score[0] != 0.125 {
+		t.Fatalf("unexpected result: %v %v", ids, score)
+	}
+}
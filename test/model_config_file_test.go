@@ -0,0 +1,109 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+const tModelConfigPbtxt string = `
+# bert classifier
+name: "bert"
+platform: "onnxruntime_onnx"
+max_batch_size: 8
+input [
+  {
+    name: "input_ids"
+    data_type: TYPE_INT32
+    dims: [ -1 ]
+  }
+]
+output [
+  {
+    name: "probability"
+    data_type: TYPE_FP32
+    dims: [ 2 ]
+  }
+]
+instance_group [ { count: 2, kind: KIND_GPU } ]
+dynamic_batching { preferred_batch_size: [ 4, 8 ] }
+parameters { key: "max_seq_length" value: { string_value: "128" } }
+`
+
+func TestModelConfigFileReadWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, nvidia_inferenceserver.ModelConfigFileName)
+	if err := os.WriteFile(path, []byte(tModelConfigPbtxt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := nvidia_inferenceserver.ReadModelConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "bert" || config.MaxBatchSize != 8 || config.Input[0].Dims[0] != -1 ||
+		config.InstanceGroup[0].Kind != nvidia_inferenceserver.ModelInstanceGroup_KIND_GPU ||
+		config.Parameters["max_seq_length"].StringValue != "128" {
+		t.Fatalf("unexpected config: %v", config)
+	}
+
+	if err = nvidia_inferenceserver.WriteModelConfigFile(path, config); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(written, []byte("max_batch_size: 8\n")) || !bytes.Contains(written, []byte("  data_type: TYPE_INT32\n")) {
+		t.Fatalf("unexpected canonical text:\n%s", written)
+	}
+	reread, err := nvidia_inferenceserver.ReadModelConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := nvidia_inferenceserver.DiffModelConfig(config, reread); len(diffs) != 0 {
+		t.Fatalf("expect no diff after write and read, got %v", diffs)
+	}
+	rendered, _ := nvidia_inferenceserver.MarshalModelConfigText(reread)
+	if !bytes.Equal(rendered, written) {
+		t.Fatal("canonical text is not stable")
+	}
+
+	if _, err = nvidia_inferenceserver.ParseModelConfigText([]byte("name: ")); err == nil {
+		t.Fatal("expect parse error")
+	}
+}
+
+func TestDiffModelConfig(t *testing.T) {
+	oldConfig, err := nvidia_inferenceserver.ParseModelConfigText([]byte(tModelConfigPbtxt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newConfig, _ := nvidia_inferenceserver.ParseModelConfigText([]byte(tModelConfigPbtxt))
+	newConfig.MaxBatchSize = 16
+	newConfig.Input[0].Dims = append(newConfig.Input[0].Dims, 128)
+	newConfig.InstanceGroup[0].Kind = nvidia_inferenceserver.ModelInstanceGroup_KIND_CPU
+	newConfig.Parameters["max_seq_length"].StringValue = "256"
+	newConfig.Parameters["do_lower_case"] = &nvidia_inferenceserver.ModelParameter{StringValue: "true"}
+	newConfig.SchedulingChoice = nil
+
+	expected := []string{
+		`max_batch_size: 8 -> 16`,
+		`input[0].dims[1]: <unset> -> 128`,
+		`dynamic_batching: {preferred_batch_size: 4 preferred_batch_size: 8} -> <unset>`,
+		`instance_group[0].kind: KIND_GPU -> KIND_CPU`,
+		`parameters["do_lower_case"]: <unset> -> {string_value: "true"}`,
+		`parameters["max_seq_length"].string_value: "128" -> "256"`,
+	}
+	diffs := nvidia_inferenceserver.DiffModelConfig(oldConfig, newConfig)
+	if len(diffs) != len(expected) {
+		t.Fatalf("expect %d diffs, got %v", len(expected), diffs)
+	}
+	for i, diff := range diffs {
+		if diff.String() != expected[i] {
+			t.Fatalf("diff %d: expect %s, got %s", i, expected[i], diff.String())
+		}
+	}
+}
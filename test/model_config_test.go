@@ -0,0 +1,127 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+func TestModelConfigBuilder(t *testing.T) {
+	builder := nvidia_inferenceserver.NewModelConfigBuilder("bert").
+		Platform("onnxruntime_onnx").
+		MaxBatchSize(8).
+		Input("input_ids", nvidia_inferenceserver.DataType_TYPE_INT32, -1).
+		Output("probability", nvidia_inferenceserver.DataType_TYPE_FP32, 2).
+		InstanceGroup(nvidia_inferenceserver.ModelInstanceGroup_KIND_GPU, 2, 0).
+		DynamicBatching(100*time.Microsecond, 4, 8).
+		Parameter("max_seq_length", "128")
+	config, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GetDynamicBatching().MaxQueueDelayMicroseconds != 100 || config.Parameters["max_seq_length"].StringValue != "128" {
+		t.Fatalf("unexpected config: %v", config)
+	}
+
+	jsonBody, err := builder.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jsonBody), `"max_batch_size":8`) || !strings.Contains(string(jsonBody), `"dynamic_batching"`) {
+		t.Fatalf("unexpected json: %s", jsonBody)
+	}
+	textBody, err := builder.Pbtxt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`name:`, `"bert"`, `data_type:`, `TYPE_INT32`, `KIND_GPU`, `preferred_batch_size:`} {
+		if !strings.Contains(string(textBody), expected) {
+			t.Fatalf("pbtxt does not contain %s: %s", expected, textBody)
+		}
+	}
+	// canonical text has fixed layout in field number order
+	if !strings.Contains(string(textBody), "instance_group: {\n  count: 2\n  gpus: 0\n  kind: KIND_GPU\n}\n"+
+		"dynamic_batching: {\n  preferred_batch_size: 4\n  preferred_batch_size: 8\n  max_queue_delay_microseconds: 100\n}\n") {
+		t.Fatalf("unexpected canonical pbtxt layout: %s", textBody)
+	}
+	parsed, err := nvidia_inferenceserver.ParseModelConfigText(textBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(parsed, config) {
+		t.Fatalf("pbtxt does not parse back to the same config: %v", parsed)
+	}
+}
+
+func TestModelConfigBuilderSequenceBatching(t *testing.T) {
+	config, err := nvidia_inferenceserver.NewModelConfigBuilder("stateful").
+		Backend("python").
+		MaxBatchSize(4).
+		Input("input", nvidia_inferenceserver.DataType_TYPE_FP32, 1).
+		Output("output", nvidia_inferenceserver.DataType_TYPE_FP32, 1).
+		SequenceBatching(time.Second).
+		SequenceControl("START", nvidia_inferenceserver.ModelSequenceBatching_Control_CONTROL_SEQUENCE_START, nvidia_inferenceserver.DataType_TYPE_INT32).
+		SequenceControl("CORRID", nvidia_inferenceserver.ModelSequenceBatching_Control_CONTROL_SEQUENCE_CORRID, nvidia_inferenceserver.DataType_TYPE_UINT64).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	controls := config.GetSequenceBatching().ControlInput
+	if len(controls) != 2 || controls[0].Control[0].Int32FalseTrue[1] != 1 ||
+		controls[1].Control[0].DataType != nvidia_inferenceserver.DataType_TYPE_UINT64 {
+		t.Fatalf("unexpected controls: %v", controls)
+	}
+
+	_, err = nvidia_inferenceserver.NewModelConfigBuilder("stateful").Backend("python").
+		Input("input", nvidia_inferenceserver.DataType_TYPE_FP32, 1).
+		SequenceControl("START", nvidia_inferenceserver.ModelSequenceBatching_Control_CONTROL_SEQUENCE_START, nvidia_inferenceserver.DataType_TYPE_INT32).
+		Build()
+	if !errors.Is(err, nvidia_inferenceserver.ErrInvalidModelConfig) {
+		t.Fatalf("expect error of control without sequence batching, got %v", err)
+	}
+}
+
+func TestModelConfigValidate(t *testing.T) {
+	_, err := nvidia_inferenceserver.NewModelConfigBuilder("bad").
+		Platform("onnxruntime_onnx").
+		Input("x", nvidia_inferenceserver.DataType_TYPE_FP32, 0).
+		Input("x", nvidia_inferenceserver.DataType_TYPE_FP32, 2).
+		Output("y", nvidia_inferenceserver.DataType_TYPE_FP32).
+		DynamicBatching(0, 16).
+		Build()
+	if !errors.Is(err, nvidia_inferenceserver.ErrInvalidModelConfig) {
+		t.Fatalf("expect ErrInvalidModelConfig, got %v", err)
+	}
+	for _, expected := range []string{"invalid dim 0", "duplicate input x", "output y dims is empty", "requires max_batch_size > 0"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("error does not contain %q: %v", expected, err)
+		}
+	}
+}
+
+func TestModelConfigEnsembleWiring(t *testing.T) {
+	newEnsemble := func(postInput string) *nvidia_inferenceserver.ModelConfigBuilder {
+		return nvidia_inferenceserver.NewModelConfigBuilder("pipeline").
+			MaxBatchSize(8).
+			Input("TEXT", nvidia_inferenceserver.DataType_TYPE_STRING, 1).
+			Output("LABEL", nvidia_inferenceserver.DataType_TYPE_STRING, 1).
+			EnsembleStep("postprocess", -1, map[string]string{"logits": postInput}, map[string]string{"label": "LABEL"}).
+			EnsembleStep("tokenizer", -1, map[string]string{"text": "TEXT"}, map[string]string{"input_ids": "ids"}).
+			EnsembleStep("bert", 1, map[string]string{"input_ids": "ids"}, map[string]string{"logits": "logits"})
+	}
+	config, err := newEnsemble("logits").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Platform != nvidia_inferenceserver.EnsemblePlatform || len(config.GetEnsembleScheduling().Step) != 3 {
+		t.Fatalf("unexpected ensemble config: %v", config)
+	}
+	if _, err = newEnsemble("missing").Build(); err == nil || !strings.Contains(err.Error(), "input missing is not produced") {
+		t.Fatalf("expect ensemble wiring error, got %v", err)
+	}
+}
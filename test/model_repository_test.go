@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+func TestModelRepositoryManagerLoadWithOverride(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, LoadDelay: 100 * time.Millisecond})
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+
+	ctx := context.Background()
+	for name, client := range map[string]*nvidia_inferenceserver.TritonClientService{
+		"grpc": grpcClient, "http": httpClient,
+	} {
+		manager := nvidia_inferenceserver.NewModelRepositoryManager(
+			client, nvidia_inferenceserver.WithModelReadyPollInterval(10*time.Millisecond))
+		if err := manager.UnloadModel(ctx, tModelName, true); err != nil {
+			t.Fatalf("%s unload error: %v", name, err)
+		}
+		if !server.RepositoryParameters(tModelName)[nvidia_inferenceserver.ModelUnloadDependentsParamKey].GetBoolParam() {
+			t.Fatalf("%s unload_dependents is not sent", name)
+		}
+
+		config := testModelConfig()
+		err := manager.LoadModel(ctx, tModelName, &nvidia_inferenceserver.ModelLoadOptions{
+			Config: config,
+			Files:  map[string][]byte{"1/model.onnx": {0, 1, 2}},
+		})
+		if err != nil {
+			t.Fatalf("%s load error: %v", name, err)
+		}
+		if isReady, _ := client.CheckModelReadyCtx(ctx, tModelName, ""); !isReady {
+			t.Fatalf("%s model is not ready after load", name)
+		}
+		params := server.RepositoryParameters(tModelName)
+		if content := params[nvidia_inferenceserver.ModelLoadFileParamPrefix+"1/model.onnx"].GetBytesParam(); len(content) != 3 || content[2] != 2 {
+			t.Fatalf("%s unexpected file content: %v", name, content)
+		}
+		modelConfig, err := client.ModelConfigurationCtx(ctx, tModelName, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if modelConfig.Config.MaxBatchSize != config.MaxBatchSize || len(modelConfig.Config.Input) != 1 {
+			t.Fatalf("%s config is not overridden: %v", name, modelConfig.Config)
+		}
+	}
+}
+
+func TestModelRepositoryManagerLoadValidateAndDeadline(t *testing.T) {
+	loadBlock := make(chan struct{})
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, LoadBlock: loadBlock})
+	httpClient := server.NewHTTPClient()
+	manager := nvidia_inferenceserver.NewModelRepositoryManager(
+		httpClient, nvidia_inferenceserver.WithModelReadyPollInterval(10*time.Millisecond))
+
+	ctx := context.Background()
+	err := manager.LoadModel(ctx, tModelName, &nvidia_inferenceserver.ModelLoadOptions{Files: map[string][]byte{"1/model.onnx": nil}})
+	if err == nil {
+		t.Fatal("expect error of files without config")
+	}
+	// model is not ready until loadBlock is closed
+	err = manager.LoadModel(ctx, tModelName, &nvidia_inferenceserver.ModelLoadOptions{ReadyTimeout: 50 * time.Millisecond})
+	if !errors.Is(err, nvidia_inferenceserver.ErrModelReadyDeadline) {
+		t.Fatalf("expect ErrModelReadyDeadline, got %v", err)
+	}
+
+	// requests without deadline on the same client after the deadline
+	isReady, readyErr := httpClient.CheckModelReadyCtx(ctx, tModelName, "")
+	if isReady || (readyErr != nil && !errors.Is(readyErr, nvidia_inferenceserver.ErrModelNotReady)) {
+		t.Fatalf("expect model not ready, got %v %v", isReady, readyErr)
+	}
+	close(loadBlock)
+	if err = manager.WaitModelReady(ctx, tModelName, ""); err != nil {
+		t.Fatalf("wait model ready error: %v", err)
+	}
+	if err = manager.LoadModel(ctx, "unknown", nil); !errors.Is(err, nvidia_inferenceserver.ErrInvalidInput) {
+		t.Fatalf("expect load error of unknown model, got %v", err)
+	}
+}
+
+func TestRollingReloadModel(t *testing.T) {
+	serverA := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	serverB := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	serverC := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	serverB.InjectError("RepositoryModelLoad", errors.New("load failed"))
+	clients := []*nvidia_inferenceserver.TritonClientService{
+		serverA.NewHTTPClient(), serverB.NewHTTPClient(), serverC.NewHTTPClient(),
+	}
+
+	options := &nvidia_inferenceserver.ModelLoadOptions{Config: testModelConfig()}
+	err := nvidia_inferenceserver.RollingReloadModel(context.Background(), clients, tModelName, options)
+	if err == nil {
+		t.Fatal("expect rolling reload error")
+	}
+	if serverA.RepositoryParameters(tModelName) == nil || serverC.RepositoryParameters(tModelName) != nil {
+		t.Fatal("rolling reload should stop at the failed server")
+	}
+
+	serverB.ClearError("RepositoryModelLoad")
+	pool, err := nvidia_inferenceserver.NewTritonClientPool(clients, nvidia_inferenceserver.WithPoolHealthCheck(0, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	if err = pool.RollingReloadModel(context.Background(), tModelName, options); err != nil {
+		t.Fatal(err)
+	}
+	if serverC.RepositoryParameters(tModelName) == nil {
+		t.Fatal("model is not reloaded on every server")
+	}
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// writeRepositoryFile write file into model repository, parent directories are created
+func writeRepositoryFile(t *testing.T, repository, path, content string) {
+	fullPath := filepath.Join(repository, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const (
+	tRepoTokenizerConfig string = `
+name: "tokenizer"
+backend: "python"
+max_batch_size: 8
+input [ { name: "text" data_type: TYPE_STRING dims: [ 1 ] } ]
+output [ { name: "input_ids" data_type: TYPE_INT32 dims: [ -1 ] } ]
+`
+	tRepoBertConfig string = `
+name: "bert"
+platform: "onnxruntime_onnx"
+max_batch_size: 8
+version_policy: { specific: { versions: [ 1, 2 ] } }
+input [ { name: "input_ids" data_type: TYPE_INT32 dims: [ -1 ] } ]
+output [ { name: "logits" data_type: TYPE_FP32 dims: [ 2 ] } ]
+`
+	tRepoPipelineConfig string = `
+name: "pipeline"
+platform: "ensemble"
+max_batch_size: 8
+input [ { name: "TEXT" data_type: TYPE_STRING dims: [ 1 ] } ]
+output [ { name: "LOGITS" data_type: TYPE_FP32 dims: [ 2 ] } ]
+ensemble_scheduling {
+  step [
+    { model_name: "tokenizer" model_version: -1 input_map { key: "text" value: "TEXT" } output_map { key: "input_ids" value: "ids" } },
+    { model_name: "bert" model_version: 1 input_map { key: "input_ids" value: "ids" } output_map { key: "logits" value: "LOGITS" } }
+  ]
+}
+`
+)
+
+// newTestRepository create a valid repository with tokenizer, bert and ensemble pipeline
+func newTestRepository(t *testing.T) string {
+	repository := t.TempDir()
+	writeRepositoryFile(t, repository, "tokenizer/config.pbtxt", tRepoTokenizerConfig)
+	writeRepositoryFile(t, repository, "tokenizer/1/model.py", "")
+	writeRepositoryFile(t, repository, "bert/config.pbtxt", tRepoBertConfig)
+	writeRepositoryFile(t, repository, "bert/1/model.onnx", "")
+	writeRepositoryFile(t, repository, "bert/2/model.onnx", "")
+	writeRepositoryFile(t, repository, "pipeline/config.pbtxt", tRepoPipelineConfig)
+	if err := os.MkdirAll(filepath.Join(repository, "pipeline", "1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+func TestScanModelRepository(t *testing.T) {
+	report, err := nvidia_inferenceserver.ScanModelRepository(newTestRepository(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() {
+		t.Fatalf("expect no problem, got %v", report.Problems)
+	}
+	bert, ok := report.Model("bert")
+	if !ok || len(bert.Versions) != 2 || bert.Config.Platform != "onnxruntime_onnx" {
+		t.Fatalf("unexpected bert model: %v", bert)
+	}
+
+	if _, err = nvidia_inferenceserver.ScanModelRepository(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expect error of missing repository")
+	}
+}
+
+func TestScanModelRepositoryProblems(t *testing.T) {
+	repository := newTestRepository(t)
+	// specific version 2 without model file, version 1 is removed
+	if err := os.RemoveAll(filepath.Join(repository, "bert", "1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repository, "bert", "2", "model.onnx")); err != nil {
+		t.Fatal(err)
+	}
+	writeRepositoryFile(t, repository, "bert/latest/README", "")
+	// ensemble references tensor which is not an output of tokenizer
+	writeRepositoryFile(t, repository, "pipeline/config.pbtxt",
+		strings.Replace(tRepoPipelineConfig, `key: "input_ids" value: "ids"`, `key: "token_ids" value: "ids"`, 1))
+	writeRepositoryFile(t, repository, "broken/config.pbtxt", "name: ")
+
+	report, err := nvidia_inferenceserver.ScanModelRepository(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	for _, problem := range report.Problems {
+		problems = append(problems, problem.String())
+	}
+	joined := strings.Join(problems, "\n")
+	for _, expected := range []string{
+		"bert: version directory name must be a positive integer",
+		"bert: version 1 of specific version policy has no version directory",
+		"bert: model file model.onnx not found",
+		"broken: [Config]decode model config error",
+		"pipeline: ensemble step 0 (tokenizer) output token_ids is not an output of the model",
+		"pipeline: ensemble step 1 (bert) references version 1 which does not exist",
+	} {
+		if !strings.Contains(joined, expected) {
+			t.Fatalf("problems do not contain %q:\n%s", expected, joined)
+		}
+	}
+}
+
+func TestScanModelRepositoryAutoCompleteConfig(t *testing.T) {
+	repository := t.TempDir()
+	// triton auto-completes config of onnx / tensorrt models
+	writeRepositoryFile(t, repository, "detector/1/model.onnx", "")
+	writeRepositoryFile(t, repository, "encoder/config.pbtxt", "max_batch_size: 8\n")
+	writeRepositoryFile(t, repository, "encoder/1/model.plan", "")
+
+	report, err := nvidia_inferenceserver.ScanModelRepository(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || len(report.Warnings) != 2 {
+		t.Fatalf("expect 2 warnings without problem, got problems %v warnings %v", report.Problems, report.Warnings)
+	}
+	if detector, ok := report.Model("detector"); !ok || detector.Config != nil || len(detector.Versions) != 1 {
+		t.Fatalf("unexpected detector model: %v", detector)
+	}
+
+	// libtorch model and unknown model file can not be auto-completed
+	writeRepositoryFile(t, repository, "custom/1/weights.bin", "")
+	writeRepositoryFile(t, repository, "classifier/config.pbtxt", "max_batch_size: 8\n")
+	writeRepositoryFile(t, repository, "classifier/1/model.pt", "")
+	if report, err = nvidia_inferenceserver.ScanModelRepository(repository); err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	for _, problem := range report.Problems {
+		problems = append(problems, problem.String())
+	}
+	joined := strings.Join(problems, "\n")
+	for _, expected := range []string{
+		"classifier: [Config]invalid model config: platform or backend must be specified",
+		"custom: config.pbtxt not found and no model file which triton can auto-complete config from",
+	} {
+		if !strings.Contains(joined, expected) {
+			t.Fatalf("problems do not contain %q:\n%s", expected, joined)
+		}
+	}
+}
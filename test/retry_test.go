@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// startFastHTTPServer start local http server with handler and return its address
+func startFastHTTPServer(t *testing.T, handler fasthttp.RequestHandler) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fasthttp.Server{Handler: handler}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return listener.Addr().String()
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var readyCount, inferCount int64
+	serverURL := startFastHTTPServer(t, func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Path()) == "/v2/health/ready" {
+			// model reloading, unavailable for the first two probes
+			if atomic.AddInt64(&readyCount, 1) <= 2 {
+				ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+			}
+			return
+		}
+		atomic.AddInt64(&inferCount, 1)
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	})
+	policy := nvidia_inferenceserver.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	client := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(
+		serverURL, &fasthttp.Client{}, nvidia_inferenceserver.WithRetryPolicy(policy))
+
+	if ready, err := client.CheckServerReady(time.Second); err != nil || !ready {
+		t.Fatalf("expect ready after retry, got %v %v", ready, err)
+	}
+	if readyCount != 3 {
+		t.Fatalf("expect 3 attempts, got %d", readyCount)
+	}
+
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+	if _, err := client.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "bert", "1", decoder); err == nil {
+		t.Fatal("expect infer error")
+	}
+	if inferCount != 1 {
+		t.Fatalf("infer should not be retried by default, got %d attempts", inferCount)
+	}
+
+	policy.RetryInference = true
+	if _, err := client.ModelHTTPInferCtx(context.Background(), []byte(`{}`), "bert", "1", decoder); err == nil {
+		t.Fatal("expect infer error")
+	}
+	if inferCount != 1+int64(policy.MaxAttempts) {
+		t.Fatalf("expect infer retried %d times, got %d attempts", policy.MaxAttempts, inferCount-1)
+	}
+}
@@ -0,0 +1,303 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+// sequenceRecorder record sequence parameters of every request received by fake model
+type sequenceRecorder struct {
+	lock   sync.Mutex
+	params []map[string]*nvidia_inferenceserver.InferParameter
+}
+
+func (r *sequenceRecorder) handler(
+	_ context.Context, request *nvidia_inferenceserver.ModelInferRequest,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	r.lock.Lock()
+	r.params = append(r.params, request.Parameters)
+	r.lock.Unlock()
+	return &nvidia_inferenceserver.ModelInferResponse{Id: request.Id}, nil
+}
+
+// check every request carries sequence id, only the first carries start and only the last carries end
+func (r *sequenceRecorder) check(t *testing.T, name string, sequenceID interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.params) != 3 {
+		t.Fatalf("%s expect 3 requests, got %d", name, len(r.params))
+	}
+	for i, params := range r.params {
+		idParam := params[nvidia_inferenceserver.SequenceIDParamKey]
+		if id, ok := sequenceID.(string); ok && idParam.GetStringParam() != id {
+			t.Fatalf("%s unexpected sequence id: %v", name, idParam)
+		}
+		if id, ok := sequenceID.(uint64); ok && uint64(idParam.GetInt64Param()) != id {
+			t.Fatalf("%s unexpected sequence id: %v", name, idParam)
+		}
+		if params[nvidia_inferenceserver.SequenceStartParamKey].GetBoolParam() != (i == 0) ||
+			params[nvidia_inferenceserver.SequenceEndParamKey].GetBoolParam() != (i == 2) {
+			t.Fatalf("%s unexpected sequence flags of request %d: %v", name, i, params)
+		}
+	}
+	r.params = nil
+}
+
+func TestSequenceSession(t *testing.T) {
+	recorder := new(sequenceRecorder)
+	config := testModelConfig()
+	config.SchedulingChoice = &nvidia_inferenceserver.ModelConfig_SequenceBatching{
+		SequenceBatching: &nvidia_inferenceserver.ModelSequenceBatching{MaxSequenceIdleMicroseconds: 1000000},
+	}
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: config, Handler: recorder.handler})
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+	inputIds, _ := nvidia_inferenceserver.NewNumericTensor("input_ids", []int64{1, 2}, []int32{101, 102})
+	inputs, rawInputs := nvidia_inferenceserver.BuildGRPCInferInputs(inputIds)
+	requestBody, _, err := nvidia_inferenceserver.BuildHTTPInferRequestBody(
+		[]*nvidia_inferenceserver.InferTensor{inputIds}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for name, infer := range map[string]func(session *nvidia_inferenceserver.SequenceSession) error{
+		"grpc": func(session *nvidia_inferenceserver.SequenceSession) error {
+			_, inferErr := session.ModelGRPCInferCtx(ctx, inputs, nil, rawInputs, decoder)
+			return inferErr
+		},
+		"http": func(session *nvidia_inferenceserver.SequenceSession) error {
+			_, inferErr := session.ModelHTTPInferCtx(ctx, requestBody, decoder)
+			return inferErr
+		},
+		"http-binary": func(session *nvidia_inferenceserver.SequenceSession) error {
+			_, inferErr := session.ModelHTTPBinaryInferCtx(ctx, inputs, nil, rawInputs, decoder)
+			return inferErr
+		},
+	} {
+		client := httpClient
+		if name == "grpc" {
+			client = grpcClient
+		}
+		session, sessionErr := client.NewSequenceSession(tModelName, tModelVersion, nil, time.Second)
+		if sessionErr != nil {
+			t.Fatal(sessionErr)
+		}
+		for i := 0; i < 3; i++ {
+			if i == 2 {
+				session.End()
+			}
+			if err = infer(session); err != nil {
+				t.Fatalf("%s infer error: %v", name, err)
+			}
+		}
+		if !session.IsEnded() {
+			t.Fatalf("%s expect sequence ended", name)
+		}
+		if err = infer(session); !errors.Is(err, nvidia_inferenceserver.ErrSequenceEnded) {
+			t.Fatalf("%s expect ErrSequenceEnded, got %v", name, err)
+		}
+		recorder.check(t, name, session.ID())
+	}
+}
+
+func TestSequenceSessionWithStream(t *testing.T) {
+	recorder := new(sequenceRecorder)
+	config := testModelConfig()
+	config.SchedulingChoice = &nvidia_inferenceserver.ModelConfig_SequenceBatching{
+		SequenceBatching: &nvidia_inferenceserver.ModelSequenceBatching{},
+	}
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: config, Handler: recorder.handler})
+	client := newFakeGRPCClient(t, server)
+
+	session, err := client.NewSequenceSession(tModelName, tModelVersion, "sequence-1", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.NewModelStreamInferSession(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	for i := 0; i < 3; i++ {
+		if i == 2 {
+			session.End()
+		}
+		if err = session.Send(stream, &nvidia_inferenceserver.ModelInferRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case result := <-stream.Responses():
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("wait stream response timeout")
+		}
+	}
+	recorder.check(t, "stream", "sequence-1")
+}
+
+func TestSequenceSessionFailedRequest(t *testing.T) {
+	recorder := new(sequenceRecorder)
+	config := testModelConfig()
+	config.SchedulingChoice = &nvidia_inferenceserver.ModelConfig_SequenceBatching{
+		SequenceBatching: &nvidia_inferenceserver.ModelSequenceBatching{},
+	}
+	slowConfig := testModelConfig()
+	slowConfig.Name, slowConfig.SchedulingChoice = "slow", config.SchedulingChoice
+	server := startFakeTriton(t,
+		&tritontest.Model{Name: tModelName, Config: config, Handler: recorder.handler},
+		&tritontest.Model{Name: "slow", Config: slowConfig, Latency: 500 * time.Millisecond})
+	client := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+
+	inputIds, _ := nvidia_inferenceserver.NewNumericTensor("input_ids", []int64{1, 2}, []int32{101, 102})
+	requestBody, _, err := nvidia_inferenceserver.BuildHTTPInferRequestBody(
+		[]*nvidia_inferenceserver.InferTensor{inputIds}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+	decoderErr := errors.New("decode failed")
+	failedDecoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, decoderErr }
+
+	ctx := context.Background()
+	session, err := httpClient.NewSequenceSession(tModelName, tModelVersion, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// request which is not sent is rolled back, start flag is sent again
+	if _, err = session.ModelHTTPInferCtx(ctx, []byte("not json"), decoder); err == nil {
+		t.Fatal("expect merge error of invalid request body")
+	}
+	// decoder error means request is accepted, sequence goes on
+	if _, err = session.ModelHTTPInferCtx(ctx, requestBody, failedDecoder); err == nil || session.IsBroken() {
+		t.Fatalf("expect decoder error without broken session, got %v", err)
+	}
+	if _, err = session.End().ModelHTTPInferCtx(ctx, requestBody, decoder); err != nil {
+		t.Fatal(err)
+	}
+	recorder.lock.Lock()
+	params := recorder.params
+	recorder.lock.Unlock()
+	if len(params) != 2 || !params[0][nvidia_inferenceserver.SequenceStartParamKey].GetBoolParam() ||
+		params[1][nvidia_inferenceserver.SequenceStartParamKey].GetBoolParam() {
+		t.Fatalf("unexpected sequence flags: %v", params)
+	}
+
+	// timeout request may be accepted by server, session is broken instead of sending start flag again
+	inputs, rawInputs := nvidia_inferenceserver.BuildGRPCInferInputs(inputIds)
+	slowSession, err := client.NewSequenceSession("slow", tModelVersion, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err = slowSession.ModelGRPCInferCtx(timeoutCtx, inputs, nil, rawInputs, decoder); !errors.Is(
+		err, nvidia_inferenceserver.ErrTimeout) || !slowSession.IsBroken() {
+		t.Fatalf("expect timeout with broken session, got %v", err)
+	}
+	if _, err = slowSession.ModelGRPCInferCtx(ctx, inputs, nil, rawInputs, decoder); !errors.Is(
+		err, nvidia_inferenceserver.ErrSequenceBroken) {
+		t.Fatalf("expect ErrSequenceBroken, got %v", err)
+	}
+}
+
+func TestSequenceSessionNotConfigured(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: testModelConfig()})
+	client := server.NewHTTPClient()
+	if _, err := client.NewSequenceSession(tModelName, tModelVersion, nil, time.Second); !errors.Is(
+		err, nvidia_inferenceserver.ErrSequenceBatchingNotConfigured) {
+		t.Fatalf("expect ErrSequenceBatchingNotConfigured, got %v", err)
+	}
+	if _, err := client.NewSequenceSession(tModelName, tModelVersion, 1.5, time.Second); err == nil {
+		t.Fatal("expect invalid sequence id error")
+	}
+	if _, err := client.NewSequenceSession(tModelName, tModelVersion, uint64(math.MaxInt64)+1, time.Second); err == nil {
+		t.Fatal("expect sequence id out of int64 range error")
+	}
+}
+
+func TestSequenceSessionStreamRejected(t *testing.T) {
+	config := testModelConfig()
+	config.SchedulingChoice = &nvidia_inferenceserver.ModelConfig_SequenceBatching{
+		SequenceBatching: &nvidia_inferenceserver.ModelSequenceBatching{},
+	}
+	// server rejects the second request of every sequence
+	var lock sync.Mutex
+	requestCounts := make(map[string]int)
+	handler := func(
+		_ context.Context, request *nvidia_inferenceserver.ModelInferRequest,
+	) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		sequenceID := request.Parameters[nvidia_inferenceserver.SequenceIDParamKey].GetStringParam()
+		if requestCounts[sequenceID]++; requestCounts[sequenceID] == 2 {
+			return nil, status.Error(codes.InvalidArgument, "inference request for sequence "+sequenceID+" is rejected")
+		}
+		return &nvidia_inferenceserver.ModelInferResponse{Id: request.Id}, nil
+	}
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: config, Handler: handler})
+	client := newFakeGRPCClient(t, server)
+	stream, err := client.NewModelStreamInferSession(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	results := make(chan error, 4)
+	callback := func(_ *nvidia_inferenceserver.ModelInferResponse, err error) { results <- err }
+	for name, send := range map[string]func(session *nvidia_inferenceserver.SequenceSession) error{
+		"send": func(session *nvidia_inferenceserver.SequenceSession) error {
+			if sendErr := session.Send(stream, &nvidia_inferenceserver.ModelInferRequest{}); sendErr != nil {
+				return sendErr
+			}
+			select {
+			case result := <-stream.Responses():
+				return result.Err
+			case <-time.After(time.Second):
+				return errors.New("wait stream response timeout")
+			}
+		},
+		"send-with-callback": func(session *nvidia_inferenceserver.SequenceSession) error {
+			if sendErr := session.SendWithCallback(stream, &nvidia_inferenceserver.ModelInferRequest{Id: "callback"}, callback); sendErr != nil {
+				return sendErr
+			}
+			select {
+			case resultErr := <-results:
+				return resultErr
+			case <-time.After(time.Second):
+				return errors.New("wait stream callback timeout")
+			}
+		},
+	} {
+		session, sessionErr := client.NewSequenceSession(tModelName, tModelVersion, name, time.Second)
+		if sessionErr != nil {
+			t.Fatal(sessionErr)
+		}
+		if err = send(session); err != nil || session.IsBroken() {
+			t.Fatalf("%s first request error: %v", name, err)
+		}
+		// local send succeeds, server rejects the request later on the stream
+		if err = send(session); err == nil || !session.IsBroken() {
+			t.Fatalf("%s expect rejected request breaks session, got %v", name, err)
+		}
+		if err = send(session); !errors.Is(err, nvidia_inferenceserver.ErrSequenceBroken) {
+			t.Fatalf("%s expect ErrSequenceBroken, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+//go:build linux
+
+package test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+// shmDoubleHandler fake model read INT32 input from shared memory and write doubled values into output region
+func shmDoubleHandler(manager **nvidia_inferenceserver.SharedMemoryManager) tritontest.ModelHandler {
+	regionOf := func(params map[string]*nvidia_inferenceserver.InferParameter) (*nvidia_inferenceserver.SystemSharedMemoryRegion, uint64, uint64, error) {
+		region, ok := (*manager).Region(params[nvidia_inferenceserver.SharedMemoryRegionParamKey].GetStringParam())
+		if !ok {
+			return nil, 0, 0, status.Error(codes.InvalidArgument, "shared memory region is not found")
+		}
+		return region, uint64(params[nvidia_inferenceserver.SharedMemoryOffsetParamKey].GetInt64Param()),
+			uint64(params[nvidia_inferenceserver.SharedMemoryByteSizeParamKey].GetInt64Param()), nil
+	}
+	return func(_ context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		input := request.Inputs[0]
+		inRegion, inOffset, inByteSize, err := regionOf(input.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := inRegion.Read(inOffset, inByteSize)
+		if err != nil {
+			return nil, err
+		}
+		result, err := nvidia_inferenceserver.NewInferResultFromGRPC(&nvidia_inferenceserver.ModelInferResponse{
+			Outputs:           []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{{Name: input.Name, Datatype: input.Datatype, Shape: input.Shape}},
+			RawOutputContents: [][]byte{raw},
+		})
+		if err != nil {
+			return nil, err
+		}
+		values, err := result.Outputs[0].AsInt32()
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			values[i] *= 2
+		}
+		outputTensor, err := nvidia_inferenceserver.NewNumericTensor("output", input.Shape, values)
+		if err != nil {
+			return nil, err
+		}
+		outRegion, outOffset, _, err := regionOf(request.Outputs[0].Parameters)
+		if err != nil {
+			return nil, err
+		}
+		outputInput, outputRaw := outputTensor.GRPCInput()
+		if err = outRegion.Write(outOffset, outputRaw); err != nil {
+			return nil, err
+		}
+		return &nvidia_inferenceserver.ModelInferResponse{
+			Outputs: []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+				{Name: outputInput.Name, Datatype: outputInput.Datatype, Shape: outputInput.Shape},
+			},
+		}, nil
+	}
+}
+
+func TestSystemSharedMemoryInfer(t *testing.T) {
+	var manager *nvidia_inferenceserver.SharedMemoryManager
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Handler: shmDoubleHandler(&manager)})
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+
+	ctx := context.Background()
+	for name, client := range map[string]*nvidia_inferenceserver.TritonClientService{
+		"grpc": grpcClient, "http-binary": httpClient,
+	} {
+		manager = nvidia_inferenceserver.NewSharedMemoryManager(client)
+		inRegion, err := manager.CreateRegion(ctx, "tritontest_input_"+name, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outRegion, err := manager.CreateRegion(ctx, "tritontest_output_"+name, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if region, ok := server.SystemSharedMemoryRegion(inRegion.Name()); !ok || region.Key != inRegion.Key() || region.ByteSize != 64 {
+			t.Fatalf("%s region is not registered: %v", name, region)
+		}
+
+		tensor, _ := nvidia_inferenceserver.NewNumericTensor("input", []int64{1, 4}, []int32{1, 2, 3, 4})
+		input, err := inRegion.WriteInput(tensor, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs := []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor{outRegion.Output("output", 16, 8)}
+		var result []int32
+		decoder := nvidia_inferenceserver.NewInferResultDecoder(
+			func(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+				output, outputErr := inferResult.Output("output")
+				if outputErr != nil {
+					return nil, outputErr
+				}
+				if readErr := outRegion.ReadOutput(output, 8, 16); readErr != nil {
+					return nil, readErr
+				}
+				result, outputErr = output.AsInt32()
+				return nil, outputErr
+			})
+		inputs := []*nvidia_inferenceserver.ModelInferRequest_InferInputTensor{input}
+		if name == "grpc" {
+			_, err = client.ModelGRPCInferCtx(ctx, inputs, outputs, nil, tModelName, tModelVersion, decoder)
+		} else {
+			_, err = client.ModelHTTPBinaryInferCtx(ctx, inputs, outputs, nil, tModelName, tModelVersion, decoder)
+		}
+		if err != nil {
+			t.Fatalf("%s infer error: %v", name, err)
+		}
+		if len(result) != 4 || result[0] != 2 || result[3] != 8 {
+			t.Fatalf("%s unexpected result: %v", name, result)
+		}
+
+		if err = manager.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := server.SystemSharedMemoryRegion(inRegion.Name()); ok {
+			t.Fatalf("%s region is not unregistered", name)
+		}
+		if _, err = os.Stat(nvidia_inferenceserver.SystemSharedMemoryDir + inRegion.Name()); !os.IsNotExist(err) {
+			t.Fatalf("%s region is not unlinked: %v", name, err)
+		}
+		if _, err = inRegion.Read(0, 4); err == nil {
+			t.Fatalf("%s expect error of closed region", name)
+		}
+	}
+}
+
+func TestSystemSharedMemoryRegionRange(t *testing.T) {
+	region, err := nvidia_inferenceserver.NewSystemSharedMemoryRegion("tritontest_range", "/tritontest_range", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer region.Close()
+	if err = region.Write(4, []byte{1, 2, 3, 4, 5}); err == nil {
+		t.Fatal("expect out of range error")
+	}
+	if _, err = nvidia_inferenceserver.NewSystemSharedMemoryRegion("tritontest_range", "/tritontest_range", 8); err == nil {
+		t.Fatal("expect error of existing shared memory")
+	}
+}
@@ -0,0 +1,278 @@
+package test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// streamResult response or error delivered to stream callback
+type streamResult struct {
+	response *nvidia_inferenceserver.ModelInferResponse
+	err      error
+}
+
+// scriptedStreamServer stream server which replies every request with responses of respond
+type scriptedStreamServer struct {
+	nvidia_inferenceserver.UnimplementedGRPCInferenceServiceServer
+
+	respond func(request *nvidia_inferenceserver.ModelInferRequest) []*nvidia_inferenceserver.ModelStreamInferResponse
+}
+
+// ModelStreamInfer send scripted responses of every request
+func (s *scriptedStreamServer) ModelStreamInfer(stream nvidia_inferenceserver.GRPCInferenceService_ModelStreamInferServer) error {
+	for {
+		request, recvErr := stream.Recv()
+		if recvErr != nil {
+			return nil
+		}
+		for _, response := range s.respond(request) {
+			if sendErr := stream.Send(response); sendErr != nil {
+				return sendErr
+			}
+		}
+	}
+}
+
+// stalledStreamServer stream server which never reads requests, client Send is blocked by flow control
+type stalledStreamServer struct {
+	nvidia_inferenceserver.UnimplementedGRPCInferenceServiceServer
+}
+
+// ModelStreamInfer wait until stream is canceled
+func (s *stalledStreamServer) ModelStreamInfer(stream nvidia_inferenceserver.GRPCInferenceService_ModelStreamInferServer) error {
+	<-stream.Context().Done()
+	return nil
+}
+
+// startScriptedStreamSession start scripted stream server and create stream session without default callback
+func startScriptedStreamSession(
+	t *testing.T, respond func(request *nvidia_inferenceserver.ModelInferRequest) []*nvidia_inferenceserver.ModelStreamInferResponse,
+) *nvidia_inferenceserver.ModelStreamInferSession {
+	return startStreamSession(t, &scriptedStreamServer{respond: respond})
+}
+
+// startStreamSession start stream server and create stream session without default callback
+func startStreamSession(
+	t *testing.T, streamServer nvidia_inferenceserver.GRPCInferenceServiceServer,
+) *nvidia_inferenceserver.ModelStreamInferSession {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	nvidia_inferenceserver.RegisterGRPCInferenceServiceServer(server, streamServer)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(conn)
+	t.Cleanup(func() { _ = client.ShutdownTritonConnection() })
+	session, err := client.NewModelStreamInferSession(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = session.Close() })
+	return session
+}
+
+// strayResponse response of request id sent when probe request arrives, it reaches Responses channel
+// only if callback of request id is removed
+func strayResponse(requestID string) []*nvidia_inferenceserver.ModelStreamInferResponse {
+	return []*nvidia_inferenceserver.ModelStreamInferResponse{
+		{InferResponse: &nvidia_inferenceserver.ModelInferResponse{Id: requestID}},
+	}
+}
+
+// expectStrayResponse send probe request and wait stray response of request id on Responses channel
+func expectStrayResponse(t *testing.T, session *nvidia_inferenceserver.ModelStreamInferSession, requestID string) {
+	if err := session.Send(&nvidia_inferenceserver.ModelInferRequest{Id: "probe"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-session.Responses():
+		if result.RequestID != requestID {
+			t.Fatalf("unexpected stray response: %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("callback of %s is not removed", requestID)
+	}
+}
+
+func TestStreamInferCallbackError(t *testing.T) {
+	session := startScriptedStreamSession(t, func(request *nvidia_inferenceserver.ModelInferRequest) []*nvidia_inferenceserver.ModelStreamInferResponse {
+		switch request.Id {
+		case "req-error":
+			// error without infer_response can not be correlated by request id
+			return []*nvidia_inferenceserver.ModelStreamInferResponse{{ErrorMessage: "inference request failed"}}
+		case "probe":
+			return strayResponse("req-error")
+		}
+		return nil
+	})
+
+	results := make(chan streamResult, 4)
+	callback := func(response *nvidia_inferenceserver.ModelInferResponse, err error) {
+		results <- streamResult{response: response, err: err}
+	}
+	if err := session.SendWithCallback(&nvidia_inferenceserver.ModelInferRequest{Id: "req-error"}, callback); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-results:
+		if result.err == nil || result.response != nil {
+			t.Fatalf("expect error of req-error, got %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("error does not reach callback")
+	}
+	expectStrayResponse(t, session, "req-error")
+
+	// callback without final response gets stream error when session is closed
+	if err := session.SendWithCallback(&nvidia_inferenceserver.ModelInferRequest{Id: "req-pending"}, callback); err != nil {
+		t.Fatal(err)
+	}
+	_ = session.Close()
+	select {
+	case result := <-results:
+		if result.err == nil {
+			t.Fatalf("expect stream error of req-pending, got %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream error does not reach pending callback")
+	}
+}
+
+func TestStreamInferCallbackEmptyFinalResponse(t *testing.T) {
+	isFinalEnabled := make(chan bool, 1)
+	session := startScriptedStreamSession(t, func(request *nvidia_inferenceserver.ModelInferRequest) []*nvidia_inferenceserver.ModelStreamInferResponse {
+		if request.Id == "probe" {
+			return strayResponse("req-1")
+		}
+		isFinalEnabled <- request.Parameters[nvidia_inferenceserver.StreamEnableEmptyFinalResponseParamKey].GetBoolParam()
+		// decoupled model sends data response, then FINAL flag only response
+		return []*nvidia_inferenceserver.ModelStreamInferResponse{
+			{InferResponse: &nvidia_inferenceserver.ModelInferResponse{
+				Id:      request.Id,
+				Outputs: []*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{{Name: "output"}},
+			}},
+			{InferResponse: &nvidia_inferenceserver.ModelInferResponse{
+				Id: request.Id,
+				Parameters: map[string]*nvidia_inferenceserver.InferParameter{
+					nvidia_inferenceserver.StreamFinalResponseParamKey: {
+						ParameterChoice: &nvidia_inferenceserver.InferParameter_BoolParam{BoolParam: true},
+					},
+				},
+			}},
+		}
+	})
+
+	results := make(chan streamResult, 4)
+	callback := func(response *nvidia_inferenceserver.ModelInferResponse, err error) {
+		results <- streamResult{response: response, err: err}
+	}
+	if err := session.SendWithCallback(&nvidia_inferenceserver.ModelInferRequest{Id: "req-1"}, callback); err != nil {
+		t.Fatal(err)
+	}
+	if !<-isFinalEnabled {
+		t.Fatal("expect triton_enable_empty_final_response in request parameters")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case result := <-results:
+			if result.err != nil || (i == 0) != (len(result.response.Outputs) == 1) {
+				t.Fatalf("unexpected response %d: %+v", i, result)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wait response %d timeout", i)
+		}
+	}
+	expectStrayResponse(t, session, "req-1")
+}
+
+func TestStreamInferCallbackErrorAfterPartialResponse(t *testing.T) {
+	session := startScriptedStreamSession(t, func(request *nvidia_inferenceserver.ModelInferRequest) []*nvidia_inferenceserver.ModelStreamInferResponse {
+		switch request.Id {
+		case "req-decoupled":
+			// partial response of decoupled model, request is still in flight
+			return []*nvidia_inferenceserver.ModelStreamInferResponse{
+				{InferResponse: &nvidia_inferenceserver.ModelInferResponse{Id: request.Id}},
+			}
+		case "req-next":
+			// error of req-decoupled arrives after req-next is sent
+			return []*nvidia_inferenceserver.ModelStreamInferResponse{{ErrorMessage: "decoupled request failed"}}
+		}
+		return nil
+	})
+
+	decoupledResults, nextResults := make(chan streamResult, 4), make(chan streamResult, 4)
+	err := session.SendWithCallback(&nvidia_inferenceserver.ModelInferRequest{Id: "req-decoupled"},
+		func(response *nvidia_inferenceserver.ModelInferResponse, err error) {
+			decoupledResults <- streamResult{response: response, err: err}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-decoupledResults:
+		if result.err != nil {
+			t.Fatalf("expect partial response, got %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("partial response does not reach callback")
+	}
+	err = session.SendWithCallback(&nvidia_inferenceserver.ModelInferRequest{Id: "req-next"},
+		func(response *nvidia_inferenceserver.ModelInferResponse, err error) {
+			nextResults <- streamResult{response: response, err: err}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case result := <-decoupledResults:
+		if result.err == nil {
+			t.Fatalf("expect error of req-decoupled, got %+v", result)
+		}
+	case result := <-nextResults:
+		t.Fatalf("error of in-flight req-decoupled is delivered to req-next: %+v", result)
+	case <-time.After(time.Second):
+		t.Fatal("error does not reach callback")
+	}
+}
+
+func TestStreamInferCloseWhileSendBlocked(t *testing.T) {
+	session := startStreamSession(t, &stalledStreamServer{})
+	// request larger than flow control window blocks Send while holding send lock
+	request := &nvidia_inferenceserver.ModelInferRequest{RawInputContents: [][]byte{make([]byte, 16<<20)}}
+	sendDone := make(chan struct{})
+	go func() {
+		defer close(sendDone)
+		for i := 0; i < 4; i++ {
+			if session.Send(request) != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closeDone := make(chan struct{})
+	go func() {
+		_ = session.Close()
+		close(closeDone)
+	}()
+	select {
+	case <-closeDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close is blocked by Send")
+	}
+	<-sendDone
+}
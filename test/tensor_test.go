@@ -0,0 +1,68 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+func TestNumericTensorRoundTrip(t *testing.T) {
+	tensor, err := nvidia_inferenceserver.NewNumericTensor("input_ids", []int64{2, 2}, []int64{101, -1, 7, 102})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tensor.Datatype != "INT64" || len(tensor.Raw) != 32 {
+		t.Fatalf("unexpected tensor: %v %d", tensor.Datatype, len(tensor.Raw))
+	}
+	data, err := nvidia_inferenceserver.DecodeRawContentsToNumeric[int64](tensor.Datatype, tensor.Raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[1] != -1 || data[3] != 102 {
+		t.Fatalf("unexpected data: %v", data)
+	}
+	if _, err = nvidia_inferenceserver.NewNumericTensor("input_ids", []int64{3}, []int32{1, 2}); err == nil {
+		t.Fatal("expect shape validation error")
+	}
+}
+
+func TestHalfPrecisionTensor(t *testing.T) {
+	values := []float32{0, 1, -2.5, 65504, 0.0001}
+	for _, datatype := range []string{"FP16", "BF16"} {
+		tensor, err := nvidia_inferenceserver.NewNumericTensorWithDataType("x", datatype, []int64{5}, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := nvidia_inferenceserver.DecodeRawContentsToNumeric[float32](datatype, tensor.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range values {
+			if diff := math.Abs(float64(data[i] - v)); diff > math.Abs(float64(v))/100+1e-6 {
+				t.Fatalf("%s value %d: expect %v, got %v", datatype, i, v, data[i])
+			}
+		}
+	}
+}
+
+func TestBytesTensor(t *testing.T) {
+	tensor, err := nvidia_inferenceserver.NewStringTensor("text", []int64{1, 2}, []string{"hello", "三"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tensor.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	inputObj, raw, err := tensor.HTTPInput(false)
+	if err != nil || raw != nil {
+		t.Fatal(err)
+	}
+	if strData := inputObj.Data.([]string); strData[1] != "三" {
+		t.Fatalf("unexpected data: %v", strData)
+	}
+	inferInputs, rawInputs := nvidia_inferenceserver.BuildGRPCInferInputs(tensor)
+	if inferInputs[0].Datatype != "BYTES" || len(rawInputs[0]) != 4+5+4+3 {
+		t.Fatalf("unexpected grpc input: %v %d", inferInputs[0], len(rawInputs[0]))
+	}
+}
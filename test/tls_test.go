@@ -0,0 +1,218 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
+)
+
+const tTLSServerName string = "triton.local"
+
+// testCertificates PEM files of generated CA, server and client certificates
+type testCertificates struct {
+	caCertPath     string
+	serverCertPath string
+	serverKeyPath  string
+	clientCertPath string
+	clientKeyPath  string
+}
+
+// writeTestCertificate create certificate signed by parent (self-signed if parent is nil), write PEM files into dir
+func writeTestCertificate(
+	t *testing.T, dir, name string, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err = os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// generateTestCertificates generate CA, server certificate for tTLSServerName / 127.0.0.1 and client certificate
+func generateTestCertificates(t *testing.T) *testCertificates {
+	dir := t.TempDir()
+	notBefore, notAfter := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	caCert, caKey := writeTestCertificate(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tritontest CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	writeTestCertificate(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: tTLSServerName},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{tTLSServerName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, caCert, caKey)
+	writeTestCertificate(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "tritontest client"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCert, caKey)
+	return &testCertificates{
+		caCertPath:     filepath.Join(dir, "ca.crt"),
+		serverCertPath: filepath.Join(dir, "server.crt"),
+		serverKeyPath:  filepath.Join(dir, "server.key"),
+		clientCertPath: filepath.Join(dir, "client.crt"),
+		clientKeyPath:  filepath.Join(dir, "client.key"),
+	}
+}
+
+// startFakeTritonTLS start fake triton server with TLS, client certificate is required if isMutual
+func startFakeTritonTLS(t *testing.T, certs *testCertificates, isMutual bool) *tritontest.Server {
+	serverCert, err := tls.LoadX509KeyPair(certs.serverCertPath, certs.serverKeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CA of client certificate is the same as server certificate
+	caConfig, err := nvidia_inferenceserver.NewTLSConfig(certs.caCertPath, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{serverCert}, MinVersion: tls.VersionTLS12}
+	if isMutual {
+		tlsConfig.ClientAuth, tlsConfig.ClientCAs = tls.RequireAndVerifyClientCert, caConfig.RootCAs
+	}
+	server := tritontest.NewServer()
+	if err = server.StartTLS(tlsConfig); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// checkTLSServerReady check server ready over https and grpc with TLS
+func checkTLSServerReady(t *testing.T, server *tritontest.Server, tlsConfig *tls.Config) map[string]error {
+	httpClient := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(
+		server.HTTPAddr(), &fasthttp.Client{}, nvidia_inferenceserver.WithHTTPSecure(tlsConfig))
+	grpcConn, err := nvidia_inferenceserver.NewGRPCSecureConnection(server.GRPCAddr(), tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcClient := nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(grpcConn)
+	defer grpcClient.ShutdownTritonConnection()
+
+	results := make(map[string]error)
+	for name, client := range map[string]*nvidia_inferenceserver.TritonClientService{"http": httpClient, "grpc": grpcClient} {
+		isReady, readyErr := client.CheckServerReady(time.Second)
+		if readyErr == nil && !isReady {
+			t.Fatalf("%s expect server ready", name)
+		}
+		results[name] = readyErr
+	}
+	return results
+}
+
+func TestTLSConnection(t *testing.T) {
+	certs := generateTestCertificates(t)
+	server := startFakeTritonTLS(t, certs, false)
+
+	tlsConfig, err := nvidia_inferenceserver.NewTLSConfig(certs.caCertPath, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, readyErr := range checkTLSServerReady(t, server, tlsConfig) {
+		if readyErr != nil {
+			t.Fatalf("%s tls error: %v", name, readyErr)
+		}
+	}
+
+	// server name of certificate is verified
+	tlsConfig, err = nvidia_inferenceserver.NewTLSConfig(certs.caCertPath, "", "", tTLSServerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, readyErr := range checkTLSServerReady(t, server, tlsConfig) {
+		if readyErr != nil {
+			t.Fatalf("%s tls error with server name: %v", name, readyErr)
+		}
+	}
+	tlsConfig, err = nvidia_inferenceserver.NewTLSConfig(certs.caCertPath, "", "", "other.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, readyErr := range checkTLSServerReady(t, server, tlsConfig) {
+		if readyErr == nil {
+			t.Fatalf("%s expect server name mismatch error", name)
+		}
+	}
+}
+
+func TestMutualTLSConnection(t *testing.T) {
+	certs := generateTestCertificates(t)
+	server := startFakeTritonTLS(t, certs, true)
+
+	tlsConfig, err := nvidia_inferenceserver.NewTLSConfig(
+		certs.caCertPath, certs.clientCertPath, certs.clientKeyPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, readyErr := range checkTLSServerReady(t, server, tlsConfig) {
+		if readyErr != nil {
+			t.Fatalf("%s mutual tls error: %v", name, readyErr)
+		}
+	}
+
+	// server rejects client without certificate
+	tlsConfig, err = nvidia_inferenceserver.NewTLSConfig(certs.caCertPath, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, readyErr := range checkTLSServerReady(t, server, tlsConfig) {
+		if readyErr == nil {
+			t.Fatalf("%s expect client certificate required error", name)
+		}
+	}
+
+	if _, err = nvidia_inferenceserver.NewTLSConfig(certs.caCertPath, certs.clientCertPath, "", ""); err == nil {
+		t.Fatal("expect client key required error")
+	}
+}
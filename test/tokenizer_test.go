@@ -25,8 +25,8 @@ func TestFullTokenizerNotChinese(t *testing.T) {
 }
 
 func TestFullTokenizerChinese(t *testing.T) {
-	voc, vocabReadErr := bert.VocabFromFile("../../static/bert-chinese-vocab.txt")
-	//voc, vocabReadErr := VocabFromFile("../../static/bert-multilingual-vocab.txt")
+	voc, vocabReadErr := bert.VocabFromFile("bert-chinese-vocab.txt")
+	//voc, vocabReadErr := VocabFromFile("bert-multilingual-vocab.txt")
 	if vocabReadErr != nil {
 		panic(vocabReadErr)
 	}
@@ -1,84 +1,346 @@
 package test
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/valyala/fasthttp"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+	"github.com/sunhailin-Leo/triton-service-go/tritontest"
 )
 
-func TestTritonHTTPClientForCheckModelReady(t *testing.T) {
-	srv := nvidia_inferenceserver.NewTritonClientForAll(
-		"<Your Triton HTTP Host>:<Your Triton HTTP Port>", &fasthttp.Client{}, nil)
-	isReady, err := srv.CheckModelReady("<Your Model Name>", "<Your Model Version>", 1*time.Second)
+const (
+	tModelName    string = "bert"
+	tModelVersion string = "1"
+)
+
+// startFakeTriton start fake triton server with models, server is closed when test finished
+func startFakeTriton(t *testing.T, models ...*tritontest.Model) *tritontest.Server {
+	server, err := tritontest.StartServer(models...)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newFakeGRPCClient create grpc client of fake triton server
+func newFakeGRPCClient(t *testing.T, server *tritontest.Server) *nvidia_inferenceserver.TritonClientService {
+	client, err := server.NewGRPCClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.ShutdownTritonConnection() })
+	return client
+}
+
+// testModelConfig config of test model
+func testModelConfig() *nvidia_inferenceserver.ModelConfig {
+	return &nvidia_inferenceserver.ModelConfig{
+		Name:         tModelName,
+		Platform:     "onnxruntime_onnx",
+		MaxBatchSize: 8,
+		Input: []*nvidia_inferenceserver.ModelInput{
+			{Name: "input_ids", DataType: nvidia_inferenceserver.DataType_TYPE_INT32, Dims: []int64{-1}},
+		},
+		Output: []*nvidia_inferenceserver.ModelOutput{
+			{Name: "probability", DataType: nvidia_inferenceserver.DataType_TYPE_FP32, Dims: []int64{2}},
+		},
+	}
+}
+
+func TestTritonHTTPClientForCheckModelReady(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	srv := nvidia_inferenceserver.NewTritonClientForAll(server.HTTPAddr(), &fasthttp.Client{}, nil)
+	isReady, err := srv.CheckModelReady(tModelName, tModelVersion, 1*time.Second)
+	if err != nil || !isReady {
+		t.Fatalf("expect model ready, got %v %v", isReady, err)
+	}
+	server.SetModelReady(tModelName, false)
+	if isReady, _ = srv.CheckModelReady(tModelName, tModelVersion, 1*time.Second); isReady {
+		t.Fatal("expect model not ready")
 	}
-	fmt.Println(isReady)
 }
 
 func TestTritonGRPCClientForCheckModelReady(t *testing.T) {
-	defaultGRPCClient, grpcErr := grpc.Dial("<Your Triton GRPC Host>:<Your Triton GRPC Port>",
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if grpcErr != nil {
-		panic(grpcErr)
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	srv := newFakeGRPCClient(t, server)
+	isReady, err := srv.CheckModelReady(tModelName, tModelVersion, 1*time.Second)
+	if err != nil || !isReady {
+		t.Fatalf("expect model ready, got %v %v", isReady, err)
 	}
-	srv := nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(defaultGRPCClient)
-	isReady, err := srv.CheckModelReady("<Your Model Name>", "<Your Model Version>", 1*time.Second)
-	if err != nil {
-		panic(err)
+	server.SetModelReady(tModelName, false)
+	if isReady, err = srv.CheckModelReady(tModelName, tModelVersion, 1*time.Second); err != nil || isReady {
+		t.Fatalf("expect model not ready, got %v %v", isReady, err)
 	}
-	fmt.Println(isReady)
 }
 
 func TestTritonHTTPClientInit(t *testing.T) {
-	client := &fasthttp.Client{}
-	trtClient := nvidia_inferenceserver.NewTritonClientWithOnlyHttp("<Your Triton HTTP Host>:<Your Triton HTTP Port>", client)
+	server := startFakeTriton(t)
+	trtClient := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(server.HTTPAddr(), &fasthttp.Client{})
 	isReady, err := trtClient.CheckServerReady(1 * time.Second)
-	if err != nil {
-		panic(err)
+	if err != nil || !isReady {
+		t.Fatalf("expect server ready, got %v %v", isReady, err)
+	}
+	server.SetServerReady(false)
+	if isReady, _ = trtClient.CheckServerReady(1 * time.Second); isReady {
+		t.Fatal("expect server not ready")
 	}
-	fmt.Println(isReady)
 }
 
 func TestTritonGRPCClientInit(t *testing.T) {
-	grpcConn, err := grpc.Dial("<Your Triton GRPC Host>:<Your Triton GRPC Port>", grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		panic(err)
-	}
-	trtClient := nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(grpcConn)
+	server := startFakeTriton(t)
+	trtClient := newFakeGRPCClient(t, server)
 	isReady, err := trtClient.CheckServerReady(1 * time.Second)
-	if err != nil {
-		panic(err)
+	if err != nil || !isReady {
+		t.Fatalf("expect server ready, got %v %v", isReady, err)
+	}
+	isLive, err := trtClient.CheckServerAlive(1 * time.Second)
+	if err != nil || !isLive {
+		t.Fatalf("expect server live, got %v %v", isLive, err)
 	}
-	fmt.Println(isReady)
 }
 
 func TestTritonAllClientInit(t *testing.T) {
-	grpcConn, err := grpc.Dial("<Your Triton GRPC Host>:<Your Triton GRPC Port>", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	server := startFakeTriton(t)
+	grpcConn, err := server.DialGRPC()
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
-	httpClient := &fasthttp.Client{}
-	trtClient := nvidia_inferenceserver.NewTritonClientForAll(
-		"<Your Triton HTTP Host>:<Your Triton HTTP Port>", httpClient, grpcConn)
+	trtClient := nvidia_inferenceserver.NewTritonClientForAll(server.HTTPAddr(), &fasthttp.Client{}, grpcConn)
+	defer trtClient.ShutdownTritonConnection()
 	isReady, err := trtClient.CheckServerReady(1 * time.Second)
-	if err != nil {
-		panic(err)
+	if err != nil || !isReady {
+		t.Fatalf("expect server ready, got %v %v", isReady, err)
 	}
-	fmt.Println(isReady)
 }
 
 func TestGetTritonModelConfig(t *testing.T) {
-	srv := nvidia_inferenceserver.NewTritonClientWithOnlyHttp("<Your Triton GRPC Host>:<Your Triton GRPC Port>", &fasthttp.Client{})
-	modelConfig, err := srv.ModelConfiguration("<Your Model Name>", "<Your Model Version>", 1*time.Second)
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: testModelConfig()})
+	for _, srv := range []*nvidia_inferenceserver.TritonClientService{
+		nvidia_inferenceserver.NewTritonClientWithOnlyHttp(server.HTTPAddr(), &fasthttp.Client{}),
+		newFakeGRPCClient(t, server),
+	} {
+		modelConfig, err := srv.ModelConfiguration(tModelName, tModelVersion, 1*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if modelConfig.Config.MaxBatchSize != 8 ||
+			modelConfig.Config.Input[0].DataType != nvidia_inferenceserver.DataType_TYPE_INT32 {
+			t.Fatalf("unexpected model config: %v", modelConfig.Config)
+		}
+	}
+}
+
+func TestTritonHTTPUnknownModelStatus(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	httpClient := server.NewHTTPClient()
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+
+	// triton report unknown model with 400 and {"error": "..."} body
+	_, err := httpClient.ModelHTTPInferCtx(context.Background(), []byte(`{"inputs":[]}`), "unknown", "", decoder)
+	var tritonErr *nvidia_inferenceserver.TritonError
+	if !errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) || !errors.As(err, &tritonErr) {
+		t.Fatalf("expect ErrModelNotFound, got %v", err)
+	}
+	if tritonErr.StatusCode != fasthttp.StatusBadRequest || tritonErr.Message == "" {
+		t.Fatalf("expect 400 with error message, got %+v", tritonErr)
+	}
+
+	// unknown URL path is 404
+	_, err = httpClient.ModelHTTPInferCtx(context.Background(), []byte(`{"inputs":[]}`), tModelName, "1/extra", decoder)
+	if !errors.As(err, &tritonErr) || tritonErr.StatusCode != fasthttp.StatusNotFound ||
+		errors.Is(err, nvidia_inferenceserver.ErrModelNotFound) {
+		t.Fatalf("expect 404 which is not ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestGetTritonModelMetadata(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Config: testModelConfig()})
+	for _, srv := range []*nvidia_inferenceserver.TritonClientService{
+		nvidia_inferenceserver.NewTritonClientWithOnlyHttp(server.HTTPAddr(), &fasthttp.Client{}),
+		newFakeGRPCClient(t, server),
+	} {
+		metadata, err := srv.ModelMetadataRequest(tModelName, tModelVersion, 1*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if metadata.Inputs[0].Datatype != "INT32" || metadata.Outputs[0].Name != "probability" {
+			t.Fatalf("unexpected model metadata: %v", metadata)
+		}
+		if _, err = srv.ModelInferStats(tModelName, tModelVersion, 1*time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTritonModelLoadAndUnload(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	srv := nvidia_inferenceserver.NewTritonClientWithOnlyHttp(server.HTTPAddr(), &fasthttp.Client{})
+	if _, err := srv.ModelUnloadWithHTTP(tModelName, nil, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	index, err := srv.ModelIndex("", true, 1*time.Second)
+	if err != nil || len(index.Models) != 0 {
+		t.Fatalf("expect no ready model, got %v %v", index, err)
+	}
+	if _, err = srv.ModelLoadWithHTTP(tModelName, nil, 1*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if index, err = srv.ModelIndex("", true, 1*time.Second); err != nil || len(index.Models) != 1 {
+		t.Fatalf("expect one ready model, got %v %v", index, err)
+	}
+}
+
+func TestTritonInferAllTransports(t *testing.T) {
+	probability, _ := nvidia_inferenceserver.NewNumericTensor("probability", []int64{1, 2}, []float32{0.25, 0.75})
+	server := startFakeTriton(t, &tritontest.Model{
+		Name: tModelName, Handler: tritontest.StaticOutputHandler(probability),
+	})
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+
+	var result []float32
+	decoder := nvidia_inferenceserver.NewInferResultDecoder(
+		func(inferResult *nvidia_inferenceserver.InferResult, params ...interface{}) ([]interface{}, error) {
+			output, err := inferResult.Output("probability")
+			if err != nil {
+				return nil, err
+			}
+			result, err = output.AsFloat32()
+			return nil, err
+		})
+	inputIds, _ := nvidia_inferenceserver.NewNumericTensor("input_ids", []int64{1, 2}, []int32{101, 102})
+	inputs, rawInputs := nvidia_inferenceserver.BuildGRPCInferInputs(inputIds)
+	outputs := []*nvidia_inferenceserver.ModelInferRequest_InferRequestedOutputTensor{{Name: "probability"}}
+	requestBody, _, err := nvidia_inferenceserver.BuildHTTPInferRequestBody(
+		[]*nvidia_inferenceserver.InferTensor{inputIds}, outputs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for name, infer := range map[string]func() error{
+		"grpc": func() error {
+			_, inferErr := grpcClient.ModelGRPCInferCtx(ctx, inputs, outputs, rawInputs, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+		"http": func() error {
+			_, inferErr := httpClient.ModelHTTPInferCtx(ctx, requestBody, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+		"http-binary": func() error {
+			_, inferErr := httpClient.ModelHTTPBinaryInferCtx(ctx, inputs, outputs, rawInputs, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+	} {
+		result = nil
+		if err = infer(); err != nil {
+			t.Fatalf("%s infer error: %v", name, err)
+		}
+		if len(result) != 2 || result[0] != 0.25 || result[1] != 0.75 {
+			t.Fatalf("%s unexpected result: %v", name, result)
+		}
+	}
+	if server.InferCount(tModelName) != 3 {
+		t.Fatalf("expect 3 infer requests, got %d", server.InferCount(tModelName))
+	}
+}
+
+func TestTritonHTTPDeadlineThenBackground(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName})
+	httpClient := server.NewHTTPClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := httpClient.CheckServerReadyCtx(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// deadline of the former request must not stay on the reused connection
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if _, err := httpClient.CheckServerReadyCtx(context.Background()); err != nil {
+			t.Fatalf("background request after deadline request error: %v", err)
+		}
+	}
+
+	// transport error has no status code
+	server.Close()
+	_, err := httpClient.CheckServerReadyCtx(context.Background())
+	var tritonErr *nvidia_inferenceserver.TritonError
+	if !errors.As(err, &tritonErr) || tritonErr.StatusCode != 0 {
+		t.Fatalf("expect transport error with status code 0, got %v", err)
+	}
+}
+
+func TestTritonInferContextCancel(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{Name: tModelName, Latency: time.Second})
+	grpcClient := newFakeGRPCClient(t, server)
+	httpClient := server.NewHTTPClient()
+	decoder := func(response interface{}, params ...interface{}) ([]interface{}, error) { return nil, nil }
+
+	for name, infer := range map[string]func(ctx context.Context) error{
+		"grpc": func(ctx context.Context) error {
+			_, inferErr := grpcClient.ModelGRPCInferCtx(ctx, nil, nil, nil, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+		"http": func(ctx context.Context) error {
+			_, inferErr := httpClient.ModelHTTPInferCtx(ctx, []byte(`{"inputs":[]}`), tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+		"http-binary": func(ctx context.Context) error {
+			_, inferErr := httpClient.ModelHTTPBinaryInferCtx(ctx, nil, nil, nil, tModelName, tModelVersion, decoder)
+			return inferErr
+		},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		start := time.Now()
+		err := infer(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s expect context canceled, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("%s in-flight infer is not stopped by cancel, took %v", name, elapsed)
+		}
+	}
+}
+
+func TestTritonStreamInferSession(t *testing.T) {
+	server := startFakeTriton(t, &tritontest.Model{
+		Name: tModelName,
+		Handler: func(_ context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+			return &nvidia_inferenceserver.ModelInferResponse{}, nil
+		},
+	})
+	srv := newFakeGRPCClient(t, server)
+	session, err := srv.NewModelStreamInferSession(context.Background(), nil)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for _, requestID := range []string{"req-1", "req-2"} {
+		if err = session.Send(&nvidia_inferenceserver.ModelInferRequest{
+			ModelName: tModelName, ModelVersion: tModelVersion, Id: requestID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = session.Send(&nvidia_inferenceserver.ModelInferRequest{ModelName: "unknown", Id: "req-3"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, requestID := range []string{"req-1", "req-2", "req-3"} {
+		select {
+		case result := <-session.Responses():
+			if result.RequestID != requestID || (requestID == "req-3") != (result.Err != nil) {
+				t.Fatalf("unexpected stream result of %s: %+v", requestID, result)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wait response of %s timeout", requestID)
+		}
 	}
-	fmt.Println(modelConfig)
 }
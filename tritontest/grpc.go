@@ -0,0 +1,205 @@
+package tritontest
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// grpcService GRPCInferenceServiceServer implementation of fake server
+type grpcService struct {
+	nvidia_inferenceserver.UnimplementedGRPCInferenceServiceServer
+
+	server *Server
+}
+
+// ServerLive server liveness
+func (g *grpcService) ServerLive(
+	ctx context.Context, _ *nvidia_inferenceserver.ServerLiveRequest,
+) (*nvidia_inferenceserver.ServerLiveResponse, error) {
+	isLive, liveErr := g.server.isServerLive(ctx)
+	if liveErr != nil {
+		return nil, toStatusError(liveErr)
+	}
+	return &nvidia_inferenceserver.ServerLiveResponse{Live: isLive}, nil
+}
+
+// ServerReady server readiness
+func (g *grpcService) ServerReady(
+	ctx context.Context, _ *nvidia_inferenceserver.ServerReadyRequest,
+) (*nvidia_inferenceserver.ServerReadyResponse, error) {
+	isReady, readyErr := g.server.isServerReady(ctx)
+	if readyErr != nil {
+		return nil, toStatusError(readyErr)
+	}
+	return &nvidia_inferenceserver.ServerReadyResponse{Ready: isReady}, nil
+}
+
+// ModelReady model readiness
+func (g *grpcService) ModelReady(
+	ctx context.Context, request *nvidia_inferenceserver.ModelReadyRequest,
+) (*nvidia_inferenceserver.ModelReadyResponse, error) {
+	isReady, readyErr := g.server.isModelReady(ctx, request.Name, request.Version)
+	if readyErr != nil {
+		return nil, toStatusError(readyErr)
+	}
+	return &nvidia_inferenceserver.ModelReadyResponse{Ready: isReady}, nil
+}
+
+// ServerMetadata server metadata
+func (g *grpcService) ServerMetadata(
+	ctx context.Context, _ *nvidia_inferenceserver.ServerMetadataRequest,
+) (*nvidia_inferenceserver.ServerMetadataResponse, error) {
+	metadata, metadataErr := g.server.serverMetadata(ctx)
+	if metadataErr != nil {
+		return nil, toStatusError(metadataErr)
+	}
+	return metadata, nil
+}
+
+// ModelMetadata model metadata
+func (g *grpcService) ModelMetadata(
+	ctx context.Context, request *nvidia_inferenceserver.ModelMetadataRequest,
+) (*nvidia_inferenceserver.ModelMetadataResponse, error) {
+	metadata, metadataErr := g.server.modelMetadata(ctx, request.Name, request.Version)
+	if metadataErr != nil {
+		return nil, toStatusError(metadataErr)
+	}
+	return metadata, nil
+}
+
+// ModelInfer call model handler
+func (g *grpcService) ModelInfer(
+	ctx context.Context, request *nvidia_inferenceserver.ModelInferRequest,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	response, inferErr := g.server.infer(ctx, request)
+	if inferErr != nil {
+		return nil, toStatusError(inferErr)
+	}
+	return response, nil
+}
+
+// ModelStreamInfer call model handler for every request of stream, errors are sent as error_message.
+// Response is marked with triton_final_response if request enables triton_enable_empty_final_response.
+func (g *grpcService) ModelStreamInfer(stream nvidia_inferenceserver.GRPCInferenceService_ModelStreamInferServer) error {
+	for {
+		request, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			return nil
+		}
+		if recvErr != nil {
+			return recvErr
+		}
+		streamResponse := new(nvidia_inferenceserver.ModelStreamInferResponse)
+		response, inferErr := g.server.infer(stream.Context(), request)
+		if inferErr != nil {
+			streamResponse.ErrorMessage = status.Convert(toStatusError(inferErr)).Message()
+			streamResponse.InferResponse = &nvidia_inferenceserver.ModelInferResponse{
+				ModelName: request.ModelName, ModelVersion: request.ModelVersion, Id: request.Id,
+			}
+		} else {
+			streamResponse.InferResponse = response
+		}
+		enableFinalParam := request.Parameters[nvidia_inferenceserver.StreamEnableEmptyFinalResponseParamKey]
+		if enableFinalParam.GetBoolParam() {
+			if streamResponse.InferResponse.Parameters == nil {
+				streamResponse.InferResponse.Parameters = make(map[string]*nvidia_inferenceserver.InferParameter)
+			}
+			streamResponse.InferResponse.Parameters[nvidia_inferenceserver.StreamFinalResponseParamKey] =
+				&nvidia_inferenceserver.InferParameter{
+					ParameterChoice: &nvidia_inferenceserver.InferParameter_BoolParam{BoolParam: true},
+				}
+		}
+		if sendErr := stream.Send(streamResponse); sendErr != nil {
+			return sendErr
+		}
+	}
+}
+
+// ModelConfig model config
+func (g *grpcService) ModelConfig(
+	ctx context.Context, request *nvidia_inferenceserver.ModelConfigRequest,
+) (*nvidia_inferenceserver.ModelConfigResponse, error) {
+	config, configErr := g.server.modelConfig(ctx, request.Name, request.Version)
+	if configErr != nil {
+		return nil, toStatusError(configErr)
+	}
+	return &nvidia_inferenceserver.ModelConfigResponse{Config: config}, nil
+}
+
+// ModelStatistics model infer statistics
+func (g *grpcService) ModelStatistics(
+	ctx context.Context, request *nvidia_inferenceserver.ModelStatisticsRequest,
+) (*nvidia_inferenceserver.ModelStatisticsResponse, error) {
+	stats, statsErr := g.server.modelStatistics(ctx, request.Name, request.Version)
+	if statsErr != nil {
+		return nil, toStatusError(statsErr)
+	}
+	return stats, nil
+}
+
+// RepositoryIndex index of models
+func (g *grpcService) RepositoryIndex(
+	ctx context.Context, request *nvidia_inferenceserver.RepositoryIndexRequest,
+) (*nvidia_inferenceserver.RepositoryIndexResponse, error) {
+	index, indexErr := g.server.repositoryIndex(ctx, request.Ready)
+	if indexErr != nil {
+		return nil, toStatusError(indexErr)
+	}
+	return &nvidia_inferenceserver.RepositoryIndexResponse{Models: index}, nil
+}
+
+// RepositoryModelLoad mark model ready
+func (g *grpcService) RepositoryModelLoad(
+	ctx context.Context, request *nvidia_inferenceserver.RepositoryModelLoadRequest,
+) (*nvidia_inferenceserver.RepositoryModelLoadResponse, error) {
+	if loadErr := g.server.loadModel(ctx, request.ModelName, true, request.Parameters); loadErr != nil {
+		return nil, toStatusError(loadErr)
+	}
+	return &nvidia_inferenceserver.RepositoryModelLoadResponse{}, nil
+}
+
+// RepositoryModelUnload mark model not ready
+func (g *grpcService) RepositoryModelUnload(
+	ctx context.Context, request *nvidia_inferenceserver.RepositoryModelUnloadRequest,
+) (*nvidia_inferenceserver.RepositoryModelUnloadResponse, error) {
+	if unloadErr := g.server.loadModel(ctx, request.ModelName, false, request.Parameters); unloadErr != nil {
+		return nil, toStatusError(unloadErr)
+	}
+	return &nvidia_inferenceserver.RepositoryModelUnloadResponse{}, nil
+}
+
+// SystemSharedMemoryStatus status of registered system shared memory
+func (g *grpcService) SystemSharedMemoryStatus(
+	ctx context.Context, request *nvidia_inferenceserver.SystemSharedMemoryStatusRequest,
+) (*nvidia_inferenceserver.SystemSharedMemoryStatusResponse, error) {
+	regions, statusErr := g.server.systemSharedMemoryStatus(ctx, request.Name)
+	if statusErr != nil {
+		return nil, toStatusError(statusErr)
+	}
+	return &nvidia_inferenceserver.SystemSharedMemoryStatusResponse{Regions: regions}, nil
+}
+
+// SystemSharedMemoryRegister record system shared memory region
+func (g *grpcService) SystemSharedMemoryRegister(
+	ctx context.Context, request *nvidia_inferenceserver.SystemSharedMemoryRegisterRequest,
+) (*nvidia_inferenceserver.SystemSharedMemoryRegisterResponse, error) {
+	if registerErr := g.server.registerSystemSharedMemory(
+		ctx, request.Name, request.Key, request.Offset, request.ByteSize); registerErr != nil {
+		return nil, toStatusError(registerErr)
+	}
+	return &nvidia_inferenceserver.SystemSharedMemoryRegisterResponse{}, nil
+}
+
+// SystemSharedMemoryUnregister remove system shared memory region
+func (g *grpcService) SystemSharedMemoryUnregister(
+	ctx context.Context, request *nvidia_inferenceserver.SystemSharedMemoryUnregisterRequest,
+) (*nvidia_inferenceserver.SystemSharedMemoryUnregisterResponse, error) {
+	if unregisterErr := g.server.unregisterSystemSharedMemory(ctx, request.Name); unregisterErr != nil {
+		return nil, toStatusError(unregisterErr)
+	}
+	return &nvidia_inferenceserver.SystemSharedMemoryUnregisterResponse{}, nil
+}
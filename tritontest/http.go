@@ -0,0 +1,354 @@
+package tritontest
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// grpcCodeToHTTPStatus map grpc status code to http status code like triton does,
+// triton report unknown model / resource with 400, 404 is only for unknown URL path (writeHTTPNotFound)
+func grpcCodeToHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange, codes.NotFound:
+		return fasthttp.StatusBadRequest
+	case codes.AlreadyExists:
+		return fasthttp.StatusConflict
+	case codes.Unauthenticated:
+		return fasthttp.StatusUnauthorized
+	case codes.PermissionDenied:
+		return fasthttp.StatusForbidden
+	case codes.ResourceExhausted:
+		return fasthttp.StatusTooManyRequests
+	case codes.Unavailable:
+		return fasthttp.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return fasthttp.StatusGatewayTimeout
+	case codes.Unimplemented:
+		return fasthttp.StatusNotImplemented
+	}
+	return fasthttp.StatusInternalServerError
+}
+
+// writeHTTPError write triton error response {"error": "..."}
+func writeHTTPError(ctx *fasthttp.RequestCtx, err error) {
+	errStatus := status.Convert(toStatusError(err))
+	body, _ := json.Marshal(&nvidia_inferenceserver.ErrorResponseHTTPObj{Error: errStatus.Message()})
+	ctx.SetStatusCode(grpcCodeToHTTPStatus(errStatus.Code()))
+	ctx.SetContentType(nvidia_inferenceserver.JsonContentType)
+	ctx.SetBody(body)
+}
+
+// writeHTTPNotFound write 404 response of unknown URL path
+func writeHTTPNotFound(ctx *fasthttp.RequestCtx) {
+	body, _ := json.Marshal(&nvidia_inferenceserver.ErrorResponseHTTPObj{Error: "Not Found"})
+	ctx.SetStatusCode(fasthttp.StatusNotFound)
+	ctx.SetContentType(nvidia_inferenceserver.JsonContentType)
+	ctx.SetBody(body)
+}
+
+// writeHTTPJSON write json response
+func writeHTTPJSON(ctx *fasthttp.RequestCtx, value interface{}) {
+	body, jsonEncodeErr := json.Marshal(value)
+	if jsonEncodeErr != nil {
+		writeHTTPError(ctx, jsonEncodeErr)
+		return
+	}
+	ctx.SetContentType(nvidia_inferenceserver.JsonContentType)
+	ctx.SetBody(body)
+}
+
+// writeHTTPReady write 200 for ready and 400 for not ready
+func writeHTTPReady(ctx *fasthttp.RequestCtx, isReady bool, readyErr error) {
+	if readyErr != nil {
+		writeHTTPError(ctx, readyErr)
+		return
+	}
+	if !isReady {
+		ctx.SetStatusCode(fasthttp.StatusBadRequest)
+	}
+}
+
+// handleHTTP route KServe v2 http api
+func (s *Server) handleHTTP(ctx *fasthttp.RequestCtx) {
+	parts := strings.Split(strings.Trim(string(ctx.Path()), "/"), "/")
+	if parts[0] != "v2" {
+		writeHTTPNotFound(ctx)
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		metadata, metadataErr := s.serverMetadata(ctx)
+		if metadataErr != nil {
+			writeHTTPError(ctx, metadataErr)
+			return
+		}
+		writeHTTPJSON(ctx, metadata)
+	case len(parts) == 3 && parts[1] == "health" && parts[2] == "live":
+		isLive, liveErr := s.isServerLive(ctx)
+		writeHTTPReady(ctx, isLive, liveErr)
+	case len(parts) == 3 && parts[1] == "health" && parts[2] == "ready":
+		isReady, readyErr := s.isServerReady(ctx)
+		writeHTTPReady(ctx, isReady, readyErr)
+	case len(parts) == 3 && parts[1] == "repository" && parts[2] == "index":
+		s.handleHTTPRepositoryIndex(ctx)
+	case len(parts) == 5 && parts[1] == "repository" && parts[2] == "models" && (parts[4] == "load" || parts[4] == "unload"):
+		s.handleHTTPRepositoryModel(ctx, parts[3], parts[4] == "load")
+	case len(parts) >= 3 && parts[1] == "systemsharedmemory":
+		s.handleHTTPSystemSharedMemory(ctx, parts[2:])
+	case len(parts) == 3 && parts[1] == "models" && parts[2] == "stats":
+		s.handleHTTPModelStatistics(ctx, "", "")
+	case len(parts) >= 3 && parts[1] == "models":
+		s.handleHTTPModel(ctx, parts[2], parts[3:])
+	default:
+		writeHTTPNotFound(ctx)
+	}
+}
+
+// handleHTTPModel route /v2/models/{name}[/versions/{version}][/ready|/config|/stats|/infer]
+func (s *Server) handleHTTPModel(ctx *fasthttp.RequestCtx, modelName string, parts []string) {
+	modelVersion := ""
+	if len(parts) >= 2 && parts[0] == "versions" {
+		modelVersion, parts = parts[1], parts[2:]
+	}
+	action := ""
+	if len(parts) == 1 {
+		action = parts[0]
+	} else if len(parts) > 1 {
+		writeHTTPNotFound(ctx)
+		return
+	}
+	switch action {
+	case "":
+		metadata, metadataErr := s.modelMetadata(ctx, modelName, modelVersion)
+		if metadataErr != nil {
+			writeHTTPError(ctx, metadataErr)
+			return
+		}
+		writeHTTPJSON(ctx, metadata)
+	case "ready":
+		isReady, readyErr := s.isModelReady(ctx, modelName, modelVersion)
+		writeHTTPReady(ctx, isReady, readyErr)
+	case "config":
+		config, configErr := s.modelConfig(ctx, modelName, modelVersion)
+		if configErr != nil {
+			writeHTTPError(ctx, configErr)
+			return
+		}
+		body, jsonEncodeErr := protojson.MarshalOptions{UseProtoNames: true}.Marshal(config)
+		if jsonEncodeErr != nil {
+			writeHTTPError(ctx, jsonEncodeErr)
+			return
+		}
+		ctx.SetContentType(nvidia_inferenceserver.JsonContentType)
+		ctx.SetBody(body)
+	case "stats":
+		s.handleHTTPModelStatistics(ctx, modelName, modelVersion)
+	case "infer":
+		s.handleHTTPInfer(ctx, modelName, modelVersion)
+	default:
+		writeHTTPNotFound(ctx)
+	}
+}
+
+// handleHTTPModelStatistics write model statistics
+func (s *Server) handleHTTPModelStatistics(ctx *fasthttp.RequestCtx, modelName, modelVersion string) {
+	stats, statsErr := s.modelStatistics(ctx, modelName, modelVersion)
+	if statsErr != nil {
+		writeHTTPError(ctx, statsErr)
+		return
+	}
+	writeHTTPJSON(ctx, stats)
+}
+
+// handleHTTPRepositoryIndex write model index as json array
+func (s *Server) handleHTTPRepositoryIndex(ctx *fasthttp.RequestCtx) {
+	requestObj := new(nvidia_inferenceserver.ModelIndexRequestHTTPObj)
+	if len(ctx.PostBody()) > 0 {
+		if jsonDecodeErr := json.Unmarshal(ctx.PostBody(), requestObj); jsonDecodeErr != nil {
+			writeHTTPError(ctx, status.Error(codes.InvalidArgument, jsonDecodeErr.Error()))
+			return
+		}
+	}
+	index, indexErr := s.repositoryIndex(ctx, requestObj.Ready)
+	if indexErr != nil {
+		writeHTTPError(ctx, indexErr)
+		return
+	}
+	writeHTTPJSON(ctx, index)
+}
+
+// handleHTTPInfer decode json / binary infer request, call model handler and encode response as requested
+func (s *Server) handleHTTPInfer(ctx *fasthttp.RequestCtx, modelName, modelVersion string) {
+	headerLength, _ := strconv.Atoi(string(ctx.Request.Header.Peek(nvidia_inferenceserver.InferHeaderContentLengthKey)))
+	request, decodeErr := nvidia_inferenceserver.DecodeHTTPBinaryInferRequest(ctx.PostBody(), headerLength)
+	if decodeErr != nil {
+		writeHTTPError(ctx, status.Error(codes.InvalidArgument, decodeErr.Error()))
+		return
+	}
+	request.ModelName, request.ModelVersion = modelName, modelVersion
+	// request headers are passed to model handler as incoming metadata like grpc
+	requestMetadata := metadata.MD{}
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		requestMetadata.Append(string(key), string(value))
+	})
+	response, inferErr := s.infer(metadata.NewIncomingContext(ctx, requestMetadata), request)
+	if inferErr != nil {
+		writeHTTPError(ctx, inferErr)
+		return
+	}
+	httpResponse, convertErr := toHTTPInferResponse(request, response)
+	if convertErr != nil {
+		writeHTTPError(ctx, convertErr)
+		return
+	}
+	body, responseHeaderLength, encodeErr := nvidia_inferenceserver.EncodeHTTPBinaryInferResponse(httpResponse)
+	if encodeErr != nil {
+		writeHTTPError(ctx, encodeErr)
+		return
+	}
+	if responseHeaderLength > 0 {
+		ctx.SetContentType(nvidia_inferenceserver.OctetStreamContentType)
+		ctx.Response.Header.Set(nvidia_inferenceserver.InferHeaderContentLengthKey, strconv.Itoa(responseHeaderLength))
+	} else {
+		ctx.SetContentType(nvidia_inferenceserver.JsonContentType)
+	}
+	ctx.SetBody(body)
+}
+
+// toHTTPInferResponse keep requested outputs only, outputs requested with binary_data=true use raw contents,
+// others use json data.
+func toHTTPInferResponse(
+	request *nvidia_inferenceserver.ModelInferRequest, response *nvidia_inferenceserver.ModelInferResponse,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	binaryOutput := make(map[string]bool, len(request.Outputs))
+	for _, output := range request.Outputs {
+		binaryOutput[output.Name] = output.Parameters[nvidia_inferenceserver.BinaryDataParamKey].GetBoolParam()
+	}
+	httpResponse := &nvidia_inferenceserver.ModelInferResponse{
+		ModelName:    response.ModelName,
+		ModelVersion: response.ModelVersion,
+		Id:           response.Id,
+		Parameters:   response.Parameters,
+	}
+	hasBinaryOutput := false
+	var rawOutputs [][]byte
+	for i, output := range response.Outputs {
+		isBinary, isRequested := binaryOutput[output.Name]
+		if len(request.Outputs) > 0 && !isRequested {
+			continue
+		}
+		var raw []byte
+		if i < len(response.RawOutputContents) {
+			raw = response.RawOutputContents[i]
+		}
+		httpOutput := &nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+			Name:       output.Name,
+			Datatype:   output.Datatype,
+			Shape:      output.Shape,
+			Parameters: output.Parameters,
+			Contents:   output.Contents,
+		}
+		if raw != nil && !isBinary {
+			contents, convertErr := nvidia_inferenceserver.RawContentsToInferTensorContents(output.Datatype, raw)
+			if convertErr != nil {
+				return nil, status.Error(codes.Internal, convertErr.Error())
+			}
+			httpOutput.Contents, raw = contents, nil
+		}
+		hasBinaryOutput = hasBinaryOutput || raw != nil
+		httpResponse.Outputs = append(httpResponse.Outputs, httpOutput)
+		rawOutputs = append(rawOutputs, raw)
+	}
+	if hasBinaryOutput {
+		httpResponse.RawOutputContents = rawOutputs
+	}
+	return httpResponse, nil
+}
+
+// handleHTTPSystemSharedMemory route /v2/systemsharedmemory[/region/{name}]/status|register|unregister
+func (s *Server) handleHTTPSystemSharedMemory(ctx *fasthttp.RequestCtx, parts []string) {
+	regionName := ""
+	if len(parts) == 3 && parts[0] == "region" {
+		regionName, parts = parts[1], parts[2:]
+	}
+	if len(parts) != 1 {
+		writeHTTPNotFound(ctx)
+		return
+	}
+	switch parts[0] {
+	case "status":
+		regions, statusErr := s.systemSharedMemoryStatus(ctx, regionName)
+		if statusErr != nil {
+			writeHTTPError(ctx, statusErr)
+			return
+		}
+		writeHTTPJSON(ctx, sortedSystemSharedMemoryRegions(regions))
+	case "register":
+		requestObj := new(nvidia_inferenceserver.SystemMemoryRegisterBodyHTTPObj)
+		if jsonDecodeErr := json.Unmarshal(ctx.PostBody(), requestObj); jsonDecodeErr != nil {
+			writeHTTPError(ctx, status.Error(codes.InvalidArgument, jsonDecodeErr.Error()))
+			return
+		}
+		if registerErr := s.registerSystemSharedMemory(
+			ctx, regionName, requestObj.Key, requestObj.Offset, requestObj.ByteSize); registerErr != nil {
+			writeHTTPError(ctx, registerErr)
+		}
+	case "unregister":
+		if unregisterErr := s.unregisterSystemSharedMemory(ctx, regionName); unregisterErr != nil {
+			writeHTTPError(ctx, unregisterErr)
+		}
+	default:
+		writeHTTPNotFound(ctx)
+	}
+}
+
+// handleHTTPRepositoryModel load / unload model with body {"parameters": {...}},
+// "file:" parameters are base64 encoded bytes
+func (s *Server) handleHTTPRepositoryModel(ctx *fasthttp.RequestCtx, modelName string, isLoad bool) {
+	var params map[string]*nvidia_inferenceserver.ModelRepositoryParameter
+	if body := ctx.PostBody(); len(body) > 0 {
+		requestObj := new(nvidia_inferenceserver.ModelRepositoryRequestHTTPObj)
+		if jsonDecodeErr := json.Unmarshal(body, requestObj); jsonDecodeErr != nil {
+			writeHTTPError(ctx, status.Error(codes.InvalidArgument, jsonDecodeErr.Error()))
+			return
+		}
+		params = make(map[string]*nvidia_inferenceserver.ModelRepositoryParameter, len(requestObj.Parameters))
+		for key, value := range requestObj.Parameters {
+			param := new(nvidia_inferenceserver.ModelRepositoryParameter)
+			switch v := value.(type) {
+			case bool:
+				param.ParameterChoice = &nvidia_inferenceserver.ModelRepositoryParameter_BoolParam{BoolParam: v}
+			case float64:
+				param.ParameterChoice = &nvidia_inferenceserver.ModelRepositoryParameter_Int64Param{Int64Param: int64(v)}
+			case string:
+				if !strings.HasPrefix(key, nvidia_inferenceserver.ModelLoadFileParamPrefix) {
+					param.ParameterChoice = &nvidia_inferenceserver.ModelRepositoryParameter_StringParam{StringParam: v}
+					break
+				}
+				content, decodeErr := base64.StdEncoding.DecodeString(v)
+				if decodeErr != nil {
+					writeHTTPError(ctx, status.Error(codes.InvalidArgument, "invalid base64 of '"+key+"'"))
+					return
+				}
+				param.ParameterChoice = &nvidia_inferenceserver.ModelRepositoryParameter_BytesParam{BytesParam: content}
+			default:
+				writeHTTPError(ctx, status.Error(codes.InvalidArgument, "unsupported type of parameter '"+key+"'"))
+				return
+			}
+			params[key] = param
+		}
+	}
+	if loadErr := s.loadModel(ctx, modelName, isLoad, params); loadErr != nil {
+		writeHTTPError(ctx, loadErr)
+	}
+}
@@ -0,0 +1,627 @@
+// Package tritontest provides an in-process fake Triton Inference Server (KServe v2 HTTP and GRPC protocol)
+// with programmable models, readiness toggles, injected latency and errors, for hermetic tests.
+package tritontest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+const (
+	DefaultServerName    string = "tritontest"
+	DefaultServerVersion string = "0.0.0"
+	DefaultModelVersion  string = "1"
+	localListenAddress   string = "127.0.0.1:0"
+)
+
+// ModelHandler handle infer request of model, return grpc status error (status.Error) to set error code.
+// Request headers of HTTP and metadata of GRPC can be read by metadata.FromIncomingContext(ctx).
+type ModelHandler func(ctx context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error)
+
+// Model programmable model of fake server
+type Model struct {
+	// Name model name
+	Name string
+	// Version model version, default is DefaultModelVersion
+	Version string
+	// Config model config, default is a config with Name only
+	Config *nvidia_inferenceserver.ModelConfig
+	// Handler infer handler, request without handler returns empty outputs
+	Handler ModelHandler
+	// Latency injected latency of every infer request
+	Latency time.Duration
+	// LoadDelay model becomes ready after LoadDelay since load request, 0 means ready immediately
+	LoadDelay time.Duration
+	// LoadBlock if not nil, model of load request becomes ready when LoadBlock is closed (after LoadDelay)
+	LoadBlock <-chan struct{}
+}
+
+// modelState model with runtime state
+type modelState struct {
+	model        *Model
+	ready        bool
+	inferCount   uint64
+	successCount uint64
+	failCount    uint64
+	lastInfer    time.Time
+	// loadGeneration increased by every load / unload request, used by delayed load
+	loadGeneration uint64
+	// repositoryParameters parameters of the last load / unload request
+	repositoryParameters map[string]*nvidia_inferenceserver.ModelRepositoryParameter
+}
+
+// Server in-process fake triton server
+type Server struct {
+	lock        sync.RWMutex
+	models      map[string]*modelState
+	modelOrder  []string
+	serverLive  bool
+	serverReady bool
+	latency     time.Duration
+	errors      map[string]error
+
+	systemSharedMemory map[string]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus
+
+	httpListener net.Listener
+	httpServer   *fasthttp.Server
+	grpcListener net.Listener
+	grpcServer   *grpc.Server
+}
+
+// NewServer create a live and ready fake server without models, call Start to serve
+func NewServer() *Server {
+	return &Server{
+		models:      make(map[string]*modelState),
+		serverLive:  true,
+		serverReady: true,
+		errors:      make(map[string]error),
+
+		systemSharedMemory: make(map[string]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus),
+	}
+}
+
+// StartServer create and start a fake server with models
+func StartServer(models ...*Model) (*Server, error) {
+	server := NewServer()
+	for _, model := range models {
+		server.AddModel(model)
+	}
+	if startErr := server.Start(); startErr != nil {
+		return nil, startErr
+	}
+	return server, nil
+}
+
+// AddModel register a ready model, model with the same name will be replaced
+func (s *Server) AddModel(model *Model) *Server {
+	if model.Version == "" {
+		model.Version = DefaultModelVersion
+	}
+	if model.Config == nil {
+		model.Config = &nvidia_inferenceserver.ModelConfig{Name: model.Name}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.models[model.Name]; !ok {
+		s.modelOrder = append(s.modelOrder, model.Name)
+	}
+	s.models[model.Name] = &modelState{model: model, ready: true}
+	return s
+}
+
+// SetServerLive toggle server liveness
+func (s *Server) SetServerLive(isLive bool) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.serverLive = isLive
+	return s
+}
+
+// SetServerReady toggle server readiness
+func (s *Server) SetServerReady(isReady bool) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.serverReady = isReady
+	return s
+}
+
+// SetModelReady toggle model readiness, not ready model rejects infer request
+func (s *Server) SetModelReady(modelName string, isReady bool) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if state, ok := s.models[modelName]; ok {
+		state.ready = isReady
+	}
+	return s
+}
+
+// SetLatency inject latency for every request
+func (s *Server) SetLatency(latency time.Duration) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.latency = latency
+	return s
+}
+
+// InjectError make operation fail with err until ClearError is called.
+// operation is the triton API name same as GRPC method name, like ServerReady / ModelInfer / ModelConfig.
+// err should be created by status.Error to set error code, other errors are treated as codes.Internal.
+func (s *Server) InjectError(operation string, err error) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.errors[operation] = err
+	return s
+}
+
+// ClearError remove injected error of operation
+func (s *Server) ClearError(operation string) *Server {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.errors, operation)
+	return s
+}
+
+// HTTPAddr address of http server, like 127.0.0.1:8000
+func (s *Server) HTTPAddr() string {
+	return s.httpListener.Addr().String()
+}
+
+// GRPCAddr address of grpc server, like 127.0.0.1:8001
+func (s *Server) GRPCAddr() string {
+	return s.grpcListener.Addr().String()
+}
+
+// Start serve http and grpc on random local ports
+func (s *Server) Start() error {
+	return s.start(nil)
+}
+
+// StartTLS serve https and grpc with TLS on random local ports, set tlsConfig.ClientAuth to require client
+// certificate (mutual-TLS)
+func (s *Server) StartTLS(tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return errors.New("tls config is nil")
+	}
+	return s.start(tlsConfig)
+}
+
+// start serve http and grpc, with TLS if tlsConfig is not nil
+func (s *Server) start(tlsConfig *tls.Config) error {
+	httpListener, listenErr := net.Listen("tcp", localListenAddress)
+	if listenErr != nil {
+		return listenErr
+	}
+	grpcListener, listenErr := net.Listen("tcp", localListenAddress)
+	if listenErr != nil {
+		_ = httpListener.Close()
+		return listenErr
+	}
+	var grpcOpts []grpc.ServerOption
+	if tlsConfig != nil {
+		httpListener = tls.NewListener(httpListener, tlsConfig)
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s.httpListener, s.grpcListener = httpListener, grpcListener
+	// connection errors (like rejected TLS handshakes) are expected in tests, do not log them
+	s.httpServer = &fasthttp.Server{Handler: s.handleHTTP, Logger: log.New(io.Discard, "", 0)}
+	s.grpcServer = grpc.NewServer(grpcOpts...)
+	nvidia_inferenceserver.RegisterGRPCInferenceServiceServer(s.grpcServer, &grpcService{server: s})
+	go func() { _ = s.httpServer.Serve(httpListener) }()
+	go func() { _ = s.grpcServer.Serve(grpcListener) }()
+	return nil
+}
+
+// Close stop http and grpc server
+func (s *Server) Close() {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.httpServer != nil {
+		_ = s.httpServer.Shutdown()
+	}
+}
+
+// NewHTTPClient create triton client connected to http server
+func (s *Server) NewHTTPClient(opts ...nvidia_inferenceserver.TritonClientOption) *nvidia_inferenceserver.TritonClientService {
+	return nvidia_inferenceserver.NewTritonClientWithOnlyHttp(s.HTTPAddr(), &fasthttp.Client{}, opts...)
+}
+
+// NewGRPCClient create triton client connected to grpc server
+func (s *Server) NewGRPCClient(opts ...nvidia_inferenceserver.TritonClientOption) (*nvidia_inferenceserver.TritonClientService, error) {
+	grpcConn, dialErr := s.DialGRPC()
+	if dialErr != nil {
+		return nil, dialErr
+	}
+	return nvidia_inferenceserver.NewTritonClientWithOnlyGRPC(grpcConn, opts...), nil
+}
+
+// DialGRPC create insecure grpc connection to grpc server
+func (s *Server) DialGRPC() (*grpc.ClientConn, error) {
+	return grpc.Dial(s.GRPCAddr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// InferCount count of infer requests received by model
+func (s *Server) InferCount(modelName string) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if state, ok := s.models[modelName]; ok {
+		return state.inferCount
+	}
+	return 0
+}
+
+// StaticOutputHandler handler always returns tensors as outputs
+func StaticOutputHandler(tensors ...*nvidia_inferenceserver.InferTensor) ModelHandler {
+	return func(_ context.Context, _ *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+		response := &nvidia_inferenceserver.ModelInferResponse{
+			Outputs:           make([]*nvidia_inferenceserver.ModelInferResponse_InferOutputTensor, len(tensors)),
+			RawOutputContents: make([][]byte, len(tensors)),
+		}
+		for i, tensor := range tensors {
+			response.Outputs[i] = &nvidia_inferenceserver.ModelInferResponse_InferOutputTensor{
+				Name:     tensor.Name,
+				Datatype: tensor.Datatype,
+				Shape:    tensor.Shape,
+			}
+			response.RawOutputContents[i] = tensor.Raw
+		}
+		return response, nil
+	}
+}
+
+// wait sleep for the injected latency, return early when ctx is done
+func wait(ctx context.Context, latency time.Duration) error {
+	if latency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// before apply injected latency and error of operation
+func (s *Server) before(ctx context.Context, operation string) error {
+	s.lock.RLock()
+	latency, injectedErr := s.latency, s.errors[operation]
+	s.lock.RUnlock()
+
+	if waitErr := wait(ctx, latency); waitErr != nil {
+		return waitErr
+	}
+	return injectedErr
+}
+
+// getModel get model state by name and version, version "" matches any version
+func (s *Server) getModel(modelName, modelVersion string) (*modelState, error) {
+	state, ok := s.models[modelName]
+	if !ok || (modelVersion != "" && modelVersion != state.model.Version) {
+		return nil, status.Error(codes.NotFound, "Request for unknown model: '"+modelName+"' version "+modelVersion+" is not found")
+	}
+	return state, nil
+}
+
+// isServerLive server liveness
+func (s *Server) isServerLive(ctx context.Context) (bool, error) {
+	if beforeErr := s.before(ctx, "ServerLive"); beforeErr != nil {
+		return false, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.serverLive, nil
+}
+
+// isServerReady server readiness
+func (s *Server) isServerReady(ctx context.Context) (bool, error) {
+	if beforeErr := s.before(ctx, "ServerReady"); beforeErr != nil {
+		return false, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.serverReady, nil
+}
+
+// isModelReady model readiness
+func (s *Server) isModelReady(ctx context.Context, modelName, modelVersion string) (bool, error) {
+	if beforeErr := s.before(ctx, "ModelReady"); beforeErr != nil {
+		return false, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	state, getErr := s.getModel(modelName, modelVersion)
+	if getErr != nil {
+		return false, getErr
+	}
+	return state.ready, nil
+}
+
+// serverMetadata server metadata
+func (s *Server) serverMetadata(ctx context.Context) (*nvidia_inferenceserver.ServerMetadataResponse, error) {
+	if beforeErr := s.before(ctx, "ServerMetadata"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	return &nvidia_inferenceserver.ServerMetadataResponse{
+		Name:       DefaultServerName,
+		Version:    DefaultServerVersion,
+		Extensions: []string{"model_repository", "model_configuration", "statistics", "binary_tensor_data"},
+	}, nil
+}
+
+// configDataTypeToTensorDataType TYPE_INT32 -> INT32, TYPE_STRING -> BYTES
+func configDataTypeToTensorDataType(dataType nvidia_inferenceserver.DataType) string {
+	if dataType == nvidia_inferenceserver.DataType_TYPE_STRING {
+		return nvidia_inferenceserver.TritonDataTypeBytes
+	}
+	return strings.TrimPrefix(dataType.String(), "TYPE_")
+}
+
+// modelMetadata model metadata generated from model config
+func (s *Server) modelMetadata(ctx context.Context, modelName, modelVersion string) (*nvidia_inferenceserver.ModelMetadataResponse, error) {
+	if beforeErr := s.before(ctx, "ModelMetadata"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	state, getErr := s.getModel(modelName, modelVersion)
+	if getErr != nil {
+		return nil, getErr
+	}
+	config := state.model.Config
+	metadata := &nvidia_inferenceserver.ModelMetadataResponse{
+		Name:     state.model.Name,
+		Versions: []string{state.model.Version},
+		Platform: config.Platform,
+	}
+	if config.Backend != "" && metadata.Platform == "" {
+		metadata.Platform = config.Backend
+	}
+	for _, input := range config.Input {
+		metadata.Inputs = append(metadata.Inputs, &nvidia_inferenceserver.ModelMetadataResponse_TensorMetadata{
+			Name: input.Name, Datatype: configDataTypeToTensorDataType(input.DataType), Shape: input.Dims,
+		})
+	}
+	for _, output := range config.Output {
+		metadata.Outputs = append(metadata.Outputs, &nvidia_inferenceserver.ModelMetadataResponse_TensorMetadata{
+			Name: output.Name, Datatype: configDataTypeToTensorDataType(output.DataType), Shape: output.Dims,
+		})
+	}
+	return metadata, nil
+}
+
+// modelConfig model config
+func (s *Server) modelConfig(ctx context.Context, modelName, modelVersion string) (*nvidia_inferenceserver.ModelConfig, error) {
+	if beforeErr := s.before(ctx, "ModelConfig"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	state, getErr := s.getModel(modelName, modelVersion)
+	if getErr != nil {
+		return nil, getErr
+	}
+	return state.model.Config, nil
+}
+
+// modelStatistics infer statistics of model, all models if modelName is empty
+func (s *Server) modelStatistics(ctx context.Context, modelName, modelVersion string) (*nvidia_inferenceserver.ModelStatisticsResponse, error) {
+	if beforeErr := s.before(ctx, "ModelStatistics"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	names := s.modelOrder
+	if modelName != "" {
+		if _, getErr := s.getModel(modelName, modelVersion); getErr != nil {
+			return nil, getErr
+		}
+		names = []string{modelName}
+	}
+	response := new(nvidia_inferenceserver.ModelStatisticsResponse)
+	for _, name := range names {
+		state := s.models[name]
+		stats := &nvidia_inferenceserver.ModelStatistics{
+			Name:           state.model.Name,
+			Version:        state.model.Version,
+			InferenceCount: state.inferCount,
+			ExecutionCount: state.inferCount,
+			InferenceStats: &nvidia_inferenceserver.InferStatistics{
+				Success: &nvidia_inferenceserver.StatisticDuration{Count: state.successCount},
+				Fail:    &nvidia_inferenceserver.StatisticDuration{Count: state.failCount},
+			},
+		}
+		if !state.lastInfer.IsZero() {
+			stats.LastInference = uint64(state.lastInfer.UnixMilli())
+		}
+		response.ModelStats = append(response.ModelStats, stats)
+	}
+	return response, nil
+}
+
+// repositoryIndex index of all models
+func (s *Server) repositoryIndex(ctx context.Context, isReady bool) ([]*nvidia_inferenceserver.RepositoryIndexResponse_ModelIndex, error) {
+	if beforeErr := s.before(ctx, "RepositoryIndex"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	index := make([]*nvidia_inferenceserver.RepositoryIndexResponse_ModelIndex, 0, len(s.modelOrder))
+	for _, name := range s.modelOrder {
+		state := s.models[name]
+		if isReady && !state.ready {
+			continue
+		}
+		modelIndex := &nvidia_inferenceserver.RepositoryIndexResponse_ModelIndex{
+			Name: state.model.Name, Version: state.model.Version, State: "READY",
+		}
+		if !state.ready {
+			modelIndex.State, modelIndex.Reason = "UNAVAILABLE", "unloaded"
+		}
+		index = append(index, modelIndex)
+	}
+	return index, nil
+}
+
+// RepositoryParameters parameters of the last load / unload request of model
+func (s *Server) RepositoryParameters(modelName string) map[string]*nvidia_inferenceserver.ModelRepositoryParameter {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if state, ok := s.models[modelName]; ok {
+		return state.repositoryParameters
+	}
+	return nil
+}
+
+// loadModel mark registered model ready (after LoadDelay / LoadBlock), "config" parameter of load request replaces model config
+func (s *Server) loadModel(
+	ctx context.Context, modelName string, isLoad bool, params map[string]*nvidia_inferenceserver.ModelRepositoryParameter,
+) error {
+	operation := "RepositoryModelUnload"
+	if isLoad {
+		operation = "RepositoryModelLoad"
+	}
+	if beforeErr := s.before(ctx, operation); beforeErr != nil {
+		return beforeErr
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state, getErr := s.getModel(modelName, "")
+	if getErr != nil {
+		if isLoad {
+			return status.Error(codes.InvalidArgument, "failed to load '"+modelName+"', failed to poll from model repository")
+		}
+		return getErr
+	}
+	if configParam, ok := params[nvidia_inferenceserver.ModelLoadConfigParamKey]; ok && isLoad {
+		config := new(nvidia_inferenceserver.ModelConfig)
+		if jsonDecodeErr := protojson.Unmarshal([]byte(configParam.GetStringParam()), config); jsonDecodeErr != nil {
+			return status.Error(codes.InvalidArgument, "failed to load '"+modelName+"', invalid config: "+jsonDecodeErr.Error())
+		}
+		if config.Name == "" {
+			config.Name = modelName
+		}
+		state.model.Config = config
+	}
+	state.loadGeneration++
+	state.repositoryParameters = params
+	if !isLoad || (state.model.LoadDelay <= 0 && state.model.LoadBlock == nil) {
+		state.ready = isLoad
+		return nil
+	}
+	state.ready = false
+	loadGeneration := state.loadGeneration
+	go func(loadDelay time.Duration, loadBlock <-chan struct{}) {
+		time.Sleep(loadDelay)
+		if loadBlock != nil {
+			<-loadBlock
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		// ignore if model is unloaded or reloaded during delay
+		if state.loadGeneration == loadGeneration {
+			state.ready = true
+		}
+	}(state.model.LoadDelay, state.model.LoadBlock)
+	return nil
+}
+
+// infer call model handler with injected latency and error
+func (s *Server) infer(ctx context.Context, request *nvidia_inferenceserver.ModelInferRequest) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	if beforeErr := s.before(ctx, "ModelInfer"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	s.lock.Lock()
+	state, getErr := s.getModel(request.ModelName, request.ModelVersion)
+	if getErr != nil {
+		s.lock.Unlock()
+		return nil, getErr
+	}
+	state.inferCount++
+	state.lastInfer = time.Now()
+	model, isReady := state.model, state.ready
+	s.lock.Unlock()
+
+	response, inferErr := s.doInfer(ctx, model, isReady, request)
+
+	s.lock.Lock()
+	if inferErr != nil {
+		state.failCount++
+	} else {
+		state.successCount++
+	}
+	s.lock.Unlock()
+	return response, inferErr
+}
+
+// doInfer check readiness and call model handler
+func (s *Server) doInfer(
+	ctx context.Context, model *Model, isReady bool, request *nvidia_inferenceserver.ModelInferRequest,
+) (*nvidia_inferenceserver.ModelInferResponse, error) {
+	if !isReady {
+		return nil, status.Error(codes.Unavailable, "Request for unknown model: '"+model.Name+"' is not ready")
+	}
+	if waitErr := wait(ctx, model.Latency); waitErr != nil {
+		return nil, waitErr
+	}
+	response := new(nvidia_inferenceserver.ModelInferResponse)
+	if model.Handler != nil {
+		var handleErr error
+		if response, handleErr = model.Handler(ctx, request); handleErr != nil {
+			return nil, handleErr
+		}
+		if response == nil {
+			return nil, status.Error(codes.Internal, "handler of model '"+model.Name+"' returns nil response")
+		}
+	}
+	response.ModelName, response.ModelVersion, response.Id = model.Name, model.Version, request.Id
+	return response, nil
+}
+
+// toStatusError convert error to grpc status error
+func toStatusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,92 @@
+package tritontest
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sunhailin-Leo/triton-service-go/nvidia_inferenceserver"
+)
+
+// SystemSharedMemoryRegion get registered system shared memory region by name
+func (s *Server) SystemSharedMemoryRegion(
+	regionName string,
+) (*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	region, ok := s.systemSharedMemory[regionName]
+	return region, ok
+}
+
+// systemSharedMemoryStatus status of region, all regions if regionName is empty
+func (s *Server) systemSharedMemoryStatus(
+	ctx context.Context, regionName string,
+) (map[string]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus, error) {
+	if beforeErr := s.before(ctx, "SystemSharedMemoryStatus"); beforeErr != nil {
+		return nil, beforeErr
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	regions := make(map[string]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus)
+	for name, region := range s.systemSharedMemory {
+		if regionName == "" || regionName == name {
+			regions[name] = region
+		}
+	}
+	if regionName != "" && len(regions) == 0 {
+		return nil, status.Error(codes.NotFound, "Unable to find system shared memory region: '"+regionName+"'")
+	}
+	return regions, nil
+}
+
+// sortedSystemSharedMemoryRegions regions sorted by name, used by HTTP status
+func sortedSystemSharedMemoryRegions(
+	regions map[string]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus,
+) []*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus {
+	sortedRegions := make([]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus, 0, len(regions))
+	for _, region := range regions {
+		sortedRegions = append(sortedRegions, region)
+	}
+	sort.Slice(sortedRegions, func(i, j int) bool { return sortedRegions[i].Name < sortedRegions[j].Name })
+	return sortedRegions
+}
+
+// registerSystemSharedMemory record region, the memory is not mapped by fake server
+func (s *Server) registerSystemSharedMemory(
+	ctx context.Context, regionName, key string, offset, byteSize uint64,
+) error {
+	if beforeErr := s.before(ctx, "SystemSharedMemoryRegister"); beforeErr != nil {
+		return beforeErr
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.systemSharedMemory[regionName]; ok {
+		return status.Error(codes.AlreadyExists,
+			"shared memory region '"+regionName+"' already in manager")
+	}
+	s.systemSharedMemory[regionName] = &nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus{
+		Name: regionName, Key: key, Offset: offset, ByteSize: byteSize,
+	}
+	return nil
+}
+
+// unregisterSystemSharedMemory remove region, all regions if regionName is empty
+func (s *Server) unregisterSystemSharedMemory(ctx context.Context, regionName string) error {
+	if beforeErr := s.before(ctx, "SystemSharedMemoryUnregister"); beforeErr != nil {
+		return beforeErr
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if regionName == "" {
+		s.systemSharedMemory = make(map[string]*nvidia_inferenceserver.SystemSharedMemoryStatusResponse_RegionStatus)
+		return nil
+	}
+	delete(s.systemSharedMemory, regionName)
+	return nil
+}
@@ -163,9 +163,14 @@ var BertChineseChar = &unicode.RangeTable{
 	},
 }
 
-// StringSliceTruncate truncate uses heuristic of trimming seq with longest len until sequenceLen satisfied
+// StringSliceTruncate truncate uses heuristic of trimming seq with longest len until sequenceLen satisfied,
+// sequenceLen is the total length of all seqs.
 func StringSliceTruncate(sequence [][]string, maxLen int) [][]string {
-	for sequenceLen := len(sequence[0]); sequenceLen > maxLen; sequenceLen-- {
+	var sequenceLen int
+	for _, seq := range sequence {
+		sequenceLen += len(seq)
+	}
+	for ; sequenceLen > maxLen; sequenceLen-- {
 		// Sort to get the longest first
 		var mi, mv int
 		for i := len(sequence) - 1; i >= 0; i-- {